@@ -1,8 +1,12 @@
 package pilot
 
 import (
+	"errors"
 	"goat/shed"
+	"goat/tractor"
+	"goat/vroom"
 	"log"
+	"os"
 	"time"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -27,26 +31,48 @@ type Drawing struct {
 	bgColor      Color        // Background color. The color of the "paper"
 	scaleX       float32      // How big (in pixels) are the virtual pixels in the X direction.
 	scaleY       float32      // How big (in pixels) are the virtual pixels in the Y direction.
+	strokeWidth  float32      // Outline thickness (in virtual pixels) of circles and ellipses. Zero means they are filled
 	frameRateCap float64      // Maximum allowed number of updates per second. - During this delay no events are processed.
 	stack        []*Drawing   // The stack that allows us to store and recall colors, scales, and other such settings.
 	frameCount   uint64       // The number of calls to draw(). Starts at 1
 	paused       bool         // don't draw until Unpause() is called
 	createdAt    uint64
+	audio        *vroom.Mixer          // Plays sound effects. nil means the script has no sound
+	audioPath    string                // Where the script's sound files are
+	tweens       *tractor.TweenManager // Tweens started by the script. Every lua state gets its own
+	physics      *tractor.PhysicsWorld // Bodies made by the script. Every lua state gets its own
+	pacer        *tractor.FramePacer   // Waits between frames, to keep to frameRateCap
+	mouse        *tractor.Mouse        // The mouse of the window
+	keyboard     *tractor.Keyboard     // The keyboard of the window, for typing
+
+	WatchScript     bool      // Reload the script when the file changes. On by default
+	scriptModTime   time.Time // modification time of the script file when it was loaded
+	scriptCheckedAt time.Time // when we last looked at the script file
+	loadError       error     // The script could not be (re)loaded. Shown on screen. The previous version keeps running
+	runtimeError    error     // The script failed while running. Shown on screen. The script is not called until it is reloaded
+
+	rects *tractor.BasicRectRenderer // Draws dots, lines and rectangles
+	prims *tractor.PrimitiveRenderer // Draws polygons
+	paths map[string]*shed.Path      // SVG paths drawn by the script, parsed. Up to maxCachedPaths
+
+	builtinGlobals map[lua.LValue]bool // the globals of the lua state before the script ran
 
 	keydownCallback *lua.LFunction
 	keyupCallback   *lua.LFunction
 }
 
-func CreateDrawing(window *glfw.Window, scriptFile string) *Drawing {
-
-	script := lua.NewState()
+// Draw with a script, in a window. keyboard and mouse are the window's: the engine's (Controls.Keyboard(),
+// Controls.Mouse()), or the drawing would take their callbacks away. The drawing handles their key events,
+// and ends their frame after every draw.
+//
+// With a mixer, the script can play sounds from audioPath (see vroom.Mixer.ExportToLua), from its very first
+// line on. The mixer is advanced by the time between frames. nil leaves the script without sound.
+func CreateDrawing(window *glfw.Window, keyboard *tractor.Keyboard, mouse *tractor.Mouse, scriptFile string, audio *vroom.Mixer, audioPath string) *Drawing {
 
 	glfw.SetTime(0)
 	dm := &Drawing{
 		window:       window,
-		script:       script,
 		scriptFile:   scriptFile,
-		drawFunc:     script.GetGlobal("Draw"),
 		nowTime:      0.0,
 		prevTime:     0.0,
 		deltaTime:    0.0,
@@ -57,12 +83,33 @@ func CreateDrawing(window *glfw.Window, scriptFile string) *Drawing {
 		frameRateCap: -1.0,
 		stack:        make([]*Drawing, 0),
 		createdAt:    uint64(time.Now().UnixMilli()),
+		WatchScript:  true,
+		rects:        tractor.CreateBasicRectRenderer("shaders/rect"),
+		prims:        tractor.CreatePrimitiveRenderer("shaders/rect"),
+		paths:        make(map[string]*shed.Path),
+		pacer:        tractor.CreateFramePacer(glfw.GetTime, func(t float64) { tractor.SleepUntil(glfw.GetTime, t) }),
+		mouse:        mouse,
+		keyboard:     keyboard,
+		audio:        audio,
+		audioPath:    audioPath,
 	}
 
-	dm.loadLuaScript()
+	keyboard.Handle(func(ke *tractor.KeyEvent) {
+		switch {
+		case ke.Pressed || ke.Repeated:
+			dm.onKeydown(ke)
+		case ke.Released:
+			dm.onKeyup(ke)
+		}
+	})
+
+	dm.rects.Finalize()
+	dm.prims.Finalize()
 
-	dm.keydownCallback = luaFuncOrNil(script.GetGlobal("Keydown"))
-	dm.keyupCallback = luaFuncOrNil(script.GetGlobal("Keyup"))
+	if err := dm.loadLuaScript(); err != nil {
+		log.Println(err)
+		dm.loadError = err
+	}
 
 	return dm
 }
@@ -77,19 +124,102 @@ func (dm *Drawing) CallSetupFunc() {
 		panic("This should never happen")
 	}
 
-	setupFunc := dm.script.GetGlobal("Setup")
-
-	luaInvokeFunc("Setup()", dm.script, setupFunc)
+	dm.callLua("Setup()", dm.script.GetGlobal("Setup"))
 }
 
-func (dm *Drawing) loadLuaScript() {
+// Load the script into a fresh lua state, and find its callbacks.
+// If the script cannot be loaded, the current state is kept.
+func (dm *Drawing) loadLuaScript() error {
+
+	if info, err := os.Stat(dm.scriptFile); err == nil {
+		dm.scriptModTime = info.ModTime()
+	}
 
-	dm.setupLuaFunctions()
+	old, oldTweens, oldPhysics := dm.script, dm.tweens, dm.physics
+	dm.script = lua.NewState()
+	dm.tweens = tractor.CreateTweenManager()
+	dm.physics = tractor.CreatePhysicsWorld(64)
+	activate := dm.setupLuaFunctions()
+	builtins := luaGlobalNames(dm.script)
 
 	if err := dm.script.DoFile(dm.scriptFile); err != nil {
-		panic(err)
+		if old != nil {
+			dm.script.Close()
+			dm.script, dm.tweens, dm.physics = old, oldTweens, oldPhysics
+		}
+		return err
 	}
+	activate() // the old script's mouse and keyboard handlers are replaced only now
+	dm.builtinGlobals = builtins
+
 	dm.drawFunc = dm.script.GetGlobal("Draw")
+	dm.keydownCallback = luaFuncOrNil(dm.script.GetGlobal("Keydown"))
+	dm.keyupCallback = luaFuncOrNil(dm.script.GetGlobal("Keyup"))
+
+	return nil
+}
+
+// Reload the script from disk, into a fresh lua state.
+//
+// If the new script has a Reload(old) function, it is called with a table
+// of the global variables the old script made (functions excluded, also inside
+// tables), so it can carry its state over. Otherwise Setup() is called again.
+//
+// If the new script cannot be loaded, the old one keeps running and the error is shown on screen.
+func (dm *Drawing) ReloadScript() error {
+	old := dm.script
+
+	if err := dm.loadLuaScript(); err != nil {
+		log.Println(err)
+		dm.loadError = err
+		return err
+	}
+
+	log.Printf("Reloaded %s", dm.scriptFile)
+	dm.loadError = nil
+	dm.runtimeError = nil
+
+	if reload := dm.script.GetGlobal("Reload"); luaFuncOrNil(reload) != nil {
+		dm.callLua("Reload()", reload, luaGlobalsTable(old, dm.script, dm.builtinGlobals))
+	} else {
+		dm.callLua("Setup()", dm.script.GetGlobal("Setup"))
+	}
+
+	if old != nil {
+		old.Close()
+	}
+
+	return nil
+}
+
+// Reload the script if the file has changed. Checks the file at most 4 times per second.
+func (dm *Drawing) reloadScriptIfChanged() {
+	if !dm.WatchScript || time.Since(dm.scriptCheckedAt) < 250*time.Millisecond {
+		return
+	}
+	dm.scriptCheckedAt = time.Now()
+
+	info, err := os.Stat(dm.scriptFile)
+	if err != nil || info.ModTime().Equal(dm.scriptModTime) {
+		return
+	}
+
+	dm.ReloadScript()
+}
+
+// Call a function in the script.
+// Errors are shown on screen, and the script is not called again until it has been reloaded.
+func (dm *Drawing) callLua(context string, fn lua.LValue, args ...lua.LValue) {
+	if dm.runtimeError != nil {
+		return
+	}
+
+	err := luaInvokeFunc(context, dm.script, fn, args...)
+	if err != nil && !errors.Is(err, errNotAFunction) {
+		log.Println(err)
+		dm.runtimeError = err
+		dm.stack = dm.stack[:0] // the script never got to call Pop()
+	}
 }
 
 // Do the CallDrawFunc phase of the game loop.
@@ -101,6 +231,8 @@ func (dm *Drawing) CallDrawFunc() {
 	//********************************************
 	/// RENDERER dm.renderer.Present() // TODO if autopresent
 
+	dm.reloadScriptIfChanged()
+
 	// The number of times draw() has been called so far.
 	dm.frameCount++
 
@@ -109,6 +241,17 @@ func (dm *Drawing) CallDrawFunc() {
 	dm.nowTime = glfw.GetTime()             // number of seconds since program started
 	dm.deltaTime = dm.nowTime - dm.prevTime // number of seconds since last update
 
+	if dm.audio != nil {
+		if err := dm.audio.Advance(dm.deltaTime); err != nil {
+			log.Printf("Audio error: %v", err)
+		}
+	}
+
+	if !dm.paused && dm.runtimeError == nil {
+		dm.tweens.Advance(float32(dm.deltaTime))
+		dm.physics.Advance(float32(dm.deltaTime))
+	}
+
 	shed.ClearScreenI(dm.bgColor.R, dm.bgColor.G, dm.bgColor.B, dm.bgColor.A)
 
 	//
@@ -116,7 +259,11 @@ func (dm *Drawing) CallDrawFunc() {
 	// Call the Draw() function
 	//********************************************
 	if !dm.paused {
-		luaInvokeFunc("Draw()", dm.script, dm.drawFunc)
+		dm.callLua("Draw()", dm.drawFunc)
+	}
+
+	if err := dm.scriptError(); err != nil {
+		dm.drawErrorOverlay(err)
 	}
 
 	dm.window.SwapBuffers()
@@ -138,39 +285,45 @@ func (dm *Drawing) CallDrawFunc() {
 	// longer delays should be handled
 	// with the Sleep() method.
 	//********************************************
-	if dm.frameRateCap <= 0 {
-		return
-	}
+	dm.pacer.TargetFPS = dm.frameRateCap
+	dm.pacer.Wait()
 
-	// TODO: implement delay for framerate cap
-	now := glfw.GetTime()
-	elapsed := now - dm.nowTime
-	secPerFrame := 1.0 / dm.frameRateCap
+	dm.mouse.EndFrame()
+	dm.keyboard.EndFrame()
+}
 
-	if elapsed > secPerFrame {
-		return
+// Mouse positions are in window pixels. The script wants virtual pixels, like everything else it sees.
+// Windows on high-dpi screens have more pixels in their framebuffer than the window is big.
+func (dm *Drawing) mouseToScript(pixel shed.V2) shed.V2 {
+	fw, fh := dm.window.GetFramebufferSize()
+	ww, wh := dm.window.GetSize()
+	if ww == 0 || wh == 0 {
+		return pixel
 	}
 
-	// Sleep() ignores negative values, so no need to check for them here
-	timeToSleep := secPerFrame - elapsed
-	time.Sleep(time.Duration(timeToSleep) * time.Second)
-
-	glfw.SwapInterval(1) // VSync:   1 = ON, 0 = OFF - negative values are allowed on certain GPUs
-	dm.window.SwapBuffers()
+	return shed.Vec2(
+		pixel.X*float32(fw)/float32(ww)/dm.scaleX,
+		pixel.Y*float32(fh)/float32(wh)/dm.scaleY,
+	)
 }
 
 // triggered whenever our game loop receives a keydown event
-func (dm *Drawing) onKeydown(ke *KeyEvent) {
-	luaInvokeFunc("Keydown", dm.script, dm.keydownCallback, luar.New(dm.script, ke))
+func (dm *Drawing) onKeydown(ke *tractor.KeyEvent) {
+	if dm.keydownCallback != nil {
+		dm.callLua("Keydown", dm.keydownCallback, luar.New(dm.script, ke))
+	}
 }
 
 // triggered whenever our game loop receives a keyup event
-func (dm *Drawing) onKeyup(ke *KeyEvent) {
-	luaInvokeFunc("Keyup", dm.script, dm.keyupCallback, luar.New(dm.script, ke))
+func (dm *Drawing) onKeyup(ke *tractor.KeyEvent) {
+	if dm.keyupCallback != nil {
+		dm.callLua("Keyup", dm.keyupCallback, luar.New(dm.script, ke))
+	}
 }
 
-// Functions sare injected into the script only once.
-func (dm *Drawing) setupLuaFunctions() {
+// Functions are injected into every lua state the script is loaded into.
+// activate() hands the mouse and keyboard to the state. Call it once the script has loaded.
+func (dm *Drawing) setupLuaFunctions() (activate func()) {
 
 	fun := func(name string, value interface{}) {
 		dm.script.SetGlobal(name, luar.New(dm.script, value))
@@ -180,6 +333,7 @@ func (dm *Drawing) setupLuaFunctions() {
 	fun("WinTitle", dm.WinTitle)
 	fun("ProcessEvents", dm.ProcessEvents)
 	fun("FrameRateCap", dm.FrameRateCap)
+	fun("VSync", dm.VSync)
 	fun("Delta", func() float64 {
 		return float64(dm.deltaTime) / 1000
 	})
@@ -204,6 +358,12 @@ func (dm *Drawing) setupLuaFunctions() {
 	fun("Line", dm.Line)
 	fun("Rectangle", dm.Rectangle)
 	fun("Polygon", dm.Polygon)
+	fun("StrokeWidth", dm.StrokeWidth)
+	fun("Circle", dm.Circle)
+	fun("Ellipse", dm.Ellipse)
+	fun("Arc", dm.Arc)
+	fun("Pie", dm.Pie)
+	fun("Path", dm.Path)
 	fun("PolarVector", CreatePolarLuaVector)
 	fun("Vector", CreateLuaVector)
 
@@ -212,4 +372,22 @@ func (dm *Drawing) setupLuaFunctions() {
 	 ****************************************/
 
 	fun("HasKey", dm.HasKey)
+
+	onError := func(err error) {
+		log.Println(err)
+		dm.runtimeError = err
+	}
+	dm.tweens.ExportToLua(dm.script, onError)
+	dm.physics.ExportToLua(dm.script, onError)
+	activateMouse := dm.mouse.ExportToLua(dm.script, dm.mouseToScript, onError)
+	activateKeyboard := dm.keyboard.ExportToLua(dm.script, onError)
+
+	if dm.audio != nil {
+		dm.audio.ExportToLua(dm.script, dm.audioPath)
+	}
+
+	return func() {
+		activateMouse()
+		activateKeyboard()
+	}
 }
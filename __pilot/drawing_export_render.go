@@ -6,6 +6,9 @@
 package pilot
 
 import (
+	"fmt"
+	"goat/shed"
+	"goat/tractor"
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -42,18 +45,42 @@ func (dm *Drawing) Background(c ...uint8) (uint8, uint8, uint8, uint8) {
 	}
 }
 
+// Set the size (in pixels) of the virtual pixels that all drawing functions use.
 func (dm *Drawing) Scale(scale float32) {
+	dm.scaleX = scale
+	dm.scaleY = scale
 }
 
+// Draw a line, one virtual pixel thick.
 func (dm *Drawing) Line(x1, y1, x2, y2 float64) {
+	dist := shed.V2{X: float32(x2 - x1), Y: float32(y2 - y1)}
+	if dist.Len() == 0 {
+		dm.Dot(x1, y1)
+		return
+	}
+
+	thingMatrix := mgl32.Translate2D(float32(x1+x2)/2, float32(y1+y2)/2).
+		Mul3(mgl32.HomogRotate2D(dist.Angle())).
+		Mul3(mgl32.Scale2D(dist.Len(), 1))
+
+	dm.drawRect(thingMatrix)
 }
 
+// Fill the virtual pixel that has its upper left corner at (x, y)
 func (dm *Drawing) Dot(x, y float64) {
+	dm.drawRect(mgl32.Translate2D(float32(x)+0.5, float32(y)+0.5))
 }
 
+// Fill the rectangle between two corners
 func (dm *Drawing) Rectangle(x1, y1, x2, y2 float64) {
+	thingMatrix := mgl32.Translate2D(float32(x1+x2)/2, float32(y1+y2)/2).
+		Mul3(mgl32.Scale2D(float32(math.Abs(x2-x1)), float32(math.Abs(y2-y1))))
+
+	dm.drawRect(thingMatrix)
 }
 
+// Fill a regular polygon. angle (in radians) is the direction of the first corner.
+// Returns the triangles that were drawn.
 func (dm *Drawing) Polygon(centerX, centerY, radius, angle float64, edgeVertexCount int) []mgl32.Vec3 {
 
 	if edgeVertexCount < 3 {
@@ -62,27 +89,145 @@ func (dm *Drawing) Polygon(centerX, centerY, radius, angle float64, edgeVertexCo
 
 	const tau = 2 * math.Pi
 	radiansPerSlice := tau / float64(edgeVertexCount)
-	triangleCount := edgeVertexCount - 2
-
-	tmp := make([]mgl32.Vec3, edgeVertexCount)
-	for i := 0; i < edgeVertexCount; i++ {
-		currentAngle := radiansPerSlice * float64(i)
-		_sin, _cos := math.Sincos(currentAngle)
-		tmp[i] = mgl32.Vec3{
-			/* x: */ float32(_cos * currentAngle),
-			/* y: */ float32(_sin * currentAngle),
-			/* z: */ 0.0,
+
+	corners := make([]shed.V2, edgeVertexCount)
+	for i := range corners {
+		_sin, _cos := math.Sincos(angle + radiansPerSlice*float64(i))
+		corners[i] = shed.V2{
+			X: float32(centerX + _cos*radius),
+			Y: float32(centerY + _sin*radius),
 		}
 	}
 
-	result := make([]mgl32.Vec3, 0)
-	for i := 0; i < triangleCount; i++ {
-		result = append(result,
-			tmp[i],
-			tmp[i+1],
-			tmp[i+2],
-		)
+	cam := tractor.Engine.GetScreenCamera()
+	dm.prims.DrawConvexPolygon(cam.GetMatrix(), dm.canvasMatrix(cam), corners, dm.inkColor())
+
+	result := make([]mgl32.Vec3, 0, 3*(edgeVertexCount-2))
+	for i := 1; i+1 < edgeVertexCount; i++ {
+		for _, c := range []shed.V2{corners[0], corners[i], corners[i+1]} {
+			result = append(result, mgl32.Vec3{c.X, c.Y, 0})
+		}
 	}
 
 	return result
 }
+
+// Set the thickness (in virtual pixels) of the outline drawn by Circle, Ellipse, Arc, Pie and Path.
+// Zero (the default) fills the shapes instead.
+func (dm *Drawing) StrokeWidth(width float32) {
+	dm.strokeWidth = width
+}
+
+func (dm *Drawing) Circle(centerX, centerY, radius float64) {
+	dm.Ellipse(centerX, centerY, radius, radius)
+}
+
+func (dm *Drawing) Ellipse(centerX, centerY, radiusX, radiusY float64) {
+	dm.drawEllipse(centerX, centerY, radiusX, radiusY, 0, 0, false)
+}
+
+// Draw a part of a circle, from one angle to another (in radians).
+// Filling it fills the area between the curve and the straight line between its ends.
+func (dm *Drawing) Arc(centerX, centerY, radius, startAngle, endAngle float64) {
+	dm.drawEllipse(centerX, centerY, radius, radius, startAngle, endAngle, false)
+}
+
+// Draw a slice of a circle, from one angle to another (in radians).
+func (dm *Drawing) Pie(centerX, centerY, radius, startAngle, endAngle float64) {
+	dm.drawEllipse(centerX, centerY, radius, radius, startAngle, endAngle, true)
+}
+
+func (dm *Drawing) drawEllipse(centerX, centerY, radiusX, radiusY, startAngle, endAngle float64, pie bool) {
+	if radiusX <= 0 || radiusY <= 0 {
+		return
+	}
+
+	cam := tractor.Engine.GetScreenCamera()
+	E := tractor.CreateArc(
+		float32(centerX), float32(centerY),
+		float32(2*radiusX), float32(2*radiusY),
+		float32(startAngle), float32(endAngle),
+		cam, dm.prims,
+	)
+	E.SetPie(pie)
+
+	if dm.strokeWidth > 0 {
+		E.Fill = shed.RGBA(0, 0, 0, 0)
+		E.Stroke = dm.inkColor()
+		E.StrokeWidth = dm.strokeWidth * (mgl32.Abs(dm.scaleX) + mgl32.Abs(dm.scaleY)) / 2 // the outline is built in screen pixels
+	} else {
+		E.Fill = dm.inkColor()
+	}
+
+	E.DrawRelative(dm.canvasMatrix(cam))
+}
+
+// How many parsed paths a drawing keeps
+const maxCachedPaths = 256
+
+// Draw an SVG path (the d attribute of a <path> element), in virtual pixels.
+// Filled, or outlined if StrokeWidth() has been set. rule is "nonzero" (the default) or "evenodd", like SVG's fill-rule.
+// Returns the error if d is not a path, and draws nothing.
+func (dm *Drawing) Path(d string, rule ...string) error {
+	path, found := dm.paths[d]
+	if !found {
+		var err error
+		if path, err = shed.ParseSVGPath(d); err != nil {
+			return err
+		}
+		if len(dm.paths) >= maxCachedPaths {
+			clear(dm.paths) // a script that makes up new paths every frame should not fill the memory
+		}
+		dm.paths[d] = path
+	}
+
+	fillRule := shed.FillNonZero
+	if len(rule) > 0 {
+		switch rule[0] {
+		case "nonzero":
+		case "evenodd":
+			fillRule = shed.FillEvenOdd
+		default:
+			return fmt.Errorf("unknown fill rule '%s'. Use \"nonzero\" or \"evenodd\"", rule[0])
+		}
+	}
+	path.FillRule = fillRule
+
+	cam := tractor.Engine.GetScreenCamera()
+	S := tractor.CreatePathShape(0, 0, path, cam, dm.prims)
+
+	if dm.strokeWidth > 0 {
+		S.Fill = shed.RGBA(0, 0, 0, 0)
+		S.Stroke = dm.inkColor()
+		S.Style = shed.StrokeStyle{Width: dm.strokeWidth, Join: shed.JoinRound, Cap: shed.CapRound}
+	} else {
+		S.Fill = dm.inkColor()
+	}
+
+	S.DrawRelative(dm.canvasMatrix(cam))
+
+	return nil
+}
+
+// Fill the unit quad, transformed by thingMatrix, with the foreground color.
+// thingMatrix is in virtual pixels.
+func (dm *Drawing) drawRect(thingMatrix mgl32.Mat3) {
+	cam := tractor.Engine.GetScreenCamera()
+
+	dm.rects.Draw(cam.GetMatrix(), dm.canvasMatrix(cam).Mul3(thingMatrix), dm.inkColor())
+}
+
+// Transforms virtual pixels into the coordinates of the screen camera.
+// The script has (0, 0) in the upper left corner of the window, and y pointing down.
+func (dm *Drawing) canvasMatrix(cam *tractor.Camera) mgl32.Mat3 {
+	w, h := cam.GetFrameSize()
+
+	return mgl32.Translate2D(-w/2, h/2).Mul3(mgl32.Scale2D(dm.scaleX, -dm.scaleY))
+}
+
+// The foreground color, as the renderers want it
+func (dm *Drawing) inkColor() shed.V4 {
+	c := dm.fgColor
+
+	return shed.RGBA(float32(c.R)/255, float32(c.G)/255, float32(c.B)/255, float32(c.A)/255)
+}
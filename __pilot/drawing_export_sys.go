@@ -121,6 +121,15 @@ func (dm *Drawing) FrameRateCap(val float64) {
 	dm.frameRateCap = val
 }
 
+// Wait for the screen to refresh before showing a frame
+func (dm *Drawing) VSync(on bool) {
+	if on {
+		glfw.SwapInterval(1) // negative values are allowed on certain GPUs
+	} else {
+		glfw.SwapInterval(0)
+	}
+}
+
 func (dm *Drawing) Dump(x ...interface{}) {
 	for i, v := range x {
 		log.Printf("Dump%3d: %+v", i, v)
@@ -0,0 +1,68 @@
+package pilot
+
+import (
+	"goat/shed"
+	"goat/tractor"
+	"strings"
+)
+
+const (
+	errorOverlayFont   = "Bonus/kenvector_future_thin.ttf"
+	errorOverlayMargin = 20
+	errorOverlaySize   = 16
+)
+
+// The error to show on screen, if any.
+// Load errors win, since fixing them is what makes the script run again.
+func (dm *Drawing) scriptError() error {
+	if dm.loadError != nil {
+		return dm.loadError
+	}
+
+	return dm.runtimeError
+}
+
+// Darken the screen, and write the error on top of it.
+func (dm *Drawing) drawErrorOverlay(err error) {
+	cam := tractor.Engine.GetScreenCamera()
+	w, h := cam.GetFrameSize()
+
+	// the screen camera has (0, 0) in the middle of the screen
+	rect := tractor.CreateBasicRect(0, 0, w, h, 0, cam, dm.rects)
+	rect.Color = shed.RGBA(0.1, 0, 0, 0.8)
+	rect.Draw()
+
+	font := tractor.Engine.LoadFont(errorOverlayFont)
+	text := wrapText(font, errorOverlaySize, w-2*errorOverlayMargin, err.Error()+"\n\nSave the script to reload it.")
+
+	x := -w/2 + errorOverlayMargin
+	y := h/2 - errorOverlayMargin - font.Metrics(errorOverlaySize).Ascent
+	font.Draw(cam, errorOverlaySize, x, y, text, shed.RGBA(1, 0.6, 0.6, 1))
+	font.Batch.Flush()
+}
+
+// Break the lines of str at spaces, so no line is wider than maxWidth.
+// Words wider than maxWidth get a line of their own.
+func wrapText(font *tractor.Font, size, maxWidth float32, str string) string {
+	var out []string
+
+	for _, line := range strings.Split(str, "\n") {
+		current := ""
+		for _, word := range strings.Split(line, " ") {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+
+			if w, _ := font.Measure(size, candidate); w > maxWidth && current != "" {
+				out = append(out, current)
+				current = word
+			} else {
+				current = candidate
+			}
+		}
+		out = append(out, current)
+	}
+
+	return strings.Join(out, "\n")
+}
@@ -3,11 +3,14 @@
 package pilot
 
 import (
+	"errors"
 	"fmt"
 
 	lua "github.com/yuin/gopher-lua"
 )
 
+var errNotAFunction = errors.New("fn was not a function")
+
 func luaFuncOrNil(fn lua.LValue) *lua.LFunction {
 	if nil == fn {
 		return nil
@@ -27,19 +30,78 @@ func luaInvokeFunc(context string, script *lua.LState, funcCandidate lua.LValue,
 
 	fn := luaFuncOrNil(funcCandidate)
 	if nil == fn {
-		return fmt.Errorf("%s : fn was not a function", context)
+		return fmt.Errorf("%s : %w", context, errNotAFunction)
 	}
 
 	err := script.CallByParam(lua.P{
 		Fn:      fn,
 		NRet:    0,
-		Protect: false,
-		Handler: &lua.LFunction{},
+		Protect: true,
 	}, args...)
 
 	if err != nil {
-		panic(fmt.Sprintf("Lua error (%s) - %s", context, err))
+		return fmt.Errorf("Lua error (%s) - %s", context, err)
 	}
 
 	return nil
 }
+
+// The names of the global variables of a lua state
+func luaGlobalNames(L *lua.LState) map[lua.LValue]bool {
+	names := map[lua.LValue]bool{}
+	L.G.Global.ForEach(func(key, _ lua.LValue) {
+		names[key] = true
+	})
+	return names
+}
+
+// Copy the global variables a script made in one lua state into a table in another.
+// Globals named in builtins (the standard library, and what we inject) are left out, and so are
+// functions, also inside tables: they belong to the old state.
+func luaGlobalsTable(from, to *lua.LState, builtins map[lua.LValue]bool) *lua.LTable {
+	tbl := to.NewTable()
+
+	if from == nil {
+		return tbl
+	}
+
+	copies := map[*lua.LTable]*lua.LTable{}
+	from.G.Global.ForEach(func(key, value lua.LValue) {
+		if !builtins[key] {
+			if v := luaCopyValue(value, to, copies); v != lua.LNil {
+				tbl.RawSet(key, v)
+			}
+		}
+	})
+
+	return tbl
+}
+
+// Copy tables, without their functions and metatables. Tables that appear twice are copied once.
+// Functions and coroutines become nil
+func luaCopyValue(value lua.LValue, to *lua.LState, copies map[*lua.LTable]*lua.LTable) lua.LValue {
+	switch v := value.(type) {
+	case *lua.LFunction, *lua.LState:
+		return lua.LNil
+
+	case *lua.LTable:
+		if copied, ok := copies[v]; ok {
+			return copied
+		}
+
+		tbl := to.NewTable()
+		copies[v] = tbl
+		v.ForEach(func(key, value lua.LValue) {
+			k := luaCopyValue(key, to, copies)
+			if k == lua.LNil {
+				return
+			}
+			if val := luaCopyValue(value, to, copies); val != lua.LNil {
+				tbl.RawSet(k, val)
+			}
+		})
+		return tbl
+	}
+
+	return value
+}
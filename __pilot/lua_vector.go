@@ -92,6 +92,19 @@ func (vec LuaVector) Rotate(radians float64) LuaVector {
 	)
 }
 
+// Is the point inside (or on the edge of) the rectangle with corners (x1, y1) and (x2, y2)?
+func (vec LuaVector) InRect(x1, y1, x2, y2 float64) bool {
+	minX, maxX := math.Min(x1, x2), math.Max(x1, x2)
+	minY, maxY := math.Min(y1, y2), math.Max(y1, y2)
+
+	return vec.x >= minX && vec.x <= maxX && vec.y >= minY && vec.y <= maxY
+}
+
+// Is the point inside the circle?
+func (vec LuaVector) InCircle(center LuaVector, radius float64) bool {
+	return vec.Sub(center).Len() < radius
+}
+
 // ToString
 func (vec LuaVector) String() string {
 
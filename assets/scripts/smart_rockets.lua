@@ -115,33 +115,11 @@ local function createRocket()
       end,
 
       CollidesWithObstacle = function(self)
-         local X = self.pos:X()
-         local Y = self.pos:Y()
-
-         if X < obstacleRect[1] then
-            return false
-         end
-         if X > obstacleRect[3] then
-            return false
-         end
-         if Y < obstacleRect[2] then
-            return false
-         end
-         if Y > obstacleRect[4] then
-            return false
-         end
-
-         return true
+         return self.pos:InRect(unpack(obstacleRect))
       end,
 
       CollidesWithGoal = function(self)
-         local dist = self.pos:Sub(goalPos):Len()
-
-         if dist < (goalSize + rocketSize) / 2 then
-            return true
-         end
-
-         return false
+         return self.pos:InCircle(goalPos, (goalSize + rocketSize) / 2)
       end,
 
       IsParent = function(self)
@@ -6,8 +6,13 @@ require (
 	github.com/go-gl/gl v0.0.0-20211210172815-726fda9656d6
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b
 	github.com/go-gl/mathgl v1.0.0
+	github.com/jfreymuth/oggvorbis v1.0.5
 	github.com/yuin/gopher-lua v1.1.0
+	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f
 	layeh.com/gopher-luar v1.0.11
 )
 
-require golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
+require (
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
+	golang.org/x/text v0.3.0 // indirect
+)
@@ -20,6 +20,40 @@ func initBasicRect() {
 		gCamera,
 		gMainRectRenderer,
 	)
+
+	// Cycle through red, blue and green, forever
+	third := float32(shed.Tau / 3)
+	gMainRect.Color = shed.RGBA(0.25, 1, 0.25, 1)
+	m.TweenSequence(
+		m.TweenV4(&gMainRect.Color, shed.RGBA(1, 0.25, 0.25, 1), third).Ease(shed.EaseInOutQuad),
+		m.TweenV4(&gMainRect.Color, shed.RGBA(0.25, 0.25, 1, 1), third).Ease(shed.EaseInOutQuad),
+		m.TweenV4(&gMainRect.Color, shed.RGBA(0.25, 1, 0.25, 1), third).Ease(shed.EaseInOutQuad),
+	).Repeat(-1).Start()
+}
+
+// ||=================================================================
+// ||
+// || LINE
+// ||
+// ||=================================================================
+func initMainLine() {
+	lineRenderer := m.CreateLineStripRenderer(LINE_SHADER)
+	lineRenderer.Finalize()
+
+	square := []shed.V2{
+		shed.Vec2(-250, -250), // lower left
+		shed.Vec2(250, -250),  // lower right
+		shed.Vec2(250, 250),   // upper right
+		shed.Vec2(-250, 250),  // upper left
+	}
+	gMainLine = m.CreateLineStrip(square, 50, gCamera, lineRenderer)
+	gMainLine.Closed = true
+	gMainLine.Colors = []shed.V4{
+		shed.RGBA(1, 1, 1, 1),
+		shed.RGBA(1, 0, 1, 1),
+		shed.RGBA(1, 1, 0, 1),
+		shed.RGBA(0, 1, 1, 1),
+	}
 }
 
 // ||=================================================================
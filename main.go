@@ -1,14 +1,20 @@
 package main
 
 import (
+	"flag"
 	"goat/shed"
 	"goat/tractor"
+	"log"
+	"os"
+	"strings"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 func main() {
 
+	flag.Parse()
+
 	// start the mainthread system, allowing us to make calls on the main thread later
 	// h.StartMainThreadSystem(actualMain)
 	actualMain()
@@ -27,6 +33,7 @@ const (
 	CAMERA_ID       = "main"
 	SPRITE_SHADER   = "shaders/sprite"
 	RECT_SHADER     = "shaders/rect"
+	LINE_SHADER     = "shaders/line_strip"
 	BG_TEX_FN       = "Backgrounds/purple.png"
 	ATLAS_FN        = "Spritesheet/sheet.xml"
 	TEST_TEX_FN     = "playerShip1_blue.png"
@@ -41,7 +48,16 @@ var (
 	gMainRectRenderer    *tractor.BasicRectRenderer
 	gMainSprite          *tractor.Sprite
 	gMainRect            *tractor.BasicRect
-	gMainLine            *tractor.BasicLine
+	gMainLine            *tractor.LineStrip
+)
+
+// Command line flags. Mostly used for golden-image testing
+var (
+	flagWatch     = flag.Bool("watch", false, "reload shaders, textures and atlasses when their files change")
+	flagFrames    = flag.Uint64("frames", 0, "run headless (no window, no GPU) for this many frames, then exit")
+	flagCapture   = flag.String("capture", "", "write the last frame to this png file. Requires -frames")
+	flagGolden    = flag.String("golden", "", "compare the last frame to this png file. Requires -frames")
+	flagTolerance = flag.Uint("tolerance", 2, "per-channel tolerance used by -golden")
 )
 
 // ||========================================================
@@ -51,12 +67,20 @@ var (
 // ||========================================================
 func actualMain() {
 
-	tractor.StartMain(&tractor.WindowOptions{
+	windowOptions := &tractor.WindowOptions{
 		Title:     "GOAT",
 		Width:     SCENE_W * PX_FACTOR,
 		Height:    SCENE_H * PX_FACTOR,
 		Resizable: false,
-	})
+	}
+
+	if headless() {
+		tractor.StartMainHeadless(windowOptions, *flagFrames)
+	} else {
+		tractor.StartMain(windowOptions)
+	}
+
+	tractor.Engine.Assets.Enabled = *flagWatch
 
 	Setup()
 
@@ -68,10 +92,36 @@ func actualMain() {
 		Draw()
 	})
 
+	if headless() {
+		checkLastFrame()
+	}
+
 	// Free/dispose all allocated resources
 	tractor.Engine.Dispose()
 }
 
+func headless() bool {
+	return *flagFrames > 0
+}
+
+// Save and/or compare the last rendered frame, as requested on the command line
+func checkLastFrame() {
+	frame, err := tractor.Engine.CaptureFrame()
+	shed.GlPanicIfErrNotNil(err)
+
+	if *flagCapture != "" {
+		shed.GlPanicIfErrNotNil(shed.SavePNG(*flagCapture, frame))
+	}
+
+	if *flagGolden != "" {
+		diffFile := strings.TrimSuffix(*flagGolden, ".png") + ".diff.png"
+		if err := shed.CompareWithGoldenFile(*flagGolden, frame, uint8(*flagTolerance), diffFile); err != nil {
+			log.Printf("%v (diff written to '%s')", err, diffFile)
+			os.Exit(1)
+		}
+	}
+}
+
 // ||========================================================
 // ||
 // || Update
@@ -91,14 +141,8 @@ func Update() {
 
 	// RECTANGLE
 	// =====================================
-	sin0, _ := shed.Sincos(tractor.Engine.Now)
-	sin120, _ := shed.Sincos(tractor.Engine.Now + shed.Tau/3)
-	sin240, _ := shed.Sincos(tractor.Engine.Now + shed.Tau*2/3)
-	//
+	// The color is tweened. See initBasicRect()
 	gMainRect.Rotate(-tractor.Engine.Delta * 2)
-	gMainRect.Color.C1 = 0.5 + sin0*0.5
-	gMainRect.Color.C2 = 0.5 + sin120*0.5
-	gMainRect.Color.C3 = 0.5 + sin240*0.5
 }
 
 // ||========================================================
@@ -118,28 +162,8 @@ func Draw() {
 	// ================
 	gMainRect.Draw()
 
-	p1 := shed.Vec2(-250, -250) // lower left
-	p2 := shed.Vec2(250, -250)  // lower right
-	gMainLine.SetColor(shed.RGBA(1, 1, 1, 1))
-	gMainLine.SetPoints(p1, p2)
-	gMainLine.Draw()
-
-	p1 = p2
-	p2 = shed.Vec2(250, 250) // upper right
-	gMainLine.SetColor(shed.RGBA(1, 0, 1, 1))
-	gMainLine.SetPoints(p1, p2)
-	gMainLine.Draw()
-
-	p1 = p2
-	p2 = shed.Vec2(-250, 250) // upper left
-	gMainLine.SetColor(shed.RGBA(1, 1, 0, 1))
-	gMainLine.SetPoints(p1, p2)
-	gMainLine.Draw()
-
-	p1 = p2
-	p2 = shed.Vec2(-250, -250) // lower left
-	gMainLine.SetColor(shed.RGBA(0, 1, 1, 1))
-	gMainLine.SetPoints(p1, p2)
+	// Main Line
+	// ================
 	gMainLine.Draw()
 }
 
@@ -150,7 +174,9 @@ func Draw() {
 // ||========================================================
 func Setup() {
 
-	shed.EnableBlending()
+	if !headless() {
+		shed.EnableBlending() // the software backend always blends
+	}
 
 	tractor.Engine.AssetPath = "assets"
 
@@ -159,6 +185,6 @@ func Setup() {
 	initBackground()
 	initMainSprite()
 	initBasicRect()
-	gMainLine = tractor.CreateBasicLine(0, 0, 0, 0, 50, gCamera, gMainRectRenderer)
+	initMainLine()
 
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"goat/shed"
+	"goat/tractor"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "render testdata/demo.png again")
+
+// The demo scene, rendered by the software backend after a fifth of a second of simulated time.
+// go test -run DemoGolden -update-golden renders it again
+func TestDemoGolden(t *testing.T) {
+	*flagFrames = 12 // the scene is big, and the software backend is slow
+
+	tractor.StartMainHeadless(&tractor.WindowOptions{Width: SCENE_W * PX_FACTOR, Height: SCENE_H * PX_FACTOR}, *flagFrames)
+	defer tractor.Engine.Dispose()
+
+	Setup()
+	tractor.Engine.Loop(func() {
+		Update()
+		Draw()
+	})
+
+	frame, err := tractor.Engine.CaptureFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "demo.png")
+	if *updateGolden {
+		if err := shed.SavePNG(golden, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff := filepath.Join(os.TempDir(), "goat_golden_demo.diff.png")
+	if err := shed.CompareWithGoldenFile(golden, frame, 2, diff); err != nil {
+		t.Fatalf("%v (diff written to '%s')", err, diff)
+	}
+}
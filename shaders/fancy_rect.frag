@@ -0,0 +1,30 @@
+#version 460 core
+
+out vec4 fragColor;
+
+in vec2 vLocal;
+
+uniform vec2 uniSize;          // width and height of the rect, in world units
+uniform vec4 uniRadius;        // corner radii: top left, top right, bottom right, bottom left
+uniform float uniStrokeWidth;  // the stroke is drawn inside the edge
+uniform vec4 uniFillColor;
+uniform vec4 uniStrokeColor;
+
+// Signed distance to the edge of a rounded box. Negative inside.
+float roundedBox(vec2 p, vec2 halfSize, vec4 radius) {
+  float r = (p.x < 0.0) ? ((p.y > 0.0) ? radius.x : radius.w)
+                        : ((p.y > 0.0) ? radius.y : radius.z);
+  vec2 q = abs(p) - halfSize + r;
+  return min(max(q.x, q.y), 0.0) + length(max(q, 0.0)) - r;
+}
+
+void main() {
+  float dist = roundedBox(vLocal, uniSize / 2.0, uniRadius);
+  float aa = max(fwidth(dist), 1e-5); // one pixel, in world units
+
+  float coverage = clamp(0.5 - dist / aa, 0.0, 1.0);
+  float fill = (uniStrokeWidth > 0.0) ? clamp(0.5 - (dist + uniStrokeWidth) / aa, 0.0, 1.0) : 1.0;
+
+  fragColor = mix(uniStrokeColor, uniFillColor, fill);
+  fragColor.a *= coverage;
+}
@@ -0,0 +1,18 @@
+#version 460 core
+
+in vec3 iVert;
+in vec2 iOutset; // the direction the corner is pushed out in, to make room for the anti-aliased fringe
+
+out vec2 vLocal; // position inside the rect, in world units. (0, 0) is the center
+
+uniform mat3 uniTransformation;
+uniform vec2 uniSize;    // width and height of the rect, in world units
+uniform vec2 uniFeather; // width of the fringe, in world units. About a pixel
+
+void main() {
+  vec3 vert = vec3(iVert.xy + iOutset * uniFeather / max(uniSize, 1e-5), 1.0);
+
+  vLocal = vert.xy * uniSize;
+
+  gl_Position = vec4(uniTransformation * vert, 1.0);
+}
@@ -0,0 +1,9 @@
+#version 460 core
+
+out vec4 fragColor;
+
+in vec4 vColor; // the alpha fades to zero at the feathered edges
+
+void main() {
+  fragColor = vColor;
+}
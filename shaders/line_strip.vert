@@ -0,0 +1,14 @@
+#version 460 core
+
+in vec2 iVert;
+in vec4 iColor;
+
+out vec4 vColor;
+
+uniform mat3 uniTransformation;
+
+void main() {
+  vColor = iColor;
+
+  gl_Position = vec4(uniTransformation * vec3(iVert, 1.0), 1.0);
+}
@@ -0,0 +1,18 @@
+#version 460 core
+
+out vec4 fragColor;
+
+in vec2 vTexCoord;
+flat in vec4 vSubTexPos;
+flat in vec4 vColor;
+flat in float vColorMix;
+
+uniform sampler2D uniTexture; // the texture to use
+
+void main() {
+  vec2 tmp =
+      vec2(mix(vSubTexPos.x, vSubTexPos.z, vTexCoord.x), // mix == lerp
+           mix(vSubTexPos.y, vSubTexPos.w, vTexCoord.y)  // mix == lerp
+      );
+  fragColor = mix(texture(uniTexture, tmp), vColor, vColorMix);
+}
@@ -0,0 +1,31 @@
+#version 460 core
+
+in vec3 iVert;
+in vec2 iTexCoord;
+
+// Per-instance attributes. One set per sprite.
+in vec3 iTrCol0;     // the sprite's transformation matrix, column 0
+in vec3 iTrCol1;     // the sprite's transformation matrix, column 1
+in vec3 iTrCol2;     // the sprite's transformation matrix, column 2
+in vec4 iSubTexPos;  // which part of the texture do we want to use
+in vec4 iColor;      // the color to use
+in float iColorMix;  // how much of the output color comes from iColor
+
+out vec2 vTexCoord;
+flat out vec4 vSubTexPos;
+flat out vec4 vColor;
+flat out float vColorMix;
+
+uniform mat3 uniCamera;
+
+void main() {
+  vTexCoord = iTexCoord;
+  vSubTexPos = iSubTexPos;
+  vColor = iColor;
+  vColorMix = iColorMix;
+
+  gl_Position = vec4(
+    uniCamera * mat3(iTrCol0, iTrCol1, iTrCol2) * iVert,
+    1.0
+  );
+}
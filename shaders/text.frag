@@ -0,0 +1,20 @@
+#version 460 core
+
+out vec4 fragColor;
+
+in vec2 vTexCoord;
+flat in vec4 vSubTexPos;
+flat in vec4 vColor;
+flat in float vColorMix;
+
+uniform sampler2D uniTexture; // the glyph atlas
+
+// Same inputs as sprite_batch, but the color tints the glyph instead of replacing it.
+// vColorMix controls how much tint is applied.
+void main() {
+  vec2 tmp =
+      vec2(mix(vSubTexPos.x, vSubTexPos.z, vTexCoord.x), // mix == lerp
+           mix(vSubTexPos.y, vSubTexPos.w, vTexCoord.y)  // mix == lerp
+      );
+  fragColor = texture(uniTexture, tmp) * mix(vec4(1.0), vColor, vColorMix);
+}
@@ -0,0 +1,307 @@
+package shed
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// =========================================================================
+// ||
+// || Collision tests.
+// ||
+// || Axis-aligned boxes, circles and convex polygons. Oriented boxes are
+// || convex polygons with four corners. Polygons may have either winding.
+// ||
+// || Tests that find an overlap return a Contact: moving B by
+// || Normal * Depth (or A by the opposite) separates the two shapes.
+// ||
+// =========================================================================
+
+// An axis-aligned bounding box
+type AABB struct {
+	Min, Max V2
+}
+
+type Circle struct {
+	Center V2
+	Radius float32
+}
+
+// Where two shapes overlap
+type Contact struct {
+	Normal V2      // unit vector, pointing from A towards B
+	Depth  float32 // how far the shapes overlap along Normal
+	Point  V2      // roughly where they touch: the point of B that is deepest inside A
+}
+
+// The smallest box around the points
+func AABBAround(points []V2) AABB {
+	if len(points) == 0 {
+		return AABB{}
+	}
+
+	box := AABB{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		box.Min = V2{Min(box.Min.X, p.X), Min(box.Min.Y, p.Y)}
+		box.Max = V2{Max(box.Max.X, p.X), Max(box.Max.Y, p.Y)}
+	}
+
+	return box
+}
+
+func (B AABB) Center() V2 {
+	return B.Min.Plus(B.Max).Scaled(0.5)
+}
+
+// The corners, counter-clockwise from the bottom left (with y pointing up)
+func (B AABB) Corners() []V2 {
+	return []V2{B.Min, {B.Max.X, B.Min.Y}, B.Max, {B.Min.X, B.Max.Y}}
+}
+
+// The smallest box around both boxes
+func (B AABB) Union(other AABB) AABB {
+	return AABB{
+		Min: V2{Min(B.Min.X, other.Min.X), Min(B.Min.Y, other.Min.Y)},
+		Max: V2{Max(B.Max.X, other.Max.X), Max(B.Max.Y, other.Max.Y)},
+	}
+}
+
+// Touching counts as overlapping
+func (B AABB) Overlaps(other AABB) bool {
+	return B.Min.X <= other.Max.X && other.Min.X <= B.Max.X &&
+		B.Min.Y <= other.Max.Y && other.Min.Y <= B.Max.Y
+}
+
+func (B AABB) Contains(p V2) bool {
+	return p.X >= B.Min.X && p.X <= B.Max.X && p.Y >= B.Min.Y && p.Y <= B.Max.Y
+}
+
+// The box around a circle
+func (C Circle) Bounds() AABB {
+	r := V2{C.Radius, C.Radius}
+	return AABB{Min: C.Center.Minus(r), Max: C.Center.Plus(r)}
+}
+
+func (C Circle) Contains(p V2) bool {
+	return p.Minus(C.Center).Len() <= C.Radius
+}
+
+// Is p inside (or on the edge of) the convex polygon?
+func PointInConvex(p V2, polygon []V2) bool {
+	var sign float32
+
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		side := b.Minus(a).Cross(p.Minus(a))
+		if side == 0 {
+			continue
+		}
+		if sign == 0 {
+			sign = Sign(side)
+		} else if Sign(side) != sign {
+			return false
+		}
+	}
+
+	return len(polygon) > 0
+}
+
+// ||=============================
+// || Tests that return contacts
+// ||=============================
+
+func CollideAABBs(a, b AABB) (Contact, bool) {
+	overlapX := Min(a.Max.X, b.Max.X) - Max(a.Min.X, b.Min.X)
+	overlapY := Min(a.Max.Y, b.Max.Y) - Max(a.Min.Y, b.Min.Y)
+	if overlapX <= 0 || overlapY <= 0 {
+		return Contact{}, false
+	}
+
+	// Push out along the axis with the least overlap
+	d := b.Center().Minus(a.Center())
+	inner := AABB{
+		Min: V2{Max(a.Min.X, b.Min.X), Max(a.Min.Y, b.Min.Y)},
+		Max: V2{Min(a.Max.X, b.Max.X), Min(a.Max.Y, b.Max.Y)},
+	}
+	if overlapX < overlapY {
+		return Contact{Normal: V2{X: signOrOne(d.X)}, Depth: overlapX, Point: inner.Center()}, true
+	}
+
+	return Contact{Normal: V2{Y: signOrOne(d.Y)}, Depth: overlapY, Point: inner.Center()}, true
+}
+
+func CollideCircles(a, b Circle) (Contact, bool) {
+	d := b.Center.Minus(a.Center)
+	dist := d.Len()
+	depth := a.Radius + b.Radius - dist
+	if depth <= 0 {
+		return Contact{}, false
+	}
+
+	normal := V2{X: 1} // same center. Any direction will do
+	if dist > 0 {
+		normal = d.Scaled(1 / dist)
+	}
+
+	return Contact{
+		Normal: normal,
+		Depth:  depth,
+		Point:  b.Center.Minus(normal.Scaled(b.Radius)),
+	}, true
+}
+
+// Separating axis test of two convex polygons
+func CollidePolygons(a, b []V2) (Contact, bool) {
+	if len(a) < 2 || len(b) < 2 {
+		return Contact{}, false
+	}
+
+	best := Contact{Depth: float32(math.Inf(1))}
+
+	for _, polygon := range [][]V2{a, b} {
+		for i, p := range polygon {
+			edge := polygon[(i+1)%len(polygon)].Minus(p)
+			if edge.Len() == 0 {
+				continue
+			}
+			axis := edge.Perpendicular().Normalized()
+
+			minA, maxA := project(a, axis)
+			minB, maxB := project(b, axis)
+			overlap := Min(maxA, maxB) - Max(minA, minB)
+			if overlap <= 0 {
+				return Contact{}, false // found a separating axis
+			}
+			if overlap < best.Depth {
+				best.Depth = overlap
+				best.Normal = axis
+			}
+		}
+	}
+
+	if centroid(b).Minus(centroid(a)).Dot(best.Normal) < 0 {
+		best.Normal = best.Normal.Scaled(-1)
+	}
+	best.Point = support(b, best.Normal.Scaled(-1))
+
+	return best, true
+}
+
+// A (convex polygon) against B (circle)
+func CollidePolygonCircle(polygon []V2, c Circle) (Contact, bool) {
+	if len(polygon) < 2 {
+		return Contact{}, false
+	}
+
+	// The closest point on the outline, and the outward normal of the edge it is on
+	winding := Sign(signedArea(polygon))
+	var closest, edgeNormal V2
+	bestDist := float32(math.Inf(1))
+
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		point := closestOnSegment(c.Center, p, q)
+		if dist := c.Center.Minus(point).Len(); dist < bestDist {
+			bestDist = dist
+			closest = point
+			edgeNormal = q.Minus(p).Perpendicular().Normalized().Scaled(-winding) // outward
+		}
+	}
+
+	if PointInConvex(c.Center, polygon) {
+		return Contact{
+			Normal: edgeNormal,
+			Depth:  c.Radius + bestDist,
+			Point:  c.Center.Minus(edgeNormal.Scaled(c.Radius)),
+		}, true
+	}
+
+	if bestDist >= c.Radius {
+		return Contact{}, false
+	}
+
+	normal := c.Center.Minus(closest).Scaled(1 / bestDist)
+	return Contact{
+		Normal: normal,
+		Depth:  c.Radius - bestDist,
+		Point:  c.Center.Minus(normal.Scaled(c.Radius)),
+	}, true
+}
+
+// A (circle) against B (convex polygon)
+func CollideCirclePolygon(c Circle, polygon []V2) (Contact, bool) {
+	contact, hit := CollidePolygonCircle(polygon, c)
+	if !hit {
+		return contact, false
+	}
+
+	// The deepest point of the polygon inside the circle
+	contact.Normal = contact.Normal.Scaled(-1)
+	contact.Point = support(polygon, contact.Normal.Scaled(-1))
+
+	return contact, true
+}
+
+// The smallest and largest projection of the points onto axis
+func project(points []V2, axis V2) (float32, float32) {
+	min := points[0].Dot(axis)
+	max := min
+
+	for _, p := range points[1:] {
+		d := p.Dot(axis)
+		min, max = Min(min, d), Max(max, d)
+	}
+
+	return min, max
+}
+
+// The point furthest in direction dir. Two points that are (almost) as far are averaged
+func support(points []V2, dir V2) V2 {
+	best, bestIdx := float32(math.Inf(-1)), 0
+	for i, p := range points {
+		if d := p.Dot(dir); d > best {
+			best, bestIdx = d, i
+		}
+	}
+
+	result, count := V2{}, float32(0)
+	for _, p := range points {
+		if best-p.Dot(dir) < 1e-4*(1+mgl32.Abs(best)) {
+			result = result.Plus(p)
+			count++
+		}
+	}
+	if count == 0 {
+		return points[bestIdx]
+	}
+
+	return result.Scaled(1 / count)
+}
+
+func centroid(points []V2) V2 {
+	sum := V2{}
+	for _, p := range points {
+		sum = sum.Plus(p)
+	}
+
+	return sum.Scaled(1 / float32(len(points)))
+}
+
+func closestOnSegment(p, a, b V2) V2 {
+	ab := b.Minus(a)
+	lenSq := ab.Dot(ab)
+	if lenSq == 0 {
+		return a
+	}
+
+	t := Max(0, Min(1, p.Minus(a).Dot(ab)/lenSq))
+	return a.Plus(ab.Scaled(t))
+}
+
+func signOrOne(f float32) float32 {
+	if f < 0 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,132 @@
+package shed
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b V2) bool {
+	return math.Abs(float64(a.X-b.X)) < 1e-4 && math.Abs(float64(a.Y-b.Y)) < 1e-4
+}
+
+// Every combination of windings of a and b
+func windings(a, b []V2) [][2][]V2 {
+	return [][2][]V2{{a, b}, {reversed(a), b}, {a, reversed(b)}, {reversed(a), reversed(b)}}
+}
+
+func TestCollidePolygons(t *testing.T) {
+	diamond := []V2{{3, 1}, {4, 2}, {3, 3}, {2, 2}} // a box turned by 45 degrees, with its left corner at (2, 2)
+
+	tests := []struct {
+		name   string
+		a, b   []V2
+		hit    bool
+		normal V2
+		depth  float32
+	}{
+		{"b to the right", square(0, 0, 2, 2), square(1.5, 0.5, 3.5, 1.5), true, V2{1, 0}, 0.5},
+		{"b to the left", square(0, 0, 2, 2), square(-1.5, 0.5, 0.25, 1.5), true, V2{-1, 0}, 0.25},
+		{"b above", square(0, 0, 2, 2), square(0.5, 1.75, 1.5, 5), true, V2{0, 1}, 0.25},
+		{"b below", square(0, 0, 2, 2), square(0.5, -3, 1.5, 0.1), true, V2{0, -1}, 0.1},
+		{"apart", square(0, 0, 2, 2), square(3, 0, 4, 2), false, V2{}, 0},
+		{"touching", square(0, 0, 2, 2), square(2, 0, 4, 2), false, V2{}, 0},
+		{"corner into a side", square(0, 0, 2.5, 4), diamond, true, V2{1, 0}, 0.5},
+		{"apart on a diagonal", square(0, 0, 2, 2), diamond, false, V2{}, 0}, // the boxes overlap, the shapes don't
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, pair := range windings(test.a, test.b) {
+				contact, hit := CollidePolygons(pair[0], pair[1])
+				if hit != test.hit {
+					t.Fatalf("hit is %v, expected %v (a: %v, b: %v)", hit, test.hit, pair[0], pair[1])
+				}
+				if !hit {
+					continue
+				}
+				if !near(contact.Normal, test.normal) || math.Abs(float64(contact.Depth-test.depth)) > 1e-4 {
+					t.Fatalf("the normal is %v and the depth %v, expected %v and %v (a: %v, b: %v)",
+						contact.Normal, contact.Depth, test.normal, test.depth, pair[0], pair[1])
+				}
+
+				// Moving b out along the normal separates them
+				moved := make([]V2, len(pair[1]))
+				for i, p := range pair[1] {
+					moved[i] = p.Plus(contact.Normal.Scaled(contact.Depth + 1e-3))
+				}
+				if _, still := CollidePolygons(pair[0], moved); still {
+					t.Fatalf("moving b by the contact does not separate the shapes")
+				}
+			}
+		})
+	}
+}
+
+func TestCollidePolygonCircle(t *testing.T) {
+	diagonal := float32(1 / math.Sqrt2)
+
+	tests := []struct {
+		name    string
+		polygon []V2
+		circle  Circle
+		hit     bool
+		normal  V2 // from the polygon towards the circle
+		depth   float32
+	}{
+		{"right of a side", square(0, 0, 2, 2), Circle{V2{2.5, 1}, 1}, true, V2{1, 0}, 0.5},
+		{"below a side", square(0, 0, 2, 2), Circle{V2{1, -0.75}, 1}, true, V2{0, -1}, 0.25},
+		{"center inside", square(0, 0, 2, 2), Circle{V2{1.8, 1}, 0.5}, true, V2{1, 0}, 0.7},
+		{"off a corner", square(0, 0, 2, 2), Circle{V2{3, 3}, 2}, true, V2{diagonal, diagonal}, 2 - float32(math.Sqrt2)},
+		{"apart", square(0, 0, 2, 2), Circle{V2{4, 1}, 1}, false, V2{}, 0},
+		{"near a corner, but apart", square(0, 0, 2, 2), Circle{V2{2.8, 2.8}, 1}, false, V2{}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, polygon := range [][]V2{test.polygon, reversed(test.polygon)} {
+				contact, hit := CollidePolygonCircle(polygon, test.circle)
+				if hit != test.hit {
+					t.Fatalf("polygon against circle: hit is %v, expected %v (%v)", hit, test.hit, polygon)
+				}
+				if hit && (!near(contact.Normal, test.normal) || math.Abs(float64(contact.Depth-test.depth)) > 1e-4) {
+					t.Fatalf("polygon against circle: the normal is %v and the depth %v, expected %v and %v (%v)",
+						contact.Normal, contact.Depth, test.normal, test.depth, polygon)
+				}
+
+				// The other way around, the normal points the other way
+				contact, hit = CollideCirclePolygon(test.circle, polygon)
+				if hit != test.hit {
+					t.Fatalf("circle against polygon: hit is %v, expected %v (%v)", hit, test.hit, polygon)
+				}
+				if hit && (!near(contact.Normal, test.normal.Scaled(-1)) || math.Abs(float64(contact.Depth-test.depth)) > 1e-4) {
+					t.Fatalf("circle against polygon: the normal is %v and the depth %v, expected %v and %v (%v)",
+						contact.Normal, contact.Depth, test.normal.Scaled(-1), test.depth, polygon)
+				}
+			}
+		})
+	}
+}
+
+func TestPointInConvex(t *testing.T) {
+	diamond := []V2{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+	tests := []struct {
+		p      V2
+		inside bool
+	}{
+		{V2{0, 0}, true},
+		{V2{0.49, 0.49}, true},
+		{V2{1, 0}, true}, // on a corner
+		{V2{0.5, 0.5}, true},
+		{V2{0.6, 0.6}, false}, // inside the box around it
+		{V2{-1.1, 0}, false},
+	}
+
+	for _, test := range tests {
+		for _, polygon := range [][]V2{diamond, reversed(diamond)} {
+			if inside := PointInConvex(test.p, polygon); inside != test.inside {
+				t.Fatalf("%v inside %v is %v, expected %v", test.p, polygon, inside, test.inside)
+			}
+		}
+	}
+}
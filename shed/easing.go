@@ -0,0 +1,129 @@
+package shed
+
+import (
+	"math"
+)
+
+// Maps the progress of an animation (0 at the start, 1 at the end) to how far
+// the animated value has come. Most curves stay within [0, 1], but back and elastic ones overshoot.
+type EaseFunc func(t float32) float32
+
+const (
+	easeBack    = 1.70158 // how far back curves overshoot. This value gives 10%
+	easeBackIO  = easeBack * 1.525
+	easeElastic = 2 * math.Pi / 3
+)
+
+func EaseLinear(t float32) float32 { return t }
+
+func EaseInQuad(t float32) float32  { return t * t }
+func EaseOutQuad(t float32) float32 { return 1 - (1-t)*(1-t) }
+func EaseInOutQuad(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - 2*(1-t)*(1-t)
+}
+
+func EaseInCubic(t float32) float32  { return t * t * t }
+func EaseOutCubic(t float32) float32 { return 1 - (1-t)*(1-t)*(1-t) }
+func EaseInOutCubic(t float32) float32 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - 4*(1-t)*(1-t)*(1-t)
+}
+
+// Pulls back before it goes
+func EaseInBack(t float32) float32 {
+	return (easeBack+1)*t*t*t - easeBack*t*t
+}
+
+// Overshoots, then settles
+func EaseOutBack(t float32) float32 {
+	return 1 - EaseInBack(1-t)
+}
+
+func EaseInOutBack(t float32) float32 {
+	if t < 0.5 {
+		t *= 2
+		return t * t * ((easeBackIO+1)*t - easeBackIO) / 2
+	}
+	t = 2*t - 2
+	return (t*t*((easeBackIO+1)*t+easeBackIO) + 2) / 2
+}
+
+func EaseInElastic(t float32) float32 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+	return -float32(math.Pow(2, float64(10*t-10)) * math.Sin(float64(10*t-10.75)*easeElastic))
+}
+
+// Wobbles around the end before it settles, like a spring
+func EaseOutElastic(t float32) float32 {
+	return 1 - EaseInElastic(1-t)
+}
+
+func EaseInOutElastic(t float32) float32 {
+	if t < 0.5 {
+		return EaseInElastic(2*t) / 2
+	}
+	return 1 - EaseInElastic(2-2*t)/2
+}
+
+// Bounces off the end, like a dropped ball
+func EaseOutBounce(t float32) float32 {
+	const n, d = 7.5625, 2.75
+
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	default:
+		t -= 2.625 / d
+		return n*t*t + 0.984375
+	}
+}
+
+func EaseInBounce(t float32) float32 {
+	return 1 - EaseOutBounce(1-t)
+}
+
+func EaseInOutBounce(t float32) float32 {
+	if t < 0.5 {
+		return EaseInBounce(2*t) / 2
+	}
+	return 0.5 + EaseOutBounce(2*t-1)/2
+}
+
+var easings = map[string]EaseFunc{
+	"linear":       EaseLinear,
+	"inQuad":       EaseInQuad,
+	"outQuad":      EaseOutQuad,
+	"inOutQuad":    EaseInOutQuad,
+	"inCubic":      EaseInCubic,
+	"outCubic":     EaseOutCubic,
+	"inOutCubic":   EaseInOutCubic,
+	"inBack":       EaseInBack,
+	"outBack":      EaseOutBack,
+	"inOutBack":    EaseInOutBack,
+	"inElastic":    EaseInElastic,
+	"outElastic":   EaseOutElastic,
+	"inOutElastic": EaseInOutElastic,
+	"inBounce":     EaseInBounce,
+	"outBounce":    EaseOutBounce,
+	"inOutBounce":  EaseInOutBounce,
+}
+
+// Look up an easing curve by name, for instance "outBounce" for EaseOutBounce
+func EaseByName(name string) (EaseFunc, bool) {
+	fn, found := easings[name]
+
+	return fn, found
+}
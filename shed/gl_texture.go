@@ -6,6 +6,7 @@ import (
 	"image/draw"
 	_ "image/jpeg"
 	_ "image/png"
+	"math"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 )
@@ -98,6 +99,70 @@ func (T *TextureWrapper) Finalize() {
 	AssertGLOK()
 }
 
+// Replace part of the texture with the pixels of img. (x, y) is the top-left corner of the region.
+// Works both before and after the texture has been finalized.
+func (T *TextureWrapper) UpdateRegion(x, y int, img *image.RGBA) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+
+	if x < 0 || y < 0 || x+w > int(T.w) || y+h > int(T.h) {
+		GlPanic(fmt.Errorf("region (%d, %d, %d, %d) is outside the %dx%d texture", x, y, w, h, T.w, T.h))
+	}
+
+	if !T.initialized {
+		for row := 0; row < h; row++ {
+			src := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+row):][:w*4]
+			copy(T.pix[((y+row)*int(T.w)+x)*4:], src)
+		}
+		return
+	}
+
+	// make the rows contiguous, as opengl expects them
+	if img.Stride != w*4 {
+		tmp := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(tmp, tmp.Bounds(), img, img.Rect.Min, draw.Src)
+		img = tmp
+	}
+
+	gl.BindTexture(T.typ, T.handle)
+	defer gl.BindTexture(T.typ, 0)
+
+	gl.TexSubImage2D(T.typ, 0, int32(x), int32(y), int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.GenerateMipmap(T.typ)
+	AssertGLOK("UpdateRegion")
+}
+
+// Replace the entire texture with a new image, keeping all settings.
+// The image may have a different size than the old one.
+func (T *TextureWrapper) ReplaceImage(img image.Image) error {
+	imgRgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(imgRgba, imgRgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	w, h := int32(imgRgba.Rect.Dx()), int32(imgRgba.Rect.Dy())
+
+	if !T.initialized {
+		T.w, T.h, T.pix = w, h, imgRgba.Pix
+		return nil
+	}
+
+	gl.BindTexture(T.typ, T.handle)
+	defer gl.BindTexture(T.typ, 0)
+
+	if w == T.w && h == T.h {
+		gl.TexSubImage2D(T.typ, 0, 0, 0, w, h, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(imgRgba.Pix))
+	} else {
+		gl.TexImage2D(T.typ, 0, gl.SRGB_ALPHA, w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(imgRgba.Pix))
+		T.w, T.h = w, h
+	}
+	gl.GenerateMipmap(T.typ)
+
+	return AssertGLOK("ReplaceImage")
+}
+
+// Has the texture been uploaded to the GPU?
+func (T *TextureWrapper) IsFinalized() bool {
+	return T.initialized
+}
+
 func (T *TextureWrapper) GetTextureUnit() uint32 {
 	return T.unit
 }
@@ -144,6 +209,52 @@ func (T *TextureWrapper) GetMinFilter() int32 {
 	return T.minFilter
 }
 
+// Sample the texture at (s, t) the way the GPU would:
+// nearest-neighbour filtering, srgb-decoding, and the configured wrap mode.
+//
+// Only textures that have not been finalized still have their pixels in memory.
+// Finalized textures sample as transparent black.
+func (T *TextureWrapper) Sample(s, t float32) V4 {
+	if T.pix == nil || T.w == 0 || T.h == 0 {
+		return V4{}
+	}
+
+	x := wrapTexCoord(s, T.w, T.wrapS)
+	y := wrapTexCoord(t, T.h, gl.REPEAT) // GL_TEXTURE_WRAP_T is never set, so opengl uses its default
+
+	i := (y*T.w + x) * 4
+
+	return V4{
+		srgbToLinear[T.pix[i+0]],
+		srgbToLinear[T.pix[i+1]],
+		srgbToLinear[T.pix[i+2]],
+		float32(T.pix[i+3]) / 255,
+	}
+}
+
+// Convert a texture coordinate into a texel index, honoring the wrap mode
+func wrapTexCoord(c float32, size, wrapMode int32) int32 {
+	i := int32(math.Floor(float64(c * float32(size))))
+
+	if wrapMode == gl.REPEAT {
+		i %= size
+		if i < 0 {
+			i += size
+		}
+		return i
+	}
+
+	// everything else is treated as CLAMP_TO_EDGE
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+
+	return i
+}
+
 func (T *TextureWrapper) GetSize() (int32, int32) {
 	return T.w, T.h
 }
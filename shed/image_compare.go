@@ -0,0 +1,155 @@
+package shed
+
+/**
+Image comparison.
+
+Used for golden-image regression testing: render a frame, compare it to a
+known-good frame, and write a diff image if they differ.
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// The result of comparing two images
+type ImageDiff struct {
+	Mismatches int         // Number of pixels where at least one channel differs by more than the tolerance
+	MaxDelta   uint8       // The largest per-channel difference found
+	Image      *image.RGBA // Visualization of the difference. Matching pixels are dimmed, mismatching pixels are red.
+}
+
+// Did the images match (within the tolerance)?
+func (D *ImageDiff) Ok() bool {
+	return D.Mismatches == 0
+}
+
+// Compare two images, channel by channel.
+// A pixel is a mismatch if any of its channels differs by more than tolerance.
+func CompareImages(expected, actual image.Image, tolerance uint8) (*ImageDiff, error) {
+
+	if expected.Bounds().Size() != actual.Bounds().Size() {
+		return nil, fmt.Errorf("image sizes differ. Expected %v, got %v", expected.Bounds().Size(), actual.Bounds().Size())
+	}
+
+	exp := toRGBA(expected)
+	act := toRGBA(actual)
+
+	diff := &ImageDiff{
+		Image: image.NewRGBA(image.Rect(0, 0, exp.Rect.Dx(), exp.Rect.Dy())),
+	}
+
+	for y := 0; y < exp.Rect.Dy(); y++ {
+		for x := 0; x < exp.Rect.Dx(); x++ {
+			i := exp.PixOffset(exp.Rect.Min.X+x, exp.Rect.Min.Y+y)
+			j := act.PixOffset(act.Rect.Min.X+x, act.Rect.Min.Y+y)
+
+			mismatch := false
+			for c := 0; c < 4; c++ {
+				d := absDiff(exp.Pix[i+c], act.Pix[j+c])
+				if d > diff.MaxDelta {
+					diff.MaxDelta = d
+				}
+				if d > tolerance {
+					mismatch = true
+				}
+			}
+
+			if mismatch {
+				diff.Mismatches++
+				diff.Image.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+
+			// dimmed grayscale version of the expected pixel, so the mismatches stand out
+			gray := uint8((uint16(exp.Pix[i]) + uint16(exp.Pix[i+1]) + uint16(exp.Pix[i+2])) / 3 / 4)
+			diff.Image.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+		}
+	}
+
+	return diff, nil
+}
+
+// Compare an image to a golden image stored on disk.
+//
+// If the images do not match, a diff image is written to diffPath (unless diffPath is empty)
+// and an error is returned.
+func CompareWithGoldenFile(goldenPath string, actual image.Image, tolerance uint8, diffPath string) error {
+
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		return fmt.Errorf("could not open golden image '%s' - %v", goldenPath, err)
+	}
+	defer f.Close()
+
+	golden, err := png.Decode(f)
+	if err != nil {
+		return fmt.Errorf("could not decode golden image '%s' - %v", goldenPath, err)
+	}
+
+	diff, err := CompareImages(golden, actual, tolerance)
+	if err != nil {
+		return err
+	}
+
+	if diff.Ok() {
+		return nil
+	}
+
+	if diffPath != "" {
+		if err := SavePNG(diffPath, diff.Image); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("image does not match '%s'. %d pixels differ, max delta is %d", goldenPath, diff.Mismatches, diff.MaxDelta)
+}
+
+// Write an image to a png file
+func SavePNG(filePath string, img image.Image) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("could not create file '%s' - %v", filePath, err)
+	}
+	defer f.Close()
+
+	return png.Encode(f, img)
+}
+
+// Flip an image upside down, in place.
+func FlipImageVertically(img *image.RGBA) {
+	h := img.Rect.Dy()
+	rowLen := img.Rect.Dx() * 4
+	tmp := make([]uint8, rowLen)
+
+	for y := 0; y < h/2; y++ {
+		top := img.Pix[y*img.Stride : y*img.Stride+rowLen]
+		bottom := img.Pix[(h-1-y)*img.Stride : (h-1-y)*img.Stride+rowLen]
+		copy(tmp, top)
+		copy(top, bottom)
+		copy(bottom, tmp)
+	}
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return rgba
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
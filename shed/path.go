@@ -0,0 +1,294 @@
+package shed
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// =========================================================================
+// ||
+// || Path.
+// ||
+// || Vector paths, like the ones in SVG: straight lines, quadratic and cubic
+// || Bézier curves, and elliptical arcs, in one or more subpaths.
+// ||
+// || Paths are flattened into polylines before they are drawn. Fill() turns
+// || them into triangles, and Stroke() turns them into thick lines.
+// ||
+// =========================================================================
+type Path struct {
+	FillRule FillRule // what is inside where subpaths overlap. Non-zero by default, like in SVG
+
+	subpaths []*subpath
+	current  V2 // where the next segment starts
+}
+
+// A polyline. A polygon if Closed is true.
+type Polyline struct {
+	Points []V2
+	Closed bool
+}
+
+type subpath struct {
+	start    V2
+	segments []pathSegment
+	closed   bool
+}
+
+const (
+	segmentLine = iota
+	segmentQuad
+	segmentCubic
+	segmentArc
+)
+
+type pathSegment struct {
+	kind int
+	from V2
+	c1   V2 // first control point of curves
+	c2   V2 // second control point of cubic curves
+	to   V2
+
+	// arcs, in center parameterization
+	center     V2
+	rx, ry     float32
+	rotation   float32 // of the x axis of the ellipse
+	startAngle float32
+	sweep      float32 // negative for clockwise arcs
+}
+
+func CreatePath() *Path {
+	return &Path{}
+}
+
+// Start a new subpath at (x, y)
+func (P *Path) MoveTo(x, y float32) *Path {
+	P.current = V2{x, y}
+	P.subpaths = append(P.subpaths, &subpath{start: P.current})
+
+	return P
+}
+
+// A straight line from the current point to (x, y)
+func (P *Path) LineTo(x, y float32) *Path {
+	return P.add(pathSegment{kind: segmentLine, to: V2{x, y}})
+}
+
+// A quadratic Bézier curve from the current point to (x, y), with one control point
+func (P *Path) QuadTo(cx, cy, x, y float32) *Path {
+	return P.add(pathSegment{kind: segmentQuad, c1: V2{cx, cy}, to: V2{x, y}})
+}
+
+// A cubic Bézier curve from the current point to (x, y), with two control points
+func (P *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float32) *Path {
+	return P.add(pathSegment{kind: segmentCubic, c1: V2{c1x, c1y}, c2: V2{c2x, c2y}, to: V2{x, y}})
+}
+
+// An elliptical arc from the current point to (x, y). Same arguments as the SVG A command:
+// the radii, the rotation (in radians) of the ellipse, and the flags that pick one of the four possible arcs.
+// sweep is true for the arc that goes in the direction of increasing angles.
+func (P *Path) ArcTo(rx, ry, rotation float32, largeArc, sweep bool, x, y float32) *Path {
+	from, to := P.current, V2{x, y}
+
+	if from == to {
+		return P // the arc is left out entirely
+	}
+
+	rx, ry = mgl32.Abs(rx), mgl32.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return P.LineTo(x, y)
+	}
+
+	// Endpoint to center parameterization. See "Elliptical arc implementation notes" in the SVG spec.
+	sinPhi, cosPhi := math.Sincos(float64(rotation))
+	dx, dy := float64(from.X-to.X)/2, float64(from.Y-to.Y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	frx, fry := float64(rx), float64(ry)
+	if lambda := x1*x1/(frx*frx) + y1*y1/(fry*fry); lambda > 1 {
+		frx, fry = frx*math.Sqrt(lambda), fry*math.Sqrt(lambda) // the radii are too small to reach the end point
+	}
+
+	num := frx*frx*fry*fry - frx*frx*y1*y1 - fry*fry*x1*x1
+	den := frx*frx*y1*y1 + fry*fry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1 := coef * frx * y1 / fry
+	cy1 := -coef * fry * x1 / frx
+
+	center := V2{
+		X: float32(cosPhi*cx1 - sinPhi*cy1 + float64(from.X+to.X)/2),
+		Y: float32(sinPhi*cx1 + cosPhi*cy1 + float64(from.Y+to.Y)/2),
+	}
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	startAngle := angle(1, 0, (x1-cx1)/frx, (y1-cy1)/fry)
+	delta := angle((x1-cx1)/frx, (y1-cy1)/fry, (-x1-cx1)/frx, (-y1-cy1)/fry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	return P.add(pathSegment{
+		kind:       segmentArc,
+		to:         to,
+		center:     center,
+		rx:         float32(frx),
+		ry:         float32(fry),
+		rotation:   rotation,
+		startAngle: float32(startAngle),
+		sweep:      float32(delta),
+	})
+}
+
+// Close the current subpath with a straight line back to its start.
+// The next segment starts a new subpath at the same point.
+func (P *Path) Close() *Path {
+	if len(P.subpaths) == 0 {
+		return P
+	}
+
+	sp := P.subpaths[len(P.subpaths)-1]
+	sp.closed = true
+	P.current = sp.start
+
+	return P
+}
+
+// The point where the next segment starts
+func (P *Path) CurrentPoint() V2 {
+	return P.current
+}
+
+func (P *Path) add(seg pathSegment) *Path {
+	if len(P.subpaths) == 0 || P.subpaths[len(P.subpaths)-1].closed {
+		P.subpaths = append(P.subpaths, &subpath{start: P.current})
+	}
+
+	seg.from = P.current
+	sp := P.subpaths[len(P.subpaths)-1]
+	sp.segments = append(sp.segments, seg)
+	P.current = seg.to
+
+	return P
+}
+
+// Turn the path into polylines. Curves are split into straight lines that
+// stray no further than tolerance from the true curve.
+// Subpaths without any segments are left out.
+func (P *Path) Flatten(tolerance float32) []Polyline {
+	if tolerance <= 0 {
+		tolerance = 0.25
+	}
+
+	lines := make([]Polyline, 0, len(P.subpaths))
+
+	for _, sp := range P.subpaths {
+		if len(sp.segments) == 0 {
+			continue
+		}
+
+		points := []V2{sp.start}
+		for i := range sp.segments {
+			points = sp.segments[i].flatten(points, tolerance)
+		}
+
+		if sp.closed && len(points) > 1 && points[len(points)-1] == points[0] {
+			points = points[:len(points)-1] // the closing line is implied
+		}
+
+		lines = append(lines, Polyline{Points: points, Closed: sp.closed})
+	}
+
+	return lines
+}
+
+// Triangulate the area inside the path. Every subpath is filled as if it were closed.
+// Where subpaths overlap (a hole in a letter O, say), FillRule says what is inside.
+func (P *Path) Fill(tolerance float32) []V2 {
+	lines := P.Flatten(tolerance)
+
+	polygons := make([][]V2, len(lines))
+	for i, line := range lines {
+		polygons[i] = line.Points
+	}
+
+	return TriangulateFill(polygons, P.FillRule)
+}
+
+// Triangulate a thick line along the path
+func (P *Path) Stroke(style StrokeStyle, tolerance float32) []V2 {
+	triangles := []V2{}
+
+	for _, line := range P.Flatten(tolerance) {
+		triangles = append(triangles, StrokePolyline(line.Points, line.Closed, style)...)
+	}
+
+	return triangles
+}
+
+// Append the points of the segment (except its first point, which is already there)
+func (S *pathSegment) flatten(points []V2, tolerance float32) []V2 {
+	switch S.kind {
+
+	case segmentQuad:
+		// Wang's formula: the number of lines needed to stay within tolerance of the curve
+		dd := S.from.Minus(S.c1.Scaled(2)).Plus(S.to).Len()
+		n := segmentCount(math.Sqrt(float64(dd) / (4 * float64(tolerance))))
+		for i := 1; i <= n; i++ {
+			t := float32(i) / float32(n)
+			mt := 1 - t
+			points = append(points, S.from.Scaled(mt*mt).Plus(S.c1.Scaled(2*mt*t)).Plus(S.to.Scaled(t*t)))
+		}
+
+	case segmentCubic:
+		dd := Max(
+			S.from.Minus(S.c1.Scaled(2)).Plus(S.c2).Len(),
+			S.c1.Minus(S.c2.Scaled(2)).Plus(S.to).Len(),
+		)
+		n := segmentCount(math.Sqrt(0.75 * float64(dd) / float64(tolerance)))
+		for i := 1; i <= n; i++ {
+			t := float32(i) / float32(n)
+			mt := 1 - t
+			points = append(points, S.from.Scaled(mt*mt*mt).
+				Plus(S.c1.Scaled(3*mt*mt*t)).
+				Plus(S.c2.Scaled(3*mt*t*t)).
+				Plus(S.to.Scaled(t*t*t)))
+		}
+
+	case segmentArc:
+		radius := float64(Max(S.rx, S.ry))
+		step := math.Pi / 2
+		if float64(tolerance) < radius {
+			step = 2 * math.Acos(1-float64(tolerance)/radius)
+		}
+		n := segmentCount(math.Abs(float64(S.sweep)) / step)
+		for i := 1; i < n; i++ {
+			a := S.startAngle + S.sweep*float32(i)/float32(n)
+			sin, cos := Sincos(a)
+			p := V2{X: S.rx * cos, Y: S.ry * sin}.Rotated(S.rotation)
+			points = append(points, S.center.Plus(p))
+		}
+		points = append(points, S.to) // exactly, not whatever rounding made of it
+
+	default:
+		points = append(points, S.to)
+	}
+
+	return points
+}
+
+func segmentCount(n float64) int {
+	if math.IsNaN(n) || n < 1 {
+		return 1
+	}
+
+	return int(math.Min(math.Ceil(n), 1000))
+}
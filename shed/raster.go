@@ -0,0 +1,225 @@
+package shed
+
+/**
+Software rasterization.
+
+Used when there is no GPU (or no window) available, for instance on a CI server.
+The rasterizer mimics the opengl pipeline used by tractor as closely as is practical:
+- all geometry is the unit quad [-0.5, 0.5] transformed into NDC [-1, 1]
+- nearest-neighbour texture sampling
+- SRC_ALPHA, ONE_MINUS_SRC_ALPHA blending (see EnableBlending)
+*/
+
+import (
+	"image"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	srgbToLinear [256]float32 // lookup table for decoding srgb-encoded texels
+)
+
+func init() {
+	for i := range srgbToLinear {
+		c := float64(i) / 255
+		if c <= 0.04045 {
+			srgbToLinear[i] = float32(c / 12.92)
+		} else {
+			srgbToLinear[i] = float32(math.Pow((c+0.055)/1.055, 2.4))
+		}
+	}
+}
+
+// Create an RGBA image of the given size, filled with a single color
+func CreateCanvas(w, h int, color V4) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	FillCanvas(img, color)
+
+	return img
+}
+
+// Fill the entire image with a single color. No blending is done.
+// This is the software equivalent of ClearScreenF
+func FillCanvas(img *image.RGBA, color V4) {
+	r, g, b, a := colorToBytes(color)
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i+0] = r
+		img.Pix[i+1] = g
+		img.Pix[i+2] = b
+		img.Pix[i+3] = a
+	}
+}
+
+// Rasterize the unit quad (the same quad the GL renderers use) into dst.
+//
+// trMatrix transforms the quad into normalized device coordinates,
+// exactly like uniTransformation does in the vertex shaders.
+//
+// shade is called once per covered pixel with the texture coordinates of
+// that pixel, and must return the (non-premultiplied) fragment color.
+func RasterQuad(dst *image.RGBA, trMatrix mgl32.Mat3, shade func(s, t float32) V4) {
+	bounds := dst.Bounds()
+	w, h := float32(bounds.Dx()), float32(bounds.Dy())
+
+	// NDC => pixel coordinates. Y is flipped because images have (0, 0) in the upper left corner.
+	toPixels := mgl32.Mat3{
+		w / 2, 0, 0,
+		0, -h / 2, 0,
+		float32(bounds.Min.X) + w/2, float32(bounds.Min.Y) + h/2, 1,
+	}
+	m := toPixels.Mul3(trMatrix)
+
+	if mgl32.Abs(m.Det()) < mgl32.Epsilon {
+		return // quad has no area
+	}
+	inv := m.Inv()
+
+	//
+	// Find the bounding box of the quad on screen
+	minX, minY := float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, corner := range [4]mgl32.Vec3{{-0.5, -0.5, 1}, {0.5, -0.5, 1}, {0.5, 0.5, 1}, {-0.5, 0.5, 1}} {
+		p := m.Mul3x1(corner)
+		minX, maxX = Min(minX, p[0]), Max(maxX, p[0])
+		minY, maxY = Min(minY, p[1]), Max(maxY, p[1])
+	}
+
+	x0 := clampInt(int(math.Floor(float64(minX))), bounds.Min.X, bounds.Max.X)
+	x1 := clampInt(int(math.Ceil(float64(maxX))), bounds.Min.X, bounds.Max.X)
+	y0 := clampInt(int(math.Floor(float64(minY))), bounds.Min.Y, bounds.Max.Y)
+	y1 := clampInt(int(math.Ceil(float64(maxY))), bounds.Min.Y, bounds.Max.Y)
+
+	//
+	// Map each pixel center back into the quad's own coordinate system.
+	// If it lands inside the unit quad, the pixel is covered.
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			local := inv.Mul3x1(mgl32.Vec3{float32(px) + 0.5, float32(py) + 0.5, 1})
+
+			if local[0] < -0.5 || local[0] >= 0.5 || local[1] < -0.5 || local[1] >= 0.5 {
+				continue
+			}
+
+			BlendPixel(dst, px, py, shade(local[0]+0.5, local[1]+0.5))
+		}
+	}
+}
+
+// Rasterize a triangle into dst.
+//
+// trMatrix transforms a, b and c into normalized device coordinates,
+// exactly like uniTransformation does in the vertex shaders.
+//
+// shade is called once per covered pixel with the barycentric weights of b and c
+// at that pixel (the weight of a is 1-wb-wc), and must return the fragment color.
+// Pixels on an edge shared by two triangles are only drawn once (top-left rule).
+func RasterTriangle(dst *image.RGBA, trMatrix mgl32.Mat3, a, b, c V2, shade func(wb, wc float32) V4) {
+	bounds := dst.Bounds()
+	w, h := float32(bounds.Dx()), float32(bounds.Dy())
+
+	toPixels := mgl32.Mat3{
+		w / 2, 0, 0,
+		0, -h / 2, 0,
+		float32(bounds.Min.X) + w/2, float32(bounds.Min.Y) + h/2, 1,
+	}
+	m := toPixels.Mul3(trMatrix)
+
+	pa := m.Mul3x1(mgl32.Vec3{a.X, a.Y, 1})
+	pb := m.Mul3x1(mgl32.Vec3{b.X, b.Y, 1})
+	pc := m.Mul3x1(mgl32.Vec3{c.X, c.Y, 1})
+
+	// Wind the triangle clockwise on screen (y points down), so all edge functions are positive inside
+	area := edgeFunction(pa, pb, pc[0], pc[1])
+	if mgl32.Abs(area) < mgl32.Epsilon {
+		return // triangle has no area
+	}
+	swapped := area < 0
+	if swapped {
+		pb, pc = pc, pb
+		area = -area
+	}
+
+	minX := Min(pa[0], Min(pb[0], pc[0]))
+	maxX := Max(pa[0], Max(pb[0], pc[0]))
+	minY := Min(pa[1], Min(pb[1], pc[1]))
+	maxY := Max(pa[1], Max(pb[1], pc[1]))
+
+	x0 := clampInt(int(math.Floor(float64(minX))), bounds.Min.X, bounds.Max.X)
+	x1 := clampInt(int(math.Ceil(float64(maxX))), bounds.Min.X, bounds.Max.X)
+	y0 := clampInt(int(math.Floor(float64(minY))), bounds.Min.Y, bounds.Max.Y)
+	y1 := clampInt(int(math.Ceil(float64(maxY))), bounds.Min.Y, bounds.Max.Y)
+
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			x, y := float32(px)+0.5, float32(py)+0.5
+
+			ea := edgeFunction(pb, pc, x, y) // opposite a
+			eb := edgeFunction(pc, pa, x, y) // opposite b
+			ec := edgeFunction(pa, pb, x, y) // opposite c
+
+			if !edgeCovers(ea, pb, pc) || !edgeCovers(eb, pc, pa) || !edgeCovers(ec, pa, pb) {
+				continue
+			}
+
+			wb, wc := eb/area, ec/area
+			if swapped {
+				wb, wc = wc, wb
+			}
+
+			BlendPixel(dst, px, py, shade(wb, wc))
+		}
+	}
+}
+
+// Twice the signed area of the triangle (p, q, (x, y))
+func edgeFunction(p, q mgl32.Vec3, x, y float32) float32 {
+	return (q[0]-p[0])*(y-p[1]) - (q[1]-p[1])*(x-p[0])
+}
+
+// Is a pixel with the given edge function value inside the edge p -> q?
+// Pixels exactly on the edge belong to top and left edges only.
+func edgeCovers(e float32, p, q mgl32.Vec3) bool {
+	if e != 0 {
+		return e > 0
+	}
+
+	dx, dy := q[0]-p[0], q[1]-p[1]
+
+	return dy < 0 || (dy == 0 && dx > 0)
+}
+
+// Blend a color onto a single pixel using SRC_ALPHA, ONE_MINUS_SRC_ALPHA
+// Just like opengl, the blend function is also applied to the alpha channel.
+func BlendPixel(dst *image.RGBA, x, y int, c V4) {
+	i := dst.PixOffset(x, y)
+	pix := dst.Pix[i : i+4 : i+4]
+
+	a := mgl32.Clamp(c.C4, 0, 1)
+	inv := 1 - a
+
+	pix[0] = floatToByte(mgl32.Clamp(c.C1, 0, 1)*a + float32(pix[0])/255*inv)
+	pix[1] = floatToByte(mgl32.Clamp(c.C2, 0, 1)*a + float32(pix[1])/255*inv)
+	pix[2] = floatToByte(mgl32.Clamp(c.C3, 0, 1)*a + float32(pix[2])/255*inv)
+	pix[3] = floatToByte(a*a + float32(pix[3])/255*inv)
+}
+
+func colorToBytes(c V4) (r, g, b, a uint8) {
+	return floatToByte(c.C1), floatToByte(c.C2), floatToByte(c.C3), floatToByte(c.C4)
+}
+
+func floatToByte(f float32) uint8 {
+	return uint8(mgl32.Clamp(f, 0, 1)*255 + 0.5)
+}
+
+func clampInt(i, min, max int) int {
+	if i < min {
+		return min
+	}
+	if i > max {
+		return max
+	}
+
+	return i
+}
@@ -16,6 +16,8 @@ type ShaderProgram struct {
 	vertShaderId uint32
 	fragShaderId uint32
 	programId    uint32
+	vertPath     string
+	fragPath     string
 }
 
 func CreateShaderProgramFromFiles(vertPath, fragPath string) *ShaderProgram {
@@ -26,33 +28,81 @@ func CreateShaderProgramFromFiles(vertPath, fragPath string) *ShaderProgram {
 		vertShaderId: 0,
 		fragShaderId: 0,
 		programId:    0,
+		vertPath:     vertPath,
+		fragPath:     fragPath,
 	}
-	var err error
 
-	if S.vertShaderId, err = compileShader(gl.VERTEX_SHADER, vertPath); err != nil {
+	programId, err := S.buildProgram()
+	if err != nil {
 		GlPanic(err)
 	}
+	S.programId = programId
 
-	if S.fragShaderId, err = compileShader(gl.FRAGMENT_SHADER, fragPath); err != nil {
-		GlPanic(err)
+	AssertGLOK("CreateShaderFromFile")
+	return &S
+}
+
+// Recompile the shader from its files.
+//
+// If compiling or linking fails, the error is returned and the old program is kept,
+// so a typo in a shader does not bring down the running program.
+// Attributes keep their locations, so vertex arrays set up for the old program still work.
+func (S *ShaderProgram) Reload() error {
+	programId, err := S.buildProgram()
+	if err != nil {
+		return err
 	}
 
-	S.programId = gl.CreateProgram()
-	gl.AttachShader(S.programId, S.vertShaderId)
-	gl.AttachShader(S.programId, S.fragShaderId)
-	gl.LinkProgram(S.programId)
+	gl.DeleteProgram(S.programId)
+	S.programId = programId
+	S.uniforms = make(map[string]int32) // uniform locations may have changed
 
-	if err := S.getLinkError(); err != nil {
-		GlPanic(fmt.Errorf("could not link shaders. %v", err))
+	AssertGLOK("Shader.Reload")
+	return nil
+}
+
+// The files the shader was compiled from
+func (S *ShaderProgram) Files() (vertPath, fragPath string) {
+	return S.vertPath, S.fragPath
+}
+
+// Compile and link a new program from the shader's files
+func (S *ShaderProgram) buildProgram() (uint32, error) {
+	var err error
+
+	if S.vertShaderId, err = compileShader(gl.VERTEX_SHADER, S.vertPath); err != nil {
+		return 0, err
 	}
 
-	gl.DetachShader(S.programId, S.vertShaderId)
-	gl.DetachShader(S.programId, S.fragShaderId)
+	if S.fragShaderId, err = compileShader(gl.FRAGMENT_SHADER, S.fragPath); err != nil {
+		gl.DeleteShader(S.vertShaderId)
+		return 0, err
+	}
+
+	programId := gl.CreateProgram()
+	gl.AttachShader(programId, S.vertShaderId)
+	gl.AttachShader(programId, S.fragShaderId)
+
+	// when reloading, attributes must stay where the vertex arrays expect them
+	for name, loc := range S.attribs {
+		if loc >= 0 {
+			gl.BindAttribLocation(programId, uint32(loc), GlStr(name))
+		}
+	}
+
+	gl.LinkProgram(programId)
+
+	gl.DetachShader(programId, S.vertShaderId)
+	gl.DetachShader(programId, S.fragShaderId)
 	gl.DeleteShader(S.vertShaderId)
 	gl.DeleteShader(S.fragShaderId)
 
-	AssertGLOK("CreateShaderFromFile")
-	return &S
+	if err := getLinkError(programId); err != nil {
+		gl.DeleteProgram(programId)
+		return 0, fmt.Errorf("could not link shaders. %v", err)
+	}
+
+	return programId, nil
 }
 
 func (S *ShaderProgram) getAttribLocation(name string) (uint32, error) {
@@ -206,6 +256,19 @@ func (S *ShaderProgram) VertexAttribPointer(name string, size int32, xtype uint3
 	AssertGLOK("VertexAttribPointer", name)
 }
 
+// Make an attribute advance once per divisor instances instead of once per vertex.
+// Used for instanced rendering.
+func (S *ShaderProgram) VertexAttribDivisor(name string, divisor uint32) {
+	pos, err := S.getAttribLocation(name)
+	if err != nil {
+		GlPanic(fmt.Errorf("VertexAttribDivisor: %v", err))
+	}
+
+	gl.VertexAttribDivisor(pos, divisor)
+
+	AssertGLOK("VertexAttribDivisor", name)
+}
+
 func (S *ShaderProgram) Use() {
 	gl.UseProgram(S.programId)
 	AssertGLOK("Shader.Use")
@@ -216,15 +279,15 @@ func (S *ShaderProgram) Destroy() {
 	AssertGLOK("Shader.Destroy")
 }
 
-func (S *ShaderProgram) getLinkError() error {
+func getLinkError(programId uint32) error {
 
 	var link_status int32
 
-	gl.GetProgramiv(S.programId, gl.LINK_STATUS, &link_status)
+	gl.GetProgramiv(programId, gl.LINK_STATUS, &link_status)
 
 	if link_status != gl.TRUE {
-		logStr := GetProgramLog(S.programId)
-		return GlProbablePanic(fmt.Errorf("linker Error: %v", logStr))
+		logStr := GetProgramLog(programId)
+		return fmt.Errorf("linker Error: %v", logStr)
 	}
 
 	return nil
@@ -255,6 +318,7 @@ func compileShader(shaderType uint32, filePath string) (shader_id uint32, err er
 
 	if success != gl.TRUE {
 		logStr := GetShaderInfoLog(shader_id)
+		gl.DeleteShader(shader_id)
 
 		if logStr == "" {
 			return 0, fmt.Errorf("cannot compile shader '%s'", filePath)
@@ -0,0 +1,483 @@
+package shed
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// How the segments of a thick line meet
+type LineJoin int
+
+const (
+	JoinMiter LineJoin = iota // sharp corners. Falls back to JoinBevel when the corner is sharper than MiterLimit allows
+	JoinRound
+	JoinBevel // corners cut off
+)
+
+// What the ends of a thick line look like
+type LineCap int
+
+const (
+	CapButt   LineCap = iota // the line stops at the end point
+	CapRound                 // a half circle around the end point
+	CapSquare                // the line goes on for half its width past the end point
+)
+
+type StrokeStyle struct {
+	Width      float32
+	Join       LineJoin
+	Cap        LineCap
+	MiterLimit float32 // longest allowed miter, in line widths. Zero means 4 (the SVG default)
+	Tolerance  float32 // how far round joins and caps may stray from a true circle. Zero means 0.25
+
+	Feather    float32   // width of the edges that fade out, centered on the outline. Zero means hard edges
+	Dashes     []float32 // lengths of the dashes and the gaps between them, alternating. An odd list is repeated. Empty means a solid line
+	DashOffset float32   // how far into the dash pattern the line starts
+}
+
+// A corner of a triangle of a stroke, in the layout the line strip shaders take
+type StrokeVertex struct {
+	Pos   V2
+	Color V4
+}
+
+// Triangulate a thick line through the points.
+// Returns a list of triangles: every three points make a triangle.
+// The triangles don't overlap, so translucent lines have the same color everywhere, corners included.
+func StrokePolyline(points []V2, closed bool, style StrokeStyle) []V2 {
+	style.Feather = 0 // the colors are dropped, and with them the fading edges
+
+	vertices := StrokeColoredPolyline(points, nil, closed, style)
+
+	triangles := make([]V2, len(vertices))
+	for i, v := range vertices {
+		triangles[i] = v.Pos
+	}
+
+	return triangles
+}
+
+// Like StrokePolyline, but every vertex has a color: colors has one for every point, and they
+// are blended along the line. Without one for every point, the line is white.
+// The edges fade out to transparent over style.Feather.
+func StrokeColoredPolyline(points []V2, colors []V4, closed bool, style StrokeStyle) []StrokeVertex {
+	if len(colors) != len(points) {
+		colors = make([]V4, len(points))
+		for i := range colors {
+			colors[i] = OPAQ_WHITE()
+		}
+	}
+
+	points, colors = strokeWithoutDuplicates(points, colors, closed)
+	closed = closed && len(points) > 2 // there and back again is just a line
+
+	M := strokeMesher{
+		half:       style.Width / 2,
+		join:       style.Join,
+		cap:        style.Cap,
+		miterLimit: style.MiterLimit,
+		tolerance:  style.Tolerance,
+	}
+	if M.half <= 0 {
+		return nil
+	}
+	if M.miterLimit <= 0 {
+		M.miterLimit = 4
+	}
+	if M.tolerance <= 0 {
+		M.tolerance = 0.25
+	}
+
+	// The visible edge stays where it should be: the feather is half inside and half outside of it
+	M.core, M.outer = M.half, M.half
+	if style.Feather > 0 {
+		M.core = Max(M.half-style.Feather/2, 0)
+		M.outer = M.half + style.Feather/2
+		M.feather = style.Feather
+		// Lines thinner than the feather fade instead
+		for i := range colors {
+			colors[i].C4 *= Min(1, style.Width/style.Feather)
+		}
+	}
+
+	if len(style.Dashes) == 0 {
+		M.strip(points, colors, closed)
+
+		return M.vertices
+	}
+
+	if closed {
+		points = append(points, points[0])
+		colors = append(colors, colors[0])
+	}
+	for _, dash := range splitDashes(points, colors, style.Dashes, style.DashOffset) {
+		dashPoints, dashColors := strokeWithoutDuplicates(dash.points, dash.colors, false)
+		M.strip(dashPoints, dashColors, false)
+	}
+
+	return M.vertices
+}
+
+// Remove points that are identical to the point before them, and keep the colors in step.
+// Closed lines lose their last point too, if it is the first one again.
+func strokeWithoutDuplicates(points []V2, colors []V4, closed bool) ([]V2, []V4) {
+	outPoints := make([]V2, 0, len(points))
+	outColors := make([]V4, 0, len(points))
+
+	for i, p := range points {
+		if len(outPoints) == 0 || outPoints[len(outPoints)-1] != p {
+			outPoints = append(outPoints, p)
+			outColors = append(outColors, colors[i])
+		}
+	}
+
+	for closed && len(outPoints) > 1 && outPoints[len(outPoints)-1] == outPoints[0] {
+		outPoints = outPoints[:len(outPoints)-1]
+		outColors = outColors[:len(outColors)-1]
+	}
+
+	return outPoints, outColors
+}
+
+type dashStrip struct {
+	points []V2
+	colors []V4
+}
+
+// Cut the polyline into the "on" parts of the dash pattern
+func splitDashes(points []V2, colors []V4, dashes []float32, offset float32) []dashStrip {
+	pattern := dashes
+	if len(pattern)%2 == 1 {
+		pattern = append(append([]float32{}, dashes...), dashes...)
+	}
+
+	var total float32
+	for _, d := range pattern {
+		if d < 0 {
+			return []dashStrip{{points, colors}} // invalid pattern, as in SVG: draw a solid line
+		}
+		total += d
+	}
+	if total <= 0 {
+		return []dashStrip{{points, colors}}
+	}
+
+	//
+	// Find where in the pattern the line starts
+	phase := float32(math.Mod(float64(offset), float64(total)))
+	if phase < 0 {
+		phase += total
+	}
+	index := 0
+	for phase > pattern[index] || (phase == pattern[index] && phase > 0) { // a dash of length zero at the start is still a dot
+		phase -= pattern[index]
+		index = (index + 1) % len(pattern)
+	}
+	left := pattern[index] - phase // what is left of the current dash or gap
+
+	result := []dashStrip{}
+	current := dashStrip{}
+	on := index%2 == 0
+	if on {
+		current = dashStrip{points: points[:1:1], colors: colors[:1:1]}
+	}
+
+	for i := 0; i+1 < len(points); i++ {
+		p, q := points[i], points[i+1]
+		length := q.Minus(p).Len()
+		done := float32(0) // how far along p-q we are
+
+		for length-done > left {
+			done += left
+			t := done / length
+			cut := p.Plus(q.Minus(p).Scaled(t))
+			color := colors[i].Mix(colors[i+1], t)
+
+			if on {
+				current.points = append(current.points, cut)
+				current.colors = append(current.colors, color)
+				result = append(result, current)
+			} else {
+				current = dashStrip{points: []V2{cut}, colors: []V4{color}}
+			}
+
+			on = !on
+			index = (index + 1) % len(pattern)
+			left = pattern[index]
+		}
+		left -= length - done
+
+		if on {
+			current.points = append(current.points, q)
+			current.colors = append(current.colors, colors[i+1])
+		}
+	}
+
+	if on && len(current.points) > 1 {
+		result = append(result, current)
+	}
+
+	return result
+}
+
+// Builds the triangles of a line, one polyline at a time
+type strokeMesher struct {
+	half       float32 // half the line width
+	core       float32 // from the center to where the feather starts
+	outer      float32 // from the center to where the feather ends
+	feather    float32
+	join       LineJoin
+	cap        LineCap
+	miterLimit float32
+	tolerance  float32
+
+	vertices []StrokeVertex
+}
+
+// A cut across the line. The points go from left to right: the outside of the feather,
+// the inside of the feather, the inside of the other feather, and the outside of it.
+type strokeSection struct {
+	lOut, lIn, rIn, rOut V2
+	color                V4
+}
+
+// A section at p, with the edges offset along normal (which points left).
+// The offsets are scaled by scale, which makes miters.
+func (M *strokeMesher) section(p, normal V2, scale float32, color V4) strokeSection {
+	return strokeSection{
+		lOut:  p.Plus(normal.Scaled(M.outer * scale)),
+		lIn:   p.Plus(normal.Scaled(M.core * scale)),
+		rIn:   p.Minus(normal.Scaled(M.core * scale)),
+		rOut:  p.Minus(normal.Scaled(M.outer * scale)),
+		color: color,
+	}
+}
+
+// Add a polyline without duplicate points to the mesh
+func (M *strokeMesher) strip(points []V2, colors []V4, closed bool) {
+	if len(points) == 0 {
+		return
+	}
+
+	if len(points) == 1 {
+		// A dot. Only round and square caps make it visible
+		if M.cap == CapButt {
+			return
+		}
+		p, c := points[0], colors[0]
+		dir := V2{X: 1}
+		first := M.section(p, dir.Perpendicular(), 1, c)
+		last := first
+		M.capEnd(&first, p, dir, dir.Scaled(-1))
+		M.capEnd(&last, p, dir, dir)
+		M.connect(first, last)
+		return
+	}
+
+	n := len(points)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+
+	dirs := make([]V2, segments)
+	lengths := make([]float32, segments)
+	for i := range dirs {
+		d := points[(i+1)%n].Minus(points[i])
+		lengths[i] = d.Len()
+		dirs[i] = d.Scaled(1 / lengths[i])
+	}
+
+	//
+	// Every segment runs from the section where it starts to the section where it ends.
+	// Joins end one segment and start the next.
+	var start, closingEnd strokeSection
+
+	if closed {
+		closingEnd, start = M.joint(points[0], colors[0], dirs[segments-1], dirs[0], lengths[segments-1], lengths[0])
+	} else {
+		start = M.section(points[0], dirs[0].Perpendicular(), 1, colors[0])
+		M.capEnd(&start, points[0], dirs[0], dirs[0].Scaled(-1))
+	}
+
+	for i := 0; i < segments; i++ {
+		j := (i + 1) % n
+
+		var end, next strokeSection
+		switch {
+		case closed && j == 0:
+			end = closingEnd
+		case !closed && j == n-1:
+			end = M.section(points[j], dirs[i].Perpendicular(), 1, colors[j])
+			M.capEnd(&end, points[j], dirs[i], dirs[i])
+		default:
+			end, next = M.joint(points[j], colors[j], dirs[i], dirs[j], lengths[i], lengths[j])
+		}
+
+		M.connect(start, end)
+		start = next
+	}
+}
+
+// The sections that end the segment coming in along in, and start the one going out along out,
+// at the corner p. Fills the gap between them on the outside of the corner.
+func (M *strokeMesher) joint(p V2, color V4, in, out V2, inLength, outLength float32) (strokeSection, strokeSection) {
+	nIn, nOut := in.Perpendicular(), out.Perpendicular()
+	turn := in.Cross(out)
+
+	if mgl32.Abs(turn) < 1e-6 && in.Dot(out) > 0 {
+		s := M.section(p, nIn, 1, color) // straight on
+		return s, s
+	}
+
+	//
+	// Where the edges of the two segments meet: along the bisector of their normals
+	bisector := nIn.Plus(nOut)
+	cosHalf := float32(0)
+	if bisector.Len() > 1e-6 {
+		bisector = bisector.Normalized()
+		cosHalf = bisector.Dot(nIn)
+	}
+
+	// On the inside of the corner, both segments stop where their edges cross, so they don't overlap.
+	// Unless a segment is too short to reach it: then they overlap a little.
+	shared := false
+	if cosHalf > 1e-6 {
+		reach := M.outer * float32(math.Sqrt(float64(1/(cosHalf*cosHalf)-1))) // how far along the segments the crossing is
+		shared = reach <= Min(inLength, outLength)
+	}
+
+	if M.join == JoinMiter && shared && 1/cosHalf <= M.miterLimit {
+		s := M.section(p, bisector, 1/cosHalf, color)
+		return s, s
+	}
+
+	end := M.section(p, nIn, 1, color)
+	start := M.section(p, nOut, 1, color)
+	inner := p
+
+	left := turn < 0 // turning right leaves a gap on the left
+	sign := float32(1)
+	if !left {
+		sign = -1
+	}
+	if shared {
+		miter := M.section(p, bisector, 1/cosHalf, color)
+		if left {
+			end.rIn, end.rOut = miter.rIn, miter.rOut
+			start.rIn, start.rOut = miter.rIn, miter.rOut
+			inner = miter.rIn
+		} else {
+			end.lIn, end.lOut = miter.lIn, miter.lOut
+			start.lIn, start.lOut = miter.lIn, miter.lOut
+			inner = miter.lIn
+		}
+	}
+
+	//
+	// The gap on the outside
+	fromIn, fromOut := end.lIn, end.lOut
+	toIn, toOut := start.lIn, start.lOut
+	if !left {
+		fromIn, fromOut = end.rIn, end.rOut
+		toIn, toOut = start.rIn, start.rOut
+	}
+
+	if M.join == JoinRound {
+		angle := float32(math.Acos(float64(mgl32.Clamp(in.Dot(out), -1, 1)))) * -sign
+		M.arc(p, inner, nIn.Scaled(sign), angle, color)
+	} else {
+		M.triangle(inner, fromIn, toIn, color, color, color)
+		M.featherQuad(fromIn, fromOut, toOut, toIn, color)
+	}
+
+	return end, start
+}
+
+// Extend the end of the line at p by its cap. dir points along the line, and outward away from it.
+func (M *strokeMesher) capEnd(s *strokeSection, p, dir, outward V2) {
+	normal := dir.Perpendicular()
+
+	if M.cap == CapRound {
+		// Half a turn from the left edge to the right edge, around the outside
+		angle := float32(math.Pi)
+		if outward == dir {
+			angle = -math.Pi
+		}
+		M.arc(p, p, normal, angle, s.color)
+		return
+	}
+
+	extend := float32(0)
+	if M.cap == CapSquare {
+		extend = M.half
+	}
+
+	// The solid part stops half a feather short of the edge, and the feather runs past it
+	*s = M.section(p.Plus(outward.Scaled(extend-M.feather/2)), normal, 1, s.color)
+	if M.feather > 0 {
+		faded := M.section(p.Plus(outward.Scaled(extend+M.feather/2)), normal, 1, transparent(s.color))
+		M.connectFlat(*s, faded)
+	}
+}
+
+// A fan around center, with the edge turning angle radians around p from p+start*radius.
+// The feather ring goes around the edge.
+func (M *strokeMesher) arc(p, center, start V2, angle float32, color V4) {
+	step := math.Pi / 2
+	if M.tolerance < M.outer {
+		step = 2 * math.Acos(1-float64(M.tolerance/M.outer))
+	}
+	n := int(math.Ceil(math.Abs(float64(angle)) / step))
+	if n < 1 {
+		n = 1
+	}
+
+	prev := start
+	for i := 1; i <= n; i++ {
+		dir := start.Rotated(angle * float32(i) / float32(n))
+		a, b := p.Plus(prev.Scaled(M.core)), p.Plus(dir.Scaled(M.core))
+
+		M.triangle(center, a, b, color, color, color)
+		if M.feather > 0 {
+			M.featherQuad(a, p.Plus(prev.Scaled(M.outer)), p.Plus(dir.Scaled(M.outer)), b, color)
+		}
+
+		prev = dir
+	}
+}
+
+// The part of the line between two sections: the solid core, and the feather on each side
+func (M *strokeMesher) connect(a, b strokeSection) {
+	M.quad(a.lIn, a.rIn, b.rIn, b.lIn, a.color, a.color, b.color, b.color)
+
+	if M.feather > 0 {
+		M.quad(a.lOut, a.lIn, b.lIn, b.lOut, transparent(a.color), a.color, b.color, transparent(b.color))
+		M.quad(a.rIn, a.rOut, b.rOut, b.rIn, a.color, transparent(a.color), transparent(b.color), b.color)
+	}
+}
+
+// Like connect, but the colors only change from a to b, not from the center to the sides. For the feather of caps
+func (M *strokeMesher) connectFlat(a, b strokeSection) {
+	M.quad(a.lOut, a.rOut, b.rOut, b.lOut, a.color, a.color, b.color, b.color)
+}
+
+// A feather strip from the solid edge inA-inB to the faded edge outA-outB
+func (M *strokeMesher) featherQuad(inA, outA, outB, inB V2, color V4) {
+	M.quad(inA, outA, outB, inB, color, transparent(color), transparent(color), color)
+}
+
+func (M *strokeMesher) quad(a, b, c, d V2, ca, cb, cc, cd V4) {
+	M.triangle(a, b, c, ca, cb, cc)
+	M.triangle(a, c, d, ca, cc, cd)
+}
+
+func (M *strokeMesher) triangle(a, b, c V2, ca, cb, cc V4) {
+	M.vertices = append(M.vertices, StrokeVertex{a, ca}, StrokeVertex{b, cb}, StrokeVertex{c, cc})
+}
+
+func transparent(color V4) V4 {
+	color.C4 = 0
+	return color
+}
@@ -0,0 +1,103 @@
+package shed
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStrokePolyline(t *testing.T) {
+	line := []V2{{0, 0}, {10, 0}}
+	corner := []V2{{0, 0}, {10, 0}, {10, 10}}
+	round := float32(math.Pi / 4) // a quarter of a circle with a radius of 1
+
+	tests := []struct {
+		name    string
+		points  []V2
+		closed  bool
+		style   StrokeStyle
+		area    float32 // the triangles must not overlap, so this is the sum of their areas
+		inside  []V2
+		outside []V2
+	}{
+		{"butt", line, false, StrokeStyle{Width: 2}, 20, []V2{{5, 0.9}, {0.1, 0}}, []V2{{-0.1, 0}, {5, 1.1}}},
+		{"square caps", line, false, StrokeStyle{Width: 2, Cap: CapSquare}, 24, []V2{{-0.9, 0}, {10.9, 0}}, []V2{{-1.1, 0}}},
+		{"round caps", line, false, StrokeStyle{Width: 2, Cap: CapRound, Tolerance: 1e-4}, 20 + 4*round, []V2{{-0.9, 0}}, []V2{{-0.9, 0.9}}},
+		{"duplicates", []V2{{0, 0}, {0, 0}, {10, 0}, {10, 0}}, false, StrokeStyle{Width: 2}, 20, []V2{{5, 0}}, nil},
+		{"miter", corner, false, StrokeStyle{Width: 2}, 40, []V2{{10.9, -0.9}}, []V2{{11.1, -1.1}}},
+		{"bevel", corner, false, StrokeStyle{Width: 2, Join: JoinBevel}, 39.5, []V2{{10.4, -0.4}}, []V2{{10.9, -0.9}}},
+		{"round join", corner, false, StrokeStyle{Width: 2, Join: JoinRound, Tolerance: 1e-4}, 39 + round, []V2{{10.6, -0.6}}, []V2{{10.8, -0.8}}},
+		{"miter limit", corner, false, StrokeStyle{Width: 2, MiterLimit: 1.2}, 39.5, nil, []V2{{10.9, -0.9}}},
+		{"closed", square(0, 0, 10, 10), true, StrokeStyle{Width: 2}, 80, []V2{{-0.9, -0.9}, {5, 9.5}}, []V2{{5, 5}}},
+		{"closed, last point repeated", append(square(0, 0, 10, 10), V2{0, 0}), true, StrokeStyle{Width: 2}, 80, []V2{{-0.9, -0.9}}, []V2{{5, 5}}},
+		{"dashes", line, false, StrokeStyle{Width: 2, Dashes: []float32{2, 2}}, 12, []V2{{1, 0}, {5, 0}, {9, 0}}, []V2{{3, 0}, {7, 0}}},
+		{"dash offset", line, false, StrokeStyle{Width: 2, Dashes: []float32{2, 2}, DashOffset: 1}, 10, []V2{{0.5, 0}, {3.5, 0}}, []V2{{1.5, 0}}},
+		{"dot", []V2{{3, 3}}, false, StrokeStyle{Width: 2, Cap: CapSquare}, 4, []V2{{3.9, 3.9}}, []V2{{4.1, 3}}},
+		{"no width", line, false, StrokeStyle{}, 0, nil, []V2{{5, 0}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			triangles := StrokePolyline(test.points, test.closed, test.style)
+
+			if len(triangles)%3 != 0 {
+				t.Fatalf("%d points do not make triangles", len(triangles))
+			}
+			if area := trianglesArea(triangles); math.Abs(float64(area-test.area)) > 1e-3 {
+				t.Fatalf("the triangles have an area of %v, expected %v", area, test.area)
+			}
+			for _, p := range test.inside {
+				if !covers(triangles, p) {
+					t.Fatalf("%v is not covered", p)
+				}
+			}
+			for _, p := range test.outside {
+				if covers(triangles, p) {
+					t.Fatalf("%v is covered", p)
+				}
+			}
+		})
+	}
+}
+
+func TestStrokeFeather(t *testing.T) {
+	red, green, blue := V4{C1: 1, C4: 1}, V4{C2: 1, C4: 1}, V4{C3: 1, C4: 1}
+
+	vertices := StrokeColoredPolyline([]V2{{0, 0}, {10, 0}, {20, 0}}, []V4{red, green, blue}, false, StrokeStyle{Width: 2, Feather: 1})
+
+	// The cut across the line at the middle point. The caps fade along the line instead, so they are left out
+	middle := 0
+	for _, v := range vertices {
+		if v.Pos.X != 10 {
+			continue
+		}
+		middle++
+
+		if v.Color.C1 != 0 || v.Color.C2 != 1 || v.Color.C3 != 0 {
+			t.Fatalf("the color at %v is %v, expected the color of the middle point", v.Pos, v.Color)
+		}
+
+		// The solid core ends half a feather inside the edge, and the feather fades out half a feather outside of it
+		switch y := math.Abs(float64(v.Pos.Y)); {
+		case math.Abs(y-0.5) < 1e-4:
+			if v.Color.C4 != 1 {
+				t.Fatalf("%v is in the core, but its alpha is %v", v.Pos, v.Color.C4)
+			}
+		case math.Abs(y-1.5) < 1e-4:
+			if v.Color.C4 != 0 {
+				t.Fatalf("%v is on the outside of the feather, but its alpha is %v", v.Pos, v.Color.C4)
+			}
+		default:
+			t.Fatalf("%v is neither in the core nor on the outside of the feather", v.Pos)
+		}
+	}
+	if middle == 0 {
+		t.Fatalf("no vertices at the middle point")
+	}
+
+	// Lines thinner than the feather fade instead
+	for _, v := range StrokeColoredPolyline([]V2{{0, 0}, {10, 0}}, nil, false, StrokeStyle{Width: 0.5, Feather: 1}) {
+		if v.Color.C4 > 0.5 {
+			t.Fatalf("a line half as wide as its feather has an alpha of %v at %v", v.Color.C4, v.Pos)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package shed
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Parse the d attribute of an SVG <path> element, for instance "M 10 10 L 90 10 Q 50 50 10 10 Z".
+// All commands are supported (M L H V C S Q T A Z), both absolute and relative.
+//
+// Note that SVG has y pointing down. Flip the path (for instance with a negative
+// scale) when drawing it with a camera that has y pointing up.
+func ParseSVGPath(d string) (*Path, error) {
+	P := CreatePath()
+	s := svgScanner{d: d}
+
+	var cmd byte
+	var lastControl V2 // the last control point of the previous curve, for S and T
+	var lastCmd byte
+
+	for {
+		s.skipSeparators()
+		if s.done() {
+			break
+		}
+
+		if c := s.peek(); isSVGCommand(c) {
+			if cmd == 0 && c != 'M' && c != 'm' {
+				return nil, s.errorf("a path must start with M")
+			}
+			cmd = c
+			s.pos++
+		} else if cmd == 0 {
+			return nil, s.errorf("expected a command")
+		} else if cmd == 'Z' || cmd == 'z' {
+			return nil, s.errorf("unexpected number after Z")
+		}
+		// else: the previous command is repeated with a new set of arguments
+
+		relative := cmd >= 'a'
+		cur := P.CurrentPoint()
+		abs := func(x, y float32) (float32, float32) {
+			if relative {
+				return cur.X + x, cur.Y + y
+			}
+			return x, y
+		}
+
+		switch cmd {
+		case 'M', 'm':
+			args, err := s.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			P.MoveTo(abs(args[0], args[1]))
+			// further pairs are lines
+			if relative {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+
+		case 'L', 'l':
+			args, err := s.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			P.LineTo(abs(args[0], args[1]))
+
+		case 'H', 'h':
+			args, err := s.numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			x, _ := abs(args[0], 0)
+			P.LineTo(x, cur.Y)
+
+		case 'V', 'v':
+			args, err := s.numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			_, y := abs(0, args[0])
+			P.LineTo(cur.X, y)
+
+		case 'C', 'c':
+			args, err := s.numbers(6)
+			if err != nil {
+				return nil, err
+			}
+			c1x, c1y := abs(args[0], args[1])
+			c2x, c2y := abs(args[2], args[3])
+			x, y := abs(args[4], args[5])
+			P.CubicTo(c1x, c1y, c2x, c2y, x, y)
+			lastControl = V2{c2x, c2y}
+
+		case 'S', 's':
+			args, err := s.numbers(4)
+			if err != nil {
+				return nil, err
+			}
+			c1 := reflectControl(cur, lastControl, lastCmd, "CcSs")
+			c2x, c2y := abs(args[0], args[1])
+			x, y := abs(args[2], args[3])
+			P.CubicTo(c1.X, c1.Y, c2x, c2y, x, y)
+			lastControl = V2{c2x, c2y}
+
+		case 'Q', 'q':
+			args, err := s.numbers(4)
+			if err != nil {
+				return nil, err
+			}
+			cx, cy := abs(args[0], args[1])
+			x, y := abs(args[2], args[3])
+			P.QuadTo(cx, cy, x, y)
+			lastControl = V2{cx, cy}
+
+		case 'T', 't':
+			args, err := s.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			c := reflectControl(cur, lastControl, lastCmd, "QqTt")
+			x, y := abs(args[0], args[1])
+			P.QuadTo(c.X, c.Y, x, y)
+			lastControl = c
+
+		case 'A', 'a':
+			rx, ry, rotation, err := s.arcRadii()
+			if err != nil {
+				return nil, err
+			}
+			largeArc, err := s.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := s.flag()
+			if err != nil {
+				return nil, err
+			}
+			args, err := s.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			x, y := abs(args[0], args[1])
+			P.ArcTo(rx, ry, mgl32.DegToRad(rotation), largeArc, sweep, x, y)
+
+		case 'Z', 'z':
+			P.Close()
+		}
+
+		lastCmd = cmd
+	}
+
+	return P, nil
+}
+
+func isSVGCommand(c byte) bool {
+	switch c {
+	case 'M', 'm', 'L', 'l', 'H', 'h', 'V', 'v', 'C', 'c', 'S', 's', 'Q', 'q', 'T', 't', 'A', 'a', 'Z', 'z':
+		return true
+	}
+
+	return false
+}
+
+// The first control point of a smooth curve is the reflection of the previous curve's
+// last control point. If the previous command was not a curve of the same kind, it is the current point.
+func reflectControl(cur, lastControl V2, lastCmd byte, sameKind string) V2 {
+	for i := range sameKind {
+		if sameKind[i] == lastCmd {
+			return cur.Scaled(2).Minus(lastControl)
+		}
+	}
+
+	return cur
+}
+
+type svgScanner struct {
+	d   string
+	pos int
+}
+
+func (S *svgScanner) done() bool {
+	return S.pos >= len(S.d)
+}
+
+func (S *svgScanner) peek() byte {
+	return S.d[S.pos]
+}
+
+func (S *svgScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("svg path, at position %d: %s", S.pos, fmt.Sprintf(format, args...))
+}
+
+func (S *svgScanner) skipSeparators() {
+	for !S.done() {
+		switch S.peek() {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			S.pos++
+		default:
+			return
+		}
+	}
+}
+
+// Read n numbers
+func (S *svgScanner) numbers(n int) ([]float32, error) {
+	result := make([]float32, n)
+
+	for i := range result {
+		f, err := S.number()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = f
+	}
+
+	return result, nil
+}
+
+// Read a number like "-12", ".5", "1e-3" or "3.". Numbers need no separator when
+// the next one starts with a sign or a second decimal point: "1-2.5.5" is 1, -2.5, 0.5
+func (S *svgScanner) number() (float32, error) {
+	S.skipSeparators()
+	start := S.pos
+
+	if !S.done() && (S.peek() == '+' || S.peek() == '-') {
+		S.pos++
+	}
+
+	digits, dot := 0, false
+	for !S.done() {
+		c := S.peek()
+		if c >= '0' && c <= '9' {
+			digits++
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		S.pos++
+	}
+
+	if digits == 0 {
+		S.pos = start
+		return 0, S.errorf("expected a number")
+	}
+
+	if !S.done() && (S.peek() == 'e' || S.peek() == 'E') {
+		mark := S.pos
+		S.pos++
+		if !S.done() && (S.peek() == '+' || S.peek() == '-') {
+			S.pos++
+		}
+		expDigits := 0
+		for !S.done() && S.peek() >= '0' && S.peek() <= '9' {
+			S.pos++
+			expDigits++
+		}
+		if expDigits == 0 {
+			S.pos = mark // not an exponent after all
+		}
+	}
+
+	f, err := strconv.ParseFloat(S.d[start:S.pos], 32)
+	if err != nil {
+		return 0, S.errorf("invalid number '%s'", S.d[start:S.pos])
+	}
+
+	return float32(f), nil
+}
+
+func (S *svgScanner) arcRadii() (rx, ry, rotation float32, err error) {
+	args, err := S.numbers(3)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return args[0], args[1], args[2], nil
+}
+
+// Read an arc flag. Flags are a single 0 or 1, and need no separator: "a1 1 0 00 1 1" is valid
+func (S *svgScanner) flag() (bool, error) {
+	S.skipSeparators()
+
+	if S.done() || (S.peek() != '0' && S.peek() != '1') {
+		return false, S.errorf("expected an arc flag (0 or 1)")
+	}
+
+	value := S.peek() == '1'
+	S.pos++
+
+	return value, nil
+}
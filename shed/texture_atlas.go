@@ -21,6 +21,7 @@ type SubTexture struct {
 	Y      uint   `xml:"y,attr"`      // y coordinate (in pixels, relative to (0, 0) in the image file
 	Width  uint   `xml:"width,attr"`  // width of the subtex
 	Height uint   `xml:"height,attr"` // height of the subtex
+	Runes  string `xml:"runes,attr"`  // Optional. The characters this subtex is a glyph for (used by text renderers)
 }
 
 // Laod a file containing a texture atlas.
@@ -0,0 +1,250 @@
+package shed
+
+import "sort"
+
+// Triangulate a simple polygon (convex or concave, in either winding order) by ear clipping.
+// Returns a list of triangles: every three points make a triangle.
+//
+// The polygon must not intersect itself. If it does, the result still covers
+// most of it, but some triangles may overlap or be missing.
+func Triangulate(polygon []V2) []V2 {
+	points := withoutDuplicates(polygon)
+	if len(points) < 3 {
+		return nil
+	}
+
+	// Ears are convex corners. Which way is convex depends on the winding
+	winding := Sign(signedArea(points))
+	if winding == 0 {
+		return nil // no area
+	}
+
+	remaining := make([]int, len(points))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	triangles := make([]V2, 0, 3*(len(points)-2))
+
+	for len(remaining) > 3 {
+		n := len(remaining)
+		clipped := -1
+
+		for i := 0; i < n && clipped < 0; i++ {
+			a := points[remaining[(i+n-1)%n]]
+			b := points[remaining[i]]
+			c := points[remaining[(i+1)%n]]
+
+			turn := b.Minus(a).Cross(c.Minus(b)) * winding
+			switch {
+			case turn == 0:
+				clipped = i // b is on the line between its neighbours. Drop it without a triangle
+			case turn > 0 && isEar(points, remaining, a, b, c):
+				triangles = append(triangles, a, b, c)
+				clipped = i
+			}
+		}
+
+		if clipped < 0 {
+			// No ears: the polygon intersects itself. Clip a corner anyway, so we get something
+			clipped = 0
+			triangles = append(triangles, points[remaining[n-1]], points[remaining[0]], points[remaining[1]])
+		}
+
+		remaining = append(remaining[:clipped], remaining[clipped+1:]...)
+	}
+
+	a, b, c := points[remaining[0]], points[remaining[1]], points[remaining[2]]
+	if b.Minus(a).Cross(c.Minus(b)) != 0 {
+		triangles = append(triangles, a, b, c)
+	}
+
+	return triangles
+}
+
+// Twice the signed area of a polygon. Positive for counter-clockwise polygons (with y pointing up)
+func signedArea(polygon []V2) float32 {
+	var area float32
+
+	for i := range polygon {
+		area += polygon[i].Cross(polygon[(i+1)%len(polygon)])
+	}
+
+	return area
+}
+
+// Is the triangle a, b, c free of all the other corners of the polygon?
+func isEar(points []V2, remaining []int, a, b, c V2) bool {
+	for _, i := range remaining {
+		p := points[i]
+		if p == a || p == b || p == c {
+			continue
+		}
+		if pointInTriangle(p, a, b, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Is p inside (or on an edge of) the triangle a, b, c?
+func pointInTriangle(p, a, b, c V2) bool {
+	d1 := b.Minus(a).Cross(p.Minus(a))
+	d2 := c.Minus(b).Cross(p.Minus(b))
+	d3 := a.Minus(c).Cross(p.Minus(c))
+
+	hasNegative := d1 < 0 || d2 < 0 || d3 < 0
+	hasPositive := d1 > 0 || d2 > 0 || d3 > 0
+
+	return !(hasNegative && hasPositive)
+}
+
+// Remove points that are identical to the point before them (including the last point, if it closes the polygon)
+func withoutDuplicates(polygon []V2) []V2 {
+	points := make([]V2, 0, len(polygon))
+
+	for _, p := range polygon {
+		if len(points) == 0 || points[len(points)-1] != p {
+			points = append(points, p)
+		}
+	}
+
+	for len(points) > 1 && points[len(points)-1] == points[0] {
+		points = points[:len(points)-1]
+	}
+
+	return points
+}
+
+// Which parts of overlapping polygons (holes, self-intersections) are inside. The same as SVG's fill-rule
+type FillRule int
+
+const (
+	FillNonZero FillRule = iota // inside if the outlines wind around the point, on balance. A hole must wind the other way
+	FillEvenOdd                 // inside if a ray from the point crosses the outlines an odd number of times
+)
+
+type fillEdge struct {
+	top, bottom V2      // top.Y < bottom.Y
+	winding     float32 // 1 if the polygon goes down along the edge, -1 if it goes up
+}
+
+// Triangulate the area inside any number of polygons, which may overlap, intersect themselves and
+// each other, and cut holes in each other, as the rule says.
+// Returns a list of triangles: every three points make a triangle.
+//
+// The area is cut into horizontal slabs at every corner and every crossing, so no outline crosses
+// another inside a slab, and the inside of each slab is a row of trapezoids.
+func TriangulateFill(polygons [][]V2, rule FillRule) []V2 {
+	edges := []fillEdge{}
+	for _, polygon := range polygons {
+		points := withoutDuplicates(polygon)
+		if len(points) < 3 {
+			continue
+		}
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+			switch {
+			case a.Y < b.Y:
+				edges = append(edges, fillEdge{a, b, 1})
+			case a.Y > b.Y:
+				edges = append(edges, fillEdge{b, a, -1})
+			} // horizontal edges do not change what is inside
+		}
+	}
+
+	ys := make([]float32, 0, 2*len(edges))
+	for i, e := range edges {
+		ys = append(ys, e.top.Y, e.bottom.Y)
+		for _, other := range edges[i+1:] {
+			if y, crossed := crossingY(e, other); crossed {
+				ys = append(ys, y)
+			}
+		}
+	}
+	sort.Slice(ys, func(i, j int) bool { return ys[i] < ys[j] })
+	sort.Slice(edges, func(i, j int) bool { return edges[i].top.Y < edges[j].top.Y })
+
+	triangles := []V2{}
+	active := []*fillEdge{}
+	next := 0
+
+	type crossing struct {
+		top, bottom, middle float32 // x where the edge crosses the slab
+		winding             float32
+	}
+	row := []crossing{}
+
+	for i := 0; i+1 < len(ys); i++ {
+		y0, y1 := ys[i], ys[i+1]
+		if y0 == y1 {
+			continue
+		}
+
+		for next < len(edges) && edges[next].top.Y <= y0 {
+			active = append(active, &edges[next])
+			next++
+		}
+
+		row = row[:0]
+		still := active[:0]
+		for _, e := range active {
+			if e.bottom.Y <= y0 {
+				continue // done
+			}
+			still = append(still, e)
+			row = append(row, crossing{e.xAt(y0), e.xAt(y1), e.xAt((y0 + y1) / 2), e.winding})
+		}
+		active = still
+
+		sort.Slice(row, func(i, j int) bool { return row[i].middle < row[j].middle })
+
+		var winding float32
+		for j := 0; j+1 < len(row); j++ {
+			winding += row[j].winding
+			if rule == FillEvenOdd && int(winding)%2 == 0 || rule != FillEvenOdd && winding == 0 {
+				continue
+			}
+
+			a, b := row[j], row[j+1]
+			tl, tr := V2{a.top, y0}, V2{b.top, y0}
+			bl, br := V2{a.bottom, y1}, V2{b.bottom, y1}
+			if tl != tr {
+				triangles = append(triangles, tl, tr, br)
+			}
+			if bl != br {
+				triangles = append(triangles, tl, br, bl)
+			}
+		}
+	}
+
+	return triangles
+}
+
+// x where the edge is at height y
+func (E *fillEdge) xAt(y float32) float32 {
+	t := (y - E.top.Y) / (E.bottom.Y - E.top.Y)
+	return E.top.X + (E.bottom.X-E.top.X)*t
+}
+
+// The height where two edges cross, if they cross somewhere other than at their ends
+func crossingY(a, b fillEdge) (float32, bool) {
+	if a.bottom.Y <= b.top.Y || b.bottom.Y <= a.top.Y {
+		return 0, false
+	}
+
+	d1, d2 := a.bottom.Minus(a.top), b.bottom.Minus(b.top)
+	den := d1.Cross(d2)
+	if den == 0 {
+		return 0, false // parallel
+	}
+
+	ab := b.top.Minus(a.top)
+	t, u := ab.Cross(d2)/den, ab.Cross(d1)/den
+	if t <= 0 || t >= 1 || u <= 0 || u >= 1 {
+		return 0, false
+	}
+
+	return a.top.Y + d1.Y*t, true
+}
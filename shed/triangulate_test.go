@@ -0,0 +1,117 @@
+package shed
+
+import (
+	"math"
+	"testing"
+)
+
+func square(x0, y0, x1, y1 float32) []V2 {
+	return []V2{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
+
+func reversed(polygon []V2) []V2 {
+	r := make([]V2, len(polygon))
+	for i, p := range polygon {
+		r[len(polygon)-1-i] = p
+	}
+	return r
+}
+
+// A five pointed star drawn in one stroke, which crosses itself
+func pentagram(r float32) []V2 {
+	star := []V2{}
+	for i := 0; i < 5; i++ {
+		a := float64(i*2)*2*math.Pi/5 - math.Pi/2
+		star = append(star, V2{r * float32(math.Cos(a)), r * float32(math.Sin(a))})
+	}
+	return star
+}
+
+func trianglesArea(triangles []V2) float32 {
+	var area float32
+	for i := 0; i+2 < len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		area += float32(math.Abs(float64(b.Minus(a).Cross(c.Minus(a))))) / 2
+	}
+	return area
+}
+
+func covers(triangles []V2, p V2) bool {
+	for i := 0; i+2 < len(triangles); i += 3 {
+		if pointInTriangle(p, triangles[i], triangles[i+1], triangles[i+2]) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTriangulateFill(t *testing.T) {
+	outer := square(0, 0, 10, 10)
+
+	tests := []struct {
+		name     string
+		polygons [][]V2
+		rule     FillRule
+		area     float32 // the triangles must not overlap, so this is the sum of their areas
+		inside   []V2
+		outside  []V2
+	}{
+		{"square", [][]V2{outer}, FillNonZero, 100, []V2{{5, 5}}, []V2{{11, 5}}},
+		{"nothing", nil, FillNonZero, 0, nil, []V2{{5, 5}}},
+		{"a line", [][]V2{{{0, 0}, {5, 5}, {10, 10}}}, FillNonZero, 0, nil, []V2{{5, 5}}},
+		{"hole, nonzero", [][]V2{outer, reversed(square(3, 3, 7, 7))}, FillNonZero, 84, []V2{{1, 1}}, []V2{{5, 5}}},
+		{"hole, even-odd", [][]V2{outer, reversed(square(3, 3, 7, 7))}, FillEvenOdd, 84, []V2{{1, 1}}, []V2{{5, 5}}},
+		{"same way round, nonzero", [][]V2{outer, square(3, 3, 7, 7)}, FillNonZero, 100, []V2{{1, 1}, {5, 5}}, nil},
+		{"same way round, even-odd", [][]V2{outer, square(3, 3, 7, 7)}, FillEvenOdd, 84, []V2{{1, 1}}, []V2{{5, 5}}},
+		{"overlap, nonzero", [][]V2{outer, square(5, 5, 15, 15)}, FillNonZero, 175, []V2{{7, 7}, {12, 12}}, []V2{{12, 2}}},
+		{"overlap, even-odd", [][]V2{outer, square(5, 5, 15, 15)}, FillEvenOdd, 150, []V2{{2, 2}, {12, 12}}, []V2{{7, 7}}},
+		{"pentagram, nonzero", [][]V2{pentagram(10)}, FillNonZero, 112.257, []V2{{0, 0}, {0, -8}}, []V2{{0, 9}}},
+		{"pentagram, even-odd", [][]V2{pentagram(10)}, FillEvenOdd, 77.568, []V2{{0, -8}}, []V2{{0, 0}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			triangles := TriangulateFill(test.polygons, test.rule)
+
+			if len(triangles)%3 != 0 {
+				t.Fatalf("%d points do not make triangles", len(triangles))
+			}
+			if area := trianglesArea(triangles); math.Abs(float64(area-test.area)) > 0.01 {
+				t.Fatalf("the triangles cover %f, expected %f", area, test.area)
+			}
+			for _, p := range test.inside {
+				if !covers(triangles, p) {
+					t.Fatalf("%v is not filled", p)
+				}
+			}
+			for _, p := range test.outside {
+				if covers(triangles, p) {
+					t.Fatalf("%v is filled", p)
+				}
+			}
+		})
+	}
+}
+
+func TestPathFillHoles(t *testing.T) {
+	// An O: the inner square goes the other way round, as in fonts and SVG icons
+	P, err := ParseSVGPath("M0 0 H10 V10 H0 Z M3 3 V7 H7 V3 Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	triangles := P.Fill(0.25)
+	if covers(triangles, V2{5, 5}) || !covers(triangles, V2{1, 5}) {
+		t.Fatalf("the hole is filled, or the ring is not")
+	}
+
+	// Both the same way round: only even-odd makes a hole
+	P, _ = ParseSVGPath("M0 0 H10 V10 H0 Z M3 3 H7 V7 H3 Z")
+	if !covers(P.Fill(0.25), V2{5, 5}) {
+		t.Fatalf("non-zero should fill the inner square")
+	}
+	P.FillRule = FillEvenOdd
+	if covers(P.Fill(0.25), V2{5, 5}) {
+		t.Fatalf("even-odd should leave the inner square empty")
+	}
+}
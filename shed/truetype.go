@@ -0,0 +1,207 @@
+package shed
+
+/**
+TrueType fonts.
+
+The parts of a .ttf (or .otf) file needed to draw text: the character map,
+glyph outlines, horizontal metrics and kerning. Reading the file is left to
+golang.org/x/image/font/sfnt, which checks every table against the size of
+the file, and glyphs are rasterized with golang.org/x/image/vector.
+
+Hinting is not supported.
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+type TrueTypeFont struct {
+	UnitsPerEm int // Size of the em square, in font units
+	Ascent     int // Distance from the baseline to the top of the tallest glyphs, in font units
+	Descent    int // Distance from the baseline to the bottom of the lowest glyphs, in font units. Negative.
+	LineGap    int // Extra distance between lines, in font units
+
+	font *sfnt.Font
+	mu   sync.Mutex  // guards buf
+	buf  sfnt.Buffer // reused by every call into the font
+}
+
+func LoadTrueTypeFile(filePath string) (*TrueTypeFont, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read font file '%s' - %v", filePath, err)
+	}
+
+	font, err := ParseTrueType(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse font file '%s' - %v", filePath, err)
+	}
+
+	return font, nil
+}
+
+func ParseTrueType(data []byte) (*TrueTypeFont, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	F := &TrueTypeFont{font: f, UnitsPerEm: int(f.UnitsPerEm())}
+	if F.UnitsPerEm <= 0 {
+		return nil, fmt.Errorf("invalid font metrics")
+	}
+
+	m, err := f.Metrics(&F.buf, F.unitsPPEM(), font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	F.Ascent = int(m.Ascent)
+	F.Descent = -int(m.Descent)
+	F.LineGap = int(m.Height - m.Ascent - m.Descent)
+
+	return F, nil
+}
+
+// With one pixel per font unit, sfnt's 26.6 numbers are 64 times too big.
+// With 1/64 pixel per font unit they are in font units
+func (F *TrueTypeFont) unitsPPEM() fixed.Int26_6 {
+	return fixed.Int26_6(F.UnitsPerEm)
+}
+
+// Number of glyphs in the font
+func (F *TrueTypeFont) NumGlyphs() int {
+	return F.font.NumGlyphs()
+}
+
+// Find the glyph for a rune. Zero (the "missing character" glyph) if the font does not have one.
+func (F *TrueTypeFont) GlyphIndex(r rune) uint16 {
+	F.mu.Lock()
+	defer F.mu.Unlock()
+
+	g, err := F.font.GlyphIndex(&F.buf, r)
+	if err != nil {
+		return 0
+	}
+
+	return uint16(g)
+}
+
+// Horizontal advance of a glyph, in font units
+func (F *TrueTypeFont) Advance(glyph uint16) int {
+	F.mu.Lock()
+	defer F.mu.Unlock()
+
+	adv, err := F.font.GlyphAdvance(&F.buf, sfnt.GlyphIndex(glyph), F.unitsPPEM(), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+
+	return int(adv)
+}
+
+// Kerning between two glyphs, in font units.
+func (F *TrueTypeFont) Kern(left, right uint16) int {
+	F.mu.Lock()
+	defer F.mu.Unlock()
+
+	kern, err := F.font.Kern(&F.buf, sfnt.GlyphIndex(left), sfnt.GlyphIndex(right), F.unitsPPEM(), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+
+	return int(kern)
+}
+
+// Rasterize a glyph at the given size (pixels per em).
+//
+// The returned image holds the coverage of each pixel. (left, top) is the position of
+// the image's top-left corner relative to the glyph's origin on the baseline, with y pointing down.
+// Glyphs without an outline (such as space) return a nil image.
+func (F *TrueTypeFont) RasterizeGlyph(glyph uint16, ppem float32) (img *image.Alpha, left, top int, err error) {
+	if !(ppem > 0 && ppem < 1<<16) {
+		return nil, 0, 0, fmt.Errorf("invalid glyph size %f", ppem)
+	}
+
+	F.mu.Lock()
+	defer F.mu.Unlock()
+
+	segments, err := F.font.LoadGlyph(&F.buf, sfnt.GlyphIndex(glyph), fixed.Int26_6(ppem*64), nil)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("glyph %d: %w", glyph, err)
+	}
+	if len(segments) == 0 {
+		return nil, 0, 0, nil
+	}
+
+	// The outline lies within its points. sfnt has y pointing down already
+	bounds := fixed.Rectangle26_6{Min: fixed.Point26_6{X: math.MaxInt32, Y: math.MaxInt32}, Max: fixed.Point26_6{X: math.MinInt32, Y: math.MinInt32}}
+	for _, s := range segments {
+		for _, p := range s.Args[:segmentPoints(s.Op)] {
+			bounds.Min.X, bounds.Max.X = min(bounds.Min.X, p.X), max(bounds.Max.X, p.X)
+			bounds.Min.Y, bounds.Max.Y = min(bounds.Min.Y, p.Y), max(bounds.Max.Y, p.Y)
+		}
+	}
+
+	left, top = bounds.Min.X.Floor(), bounds.Min.Y.Floor()
+	w, h := bounds.Max.X.Ceil()-left, bounds.Max.Y.Ceil()-top
+	if w <= 0 || h <= 0 {
+		return nil, 0, 0, nil
+	}
+
+	// 26.6 => pixels in the image
+	tr := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X)/64 - float32(left), float32(p.Y)/64 - float32(top)
+	}
+
+	z := vector.NewRasterizer(w, h)
+	z.DrawOp = draw.Src
+
+	for i, s := range segments {
+		a := s.Args
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				z.ClosePath()
+			}
+			z.MoveTo(tr(a[0]))
+		case sfnt.SegmentOpLineTo:
+			z.LineTo(tr(a[0]))
+		case sfnt.SegmentOpQuadTo:
+			cx, cy := tr(a[0])
+			x, y := tr(a[1])
+			z.QuadTo(cx, cy, x, y)
+		case sfnt.SegmentOpCubeTo:
+			c1x, c1y := tr(a[0])
+			c2x, c2y := tr(a[1])
+			x, y := tr(a[2])
+			z.CubeTo(c1x, c1y, c2x, c2y, x, y)
+		}
+	}
+	z.ClosePath()
+
+	img = image.NewAlpha(image.Rect(0, 0, w, h))
+	z.Draw(img, img.Bounds(), image.Opaque, image.Point{})
+
+	return img, left, top, nil
+}
+
+// How many of a segment's Args it uses
+func segmentPoints(op sfnt.SegmentOp) int {
+	switch op {
+	case sfnt.SegmentOpQuadTo:
+		return 2
+	case sfnt.SegmentOpCubeTo:
+		return 3
+	}
+	return 1
+}
@@ -0,0 +1,180 @@
+package shed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestParseTrueType(t *testing.T) {
+	F, err := ParseTrueType(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if F.UnitsPerEm != 2048 || F.Ascent <= 0 || F.Descent >= 0 || F.LineGap < 0 {
+		t.Fatalf("unexpected metrics: %d units per em, ascent %d, descent %d, line gap %d",
+			F.UnitsPerEm, F.Ascent, F.Descent, F.LineGap)
+	}
+
+	A, V := F.GlyphIndex('A'), F.GlyphIndex('V')
+	if A == 0 || V == 0 || A == V {
+		t.Fatalf("glyphs A %d and V %d", A, V)
+	}
+	if F.GlyphIndex('\U0010fffd') != 0 {
+		t.Fatalf("a character the font does not have should be glyph 0")
+	}
+	if adv := F.Advance(A); adv <= 0 || adv > F.UnitsPerEm {
+		t.Fatalf("the advance of A is %d", adv)
+	}
+	F.Kern(A, V) // the Go fonts may or may not kern; it must not fail
+
+	img, left, top, err := F.RasterizeGlyph(A, 32)
+	if err != nil || img == nil {
+		t.Fatalf("could not rasterize A: %v", err)
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w < 10 || w > 32 || h < 15 || h > 32 || left < -2 || left > 4 || top > -15 || top < -32 {
+		t.Fatalf("A at 32 pixels per em is %dx%d at %d,%d", w, h, left, top)
+	}
+
+	covered := 0
+	for _, a := range img.Pix {
+		if a > 128 {
+			covered++
+		}
+	}
+	if covered < w*h/8 || covered > w*h*3/4 {
+		t.Fatalf("A covers %d of %d pixels", covered, w*h)
+	}
+
+	if img, _, _, err := F.RasterizeGlyph(F.GlyphIndex(' '), 32); err != nil || img != nil {
+		t.Fatalf("a space should have no image (err %v)", err)
+	}
+	if _, _, _, err := F.RasterizeGlyph(uint16(F.NumGlyphs()), 32); err == nil {
+		t.Fatalf("a glyph past the end should not rasterize")
+	}
+	if _, _, _, err := F.RasterizeGlyph(A, -1); err == nil {
+		t.Fatalf("a negative size should not rasterize")
+	}
+}
+
+// Broken files must give errors (or a font that works), never panics
+func TestParseTrueTypeMalformed(t *testing.T) {
+	data := goregular.TTF
+
+	tests := map[string][]byte{
+		"empty":        nil,
+		"not a font":   []byte("this is not a font, not even close"),
+		"header only":  data[:12],
+		"no tables":    append([]byte{0, 1, 0, 0, 0, 0}, make([]byte, 6)...),
+		"huge tables":  append([]byte{0, 1, 0, 0, 0xff, 0xff}, make([]byte, 6)...),
+		"table dir":    data[:12+16*2],
+		"half":         data[:len(data)/2],
+		"last byte":    data[:len(data)-1],
+		"all but head": append(append([]byte{}, data[:12]...), make([]byte, len(data)-12)...),
+	}
+
+	// Every table of the font, broken in turn
+	numTables := int(data[4])<<8 | int(data[5])
+	for i := 0; i < numTables; i++ {
+		rec := data[12+16*i:]
+		tag := string(rec[:4])
+		offset := int(rec[8])<<24 | int(rec[9])<<16 | int(rec[10])<<8 | int(rec[11])
+		length := int(rec[12])<<24 | int(rec[13])<<16 | int(rec[14])<<8 | int(rec[15])
+
+		garbled := append([]byte{}, data...)
+		for j := offset; j < offset+length && j < len(garbled); j++ {
+			garbled[j] ^= byte(0x5a + j)
+		}
+		tests["garbled "+tag] = garbled
+
+		moved := append([]byte{}, data...)
+		moved[12+16*i+8], moved[12+16*i+9] = 0xff, 0xff // the table starts past the end of the file
+		tests["misplaced "+tag] = moved
+	}
+
+	for name, font := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic: %v", r)
+				}
+			}()
+
+			F, err := ParseTrueType(font)
+			if err != nil {
+				return
+			}
+
+			for _, r := range "AVay, ." {
+				g := F.GlyphIndex(r)
+				F.Advance(g)
+				F.Kern(g, F.GlyphIndex('V'))
+				F.RasterizeGlyph(g, 24)
+			}
+		})
+	}
+}
+
+// The fonts that come with the assets, which are not the Go fonts the other tests use.
+// Tests run in the package directory, so the assets are one level up
+func TestParseBundledFonts(t *testing.T) {
+	for _, name := range []string{"kenvector_future.ttf", "kenvector_future_thin.ttf"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "assets", "Bonus", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			F, err := ParseTrueType(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if F.UnitsPerEm <= 0 || F.Ascent <= 0 || F.Descent >= 0 || F.Ascent-F.Descent > 2*F.UnitsPerEm {
+				t.Fatalf("unexpected metrics: %d units per em, ascent %d, descent %d", F.UnitsPerEm, F.Ascent, F.Descent)
+			}
+
+			// Measure a line the way the text renderer does
+			var width int
+			prev := uint16(0)
+			for i, r := range "SCORE 1234" {
+				g := F.GlyphIndex(r)
+				if g == 0 {
+					t.Fatalf("the font has no glyph for '%c'", r)
+				}
+				if i > 0 {
+					width += F.Kern(prev, g)
+				}
+				width += F.Advance(g)
+				prev = g
+			}
+			if em := int(F.UnitsPerEm); width < 10*em/4 || width > 10*em {
+				t.Fatalf("10 characters are %d units wide, at %d units per em", width, em)
+			}
+
+			for _, r := range "A7" {
+				img, _, top, err := F.RasterizeGlyph(F.GlyphIndex(r), 32)
+				if err != nil || img == nil {
+					t.Fatalf("could not rasterize '%c': %v", r, err)
+				}
+				w, h := img.Bounds().Dx(), img.Bounds().Dy()
+				if w < 8 || w > 40 || h < 12 || h > 40 || top > -12 {
+					t.Fatalf("'%c' at 32 pixels per em is %dx%d, %d above the baseline", r, w, h, -top)
+				}
+
+				covered := 0
+				for _, a := range img.Pix {
+					if a > 128 {
+						covered++
+					}
+				}
+				if covered < w*h/10 || covered > w*h*3/4 {
+					t.Fatalf("'%c' covers %d of %d pixels", r, covered, w*h)
+				}
+			}
+		})
+	}
+}
@@ -170,6 +170,21 @@ func (vec V2) ClampedAngle(min, max float32) V2 {
 	return vec
 }
 
+// Dot product
+func (vec V2) Dot(other V2) float32 {
+	return vec.X*other.X + vec.Y*other.Y
+}
+
+// The z component of the 3D cross product. Positive if other is counter-clockwise from vec
+func (vec V2) Cross(other V2) float32 {
+	return vec.X*other.Y - vec.Y*other.X
+}
+
+// The vector rotated 90 degrees counter-clockwise
+func (vec V2) Perpendicular() V2 {
+	return V2{X: -vec.Y, Y: vec.X}
+}
+
 // Treat vec1 and vec2 as points.
 // Return a "point" right between vec1 and vec2
 func (vec1 V2) Between(vec2 V2) V2 {
@@ -211,6 +226,27 @@ func (vec V4) Plus(other V4) V4 {
 	}
 }
 
+// Component-wise multiplication
+func (vec V4) Times(other V4) V4 {
+	return V4{
+		vec.C1 * other.C1,
+		vec.C2 * other.C2,
+		vec.C3 * other.C3,
+		vec.C4 * other.C4,
+	}
+}
+
+// Unclamped linear interpolation between two vectors.
+// Matches GLSL's mix()
+func (vec V4) Mix(other V4, amt float32) V4 {
+	return V4{
+		LerpU(vec.C1, other.C1, amt),
+		LerpU(vec.C2, other.C2, amt),
+		LerpU(vec.C3, other.C3, amt),
+		LerpU(vec.C4, other.C4, amt),
+	}
+}
+
 func Vec4(c1, c2, c3, c4 float32) V4 {
 	return V4{c1, c2, c3, c4}
 }
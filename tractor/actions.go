@@ -0,0 +1,509 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type InputSource int
+
+const (
+	InputKey InputSource = iota
+	InputMouse
+	InputGamepadButton
+	InputGamepadAxis
+)
+
+// Read every connected gamepad. See Actions.Gamepad
+const AnyGamepad = -1
+
+// One input that drives an action
+type Binding struct {
+	Source InputSource
+	Key    KeyCode       // for InputKey
+	Mouse  MouseButton   // for InputMouse
+	Button GamepadButton // for InputGamepadButton
+	Axis   GamepadAxis   // for InputGamepadAxis
+
+	Half     byte // '+' or '-': only that half of a gamepad axis, read from 0 to 1. 0 for all of it
+	Negative bool // the input pushes the value of the action down instead of up
+}
+
+func KeyBinding(key KeyCode) Binding {
+	return Binding{Source: InputKey, Key: key}
+}
+
+func MouseBinding(button MouseButton) Binding {
+	return Binding{Source: InputMouse, Mouse: button}
+}
+
+func GamepadButtonBinding(button GamepadButton) Binding {
+	return Binding{Source: InputGamepadButton, Button: button}
+}
+
+func GamepadAxisBinding(axis GamepadAxis) Binding {
+	return Binding{Source: InputGamepadAxis, Axis: axis}
+}
+
+// The same input, pushing the other way. Left and right on one axis:
+//
+//	actions.Bind("turn", KeyBinding(KeyCode(KeyLeft)).Negated(), KeyBinding(KeyCode(KeyRight)))
+func (B Binding) Negated() Binding {
+	B.Negative = !B.Negative
+	return B
+}
+
+// =========================================================================
+// ||
+// || Actions.
+// ||
+// || Named things the player does ("fire", "thrust", "turn"), bound to
+// || keys, mouse buttons and gamepad inputs. The game asks for actions,
+// || not keys, so the controls can be changed without changing the code:
+// ||
+// ||   if actions.Pressed("fire") { ... }
+// ||   ship.Turn(actions.Value("turn") * dt)
+// ||
+// || An action has a value from -1 to 1: the sum of its inputs. Keys and
+// || buttons count as 1 while they are held, gamepad axes as far as they
+// || are pushed. It is down while one of its inputs is held, or pushed
+// || further than AxisThreshold.
+// ||
+// || Update() reads the inputs, so "pressed" and "released" mean "since
+// || the last Update()". For Controls.Actions(), Loop() calls it once per
+// || frame, and LoopFixed() before every update.
+// ||
+// =========================================================================
+type Actions struct {
+	Gamepad       int     // the slot of the gamepad to read, or AnyGamepad (the default)
+	AxisThreshold float32 // 0.5 by default
+
+	keyboard *Keyboard
+	mouse    *Mouse
+	gamepads *Gamepads
+	actions  map[string]*action
+}
+
+type action struct {
+	bindings []Binding
+	value    float32
+	down     bool
+	pressed  bool
+	released bool
+}
+
+// Actions that read the given devices. Any of them may be nil
+func CreateActions(keyboard *Keyboard, mouse *Mouse, gamepads *Gamepads) *Actions {
+	return &Actions{
+		Gamepad:       AnyGamepad,
+		AxisThreshold: 0.5,
+		keyboard:      keyboard,
+		mouse:         mouse,
+		gamepads:      gamepads,
+		actions:       make(map[string]*action),
+	}
+}
+
+// Add inputs to an action. The action is created if it does not exist yet
+func (A *Actions) Bind(name string, bindings ...Binding) {
+	act, exists := A.actions[name]
+	if !exists {
+		act = &action{}
+		A.actions[name] = act
+	}
+	act.bindings = append(act.bindings, bindings...)
+}
+
+// Remove all inputs of an action. The action itself stays, so asking for it is not a mistake
+func (A *Actions) Unbind(name string) {
+	if act, exists := A.actions[name]; exists {
+		act.bindings = nil
+	}
+}
+
+// The inputs of an action
+func (A *Actions) Bindings(name string) []Binding {
+	if act, exists := A.actions[name]; exists {
+		return append([]Binding{}, act.bindings...)
+	}
+	return nil
+}
+
+// The names of all actions, sorted
+func (A *Actions) Names() []string {
+	names := make([]string, 0, len(A.actions))
+	for name := range A.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read the inputs of every action
+func (A *Actions) Update() {
+	for _, act := range A.actions {
+		wasDown := act.down
+		tapped := false // went down since the last Update(), maybe up again too
+
+		act.value, act.down = 0, false
+		for _, B := range act.bindings {
+			value := A.read(B)
+			act.value += value
+			act.down = act.down || mgl32.Abs(value) >= A.AxisThreshold
+			tapped = tapped || A.wasPressed(B)
+		}
+
+		act.value = max(-1, min(act.value, 1))
+		act.pressed = !wasDown && (act.down || tapped)
+		act.released = !act.down && (wasDown || tapped)
+	}
+}
+
+// The value of an input, from -1 to 1
+func (A *Actions) read(B Binding) float32 {
+	var value float32
+
+	switch B.Source {
+	case InputKey:
+		if A.keyboard != nil && A.keyboard.IsDown(B.Key) {
+			value = 1
+		}
+	case InputMouse:
+		if A.mouse != nil && A.mouse.IsDown(B.Mouse) {
+			value = 1
+		}
+	case InputGamepadButton:
+		for _, G := range A.pads() {
+			if G.IsDown(B.Button) {
+				value = 1
+			}
+		}
+	case InputGamepadAxis:
+		for _, G := range A.pads() {
+			v := G.Axis(B.Axis)
+			switch B.Half {
+			case '+':
+				v = max(v, 0)
+			case '-':
+				v = max(-v, 0)
+			}
+			if mgl32.Abs(v) > mgl32.Abs(value) {
+				value = v // the gamepad pushed furthest wins
+			}
+		}
+	}
+
+	if B.Negative {
+		value = -value
+	}
+	return value
+}
+
+// Did a key or button go down since the last Update()? Catches taps that are over before Update() sees them
+func (A *Actions) wasPressed(B Binding) bool {
+	switch B.Source {
+	case InputKey:
+		return A.keyboard != nil && A.keyboard.WasPressed(B.Key)
+	case InputMouse:
+		return A.mouse != nil && A.mouse.WasPressed(B.Mouse)
+	}
+	return false
+}
+
+// The gamepads the actions read
+func (A *Actions) pads() []*Gamepad {
+	if A.gamepads == nil {
+		return nil
+	}
+	if A.Gamepad == AnyGamepad {
+		return A.gamepads.Connected()
+	}
+	if G := A.gamepads.Get(A.Gamepad); G != nil {
+		return []*Gamepad{G}
+	}
+	return nil
+}
+
+// Is the action held down?
+func (A *Actions) Down(name string) bool {
+	return A.get(name).down
+}
+
+// Did the action go down since the last Update()?
+func (A *Actions) Pressed(name string) bool {
+	return A.get(name).pressed
+}
+
+// Did the action go up since the last Update()?
+func (A *Actions) Released(name string) bool {
+	return A.get(name).released
+}
+
+// The value of the action, from -1 to 1
+func (A *Actions) Value(name string) float32 {
+	return A.get(name).value
+}
+
+// Two actions as a direction, no longer than 1. For moving with keys and sticks alike
+func (A *Actions) Vector(x, y string) shed.V2 {
+	v := shed.Vec2(A.Value(x), A.Value(y))
+	if length := v.Len(); length > 1 {
+		v = v.Scaled(1 / length)
+	}
+	return v
+}
+
+// Asking for an action that was never bound is a typo
+func (A *Actions) get(name string) *action {
+	act, exists := A.actions[name]
+	if !exists {
+		shed.GlPanic(fmt.Errorf("there is no action called '%s'", name))
+	}
+	return act
+}
+
+// =========================================================================
+// ||
+// || Saving and loading.
+// ||
+// || One action per line, with its inputs separated by commas:
+// ||
+// ||   # comments start with #
+// ||   fire = key:space, mouse:left, pad:a
+// ||   turn = -key:left, key:right, pad:leftx
+// ||   brake = key:s, pad:lefttrigger, pad:+lefty
+// ||
+// || Keys are named like the Key constants, in lower case ("a", "space",
+// || "leftshift", "f1", "kp0"). Mouse buttons are "left", "right",
+// || "middle" or "button4" to "button8". Gamepad inputs use SDL's names
+// || (see GamepadMapping). A "-" in front of an input negates it, and a
+// || "+" or "-" in front of a gamepad axis uses only that half of it.
+// ||
+// =========================================================================
+
+// Load bindings from a string. Actions in the text get the inputs of the text,
+// the other actions keep theirs. Nothing changes if the text has a mistake
+func (A *Actions) LoadString(text string) error {
+	parsed := map[string][]Binding{}
+
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, inputs, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return fmt.Errorf("line %d: expected 'action = input, input, ...', got '%s'", number+1, line)
+		}
+
+		bindings := []Binding{}
+		for _, input := range strings.Split(inputs, ",") {
+			if input = strings.TrimSpace(input); input == "" {
+				continue
+			}
+
+			B, err := ParseBinding(input)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number+1, err)
+			}
+			bindings = append(bindings, B)
+		}
+		parsed[name] = bindings
+	}
+
+	for name, bindings := range parsed {
+		A.Unbind(name)
+		A.Bind(name, bindings...)
+	}
+
+	return nil
+}
+
+// The bindings in the format LoadString() reads
+func (A *Actions) String() string {
+	var sb strings.Builder
+
+	for _, name := range A.Names() {
+		inputs := []string{}
+		for _, B := range A.actions[name].bindings {
+			inputs = append(inputs, B.String())
+		}
+		fmt.Fprintf(&sb, "%s = %s\n", name, strings.Join(inputs, ", "))
+	}
+
+	return sb.String()
+}
+
+func (A *Actions) Load(filename string) error {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := A.LoadString(string(text)); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+func (A *Actions) Save(filename string) error {
+	return os.WriteFile(filename, []byte(A.String()), 0644)
+}
+
+// Parse one input, like "key:space" or "-pad:+leftx"
+func ParseBinding(text string) (Binding, error) {
+	B := Binding{}
+
+	if strings.HasPrefix(text, "-") {
+		B.Negative, text = true, text[1:]
+	}
+
+	source, name, found := strings.Cut(text, ":")
+	if !found {
+		return B, fmt.Errorf("'%s' is not source:input", text)
+	}
+
+	ok := false
+	switch source {
+	case "key":
+		B.Source = InputKey
+		B.Key, ok = keyNames[name]
+	case "mouse":
+		B.Source = InputMouse
+		B.Mouse, ok = mouseButtonNames[name]
+	case "pad":
+		if name != "" && (name[0] == '+' || name[0] == '-') {
+			B.Half, name = name[0], name[1:]
+		}
+		if B.Button, ok = gamepadButtonNames[name]; ok && B.Half == 0 {
+			B.Source = InputGamepadButton
+		} else if B.Axis, ok = gamepadAxisNames[name]; ok {
+			B.Source = InputGamepadAxis
+		}
+	default:
+		return B, fmt.Errorf("unknown input source '%s' in '%s'. Use key, mouse or pad", source, text)
+	}
+
+	if !ok {
+		return B, fmt.Errorf("unknown %s input '%s'", source, name)
+	}
+	return B, nil
+}
+
+// The binding in the format ParseBinding() reads
+func (B Binding) String() string {
+	var text string
+
+	switch B.Source {
+	case InputKey:
+		text = "key:" + nameOf(keyNames, B.Key)
+	case InputMouse:
+		text = "mouse:" + nameOf(mouseButtonNames, B.Mouse)
+	case InputGamepadButton:
+		text = "pad:" + nameOf(gamepadButtonNames, B.Button)
+	case InputGamepadAxis:
+		text = "pad:" + nameOf(gamepadAxisNames, B.Axis)
+		if B.Half != 0 {
+			text = "pad:" + string(B.Half) + text[len("pad:"):]
+		}
+	}
+
+	if B.Negative {
+		text = "-" + text
+	}
+	return text
+}
+
+// The name of a value in a table of names. Names are unique, so the first one found is the only one
+func nameOf[T comparable](names map[string]T, value T) string {
+	for name, v := range names {
+		if v == value {
+			return name
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+var mouseButtonNames = map[string]MouseButton{
+	"left":   MouseLeft,
+	"right":  MouseRight,
+	"middle": MouseMiddle,
+}
+
+var keyNames = map[string]KeyCode{
+	"space":        KeyCode(KeySpace),
+	"apostrophe":   KeyCode(KeyApostrophe),
+	"comma":        KeyCode(KeyComma),
+	"minus":        KeyCode(KeyMinus),
+	"period":       KeyCode(KeyPeriod),
+	"slash":        KeyCode(KeySlash),
+	"semicolon":    KeyCode(KeySemicolon),
+	"equal":        KeyCode(KeyEqual),
+	"leftbracket":  KeyCode(KeyLeftBracket),
+	"backslash":    KeyCode(KeyBackslash),
+	"rightbracket": KeyCode(KeyRightBracket),
+	"graveaccent":  KeyCode(KeyGraveAccent),
+	"world1":       KeyCode(KeyWorld1),
+	"world2":       KeyCode(KeyWorld2),
+	"escape":       KeyCode(KeyEscape),
+	"enter":        KeyCode(KeyEnter),
+	"tab":          KeyCode(KeyTab),
+	"backspace":    KeyCode(KeyBackspace),
+	"insert":       KeyCode(KeyInsert),
+	"delete":       KeyCode(KeyDelete),
+	"right":        KeyCode(KeyRight),
+	"left":         KeyCode(KeyLeft),
+	"down":         KeyCode(KeyDown),
+	"up":           KeyCode(KeyUp),
+	"pageup":       KeyCode(KeyPageUp),
+	"pagedown":     KeyCode(KeyPageDown),
+	"home":         KeyCode(KeyHome),
+	"end":          KeyCode(KeyEnd),
+	"capslock":     KeyCode(KeyCapsLock),
+	"scrolllock":   KeyCode(KeyScrollLock),
+	"numlock":      KeyCode(KeyNumLock),
+	"printscreen":  KeyCode(KeyPrintScreen),
+	"pause":        KeyCode(KeyPause),
+	"kpdecimal":    KeyCode(KeyKPDecimal),
+	"kpdivide":     KeyCode(KeyKPDivide),
+	"kpmultiply":   KeyCode(KeyKPMultiply),
+	"kpsubtract":   KeyCode(KeyKPSubtract),
+	"kpadd":        KeyCode(KeyKPAdd),
+	"kpenter":      KeyCode(KeyKPEnter),
+	"kpequal":      KeyCode(KeyKPEqual),
+	"leftshift":    KeyCode(KeyLeftShift),
+	"leftcontrol":  KeyCode(KeyLeftControl),
+	"leftalt":      KeyCode(KeyLeftAlt),
+	"leftsuper":    KeyCode(KeyLeftSuper),
+	"rightshift":   KeyCode(KeyRightShift),
+	"rightcontrol": KeyCode(KeyRightControl),
+	"rightalt":     KeyCode(KeyRightAlt),
+	"rightsuper":   KeyCode(KeyRightSuper),
+	"menu":         KeyCode(KeyMenu),
+}
+
+// The keys and buttons that come in numbered runs
+func init() {
+	for i := 0; i < 26; i++ {
+		keyNames[string(rune('a'+i))] = KeyCode(KeyA + glfw.Key(i))
+	}
+	for i := 0; i < 10; i++ {
+		keyNames[strconv.Itoa(i)] = KeyCode(Key0 + glfw.Key(i))
+		keyNames["kp"+strconv.Itoa(i)] = KeyCode(KeyKP0 + glfw.Key(i))
+	}
+	for i := 0; i < 25; i++ {
+		keyNames["f"+strconv.Itoa(i+1)] = KeyCode(KeyF1 + glfw.Key(i))
+	}
+	for b := int(MouseMiddle) + 1; b < mouseButtonCount; b++ {
+		mouseButtonNames["button"+strconv.Itoa(b+1)] = MouseButton(b)
+	}
+}
@@ -0,0 +1,75 @@
+package tractor
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// A tap in a frame without a fixed update must reach the next update, and only that one
+func TestLoopFixedKeepsPressesForTheNextUpdate(t *testing.T) {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 30)
+	W.TickRate = 20 // one update every 3 frames
+
+	A := W.Controls.Actions()
+	A.Bind("fire", KeyBinding(KeyCode(KeySpace)))
+	K := W.Controls.Keyboard()
+
+	updates, tapped := 0, false
+	actionPresses, keyPresses := []int{}, []int{}
+
+	W.LoopFixed(func(dt float32) {
+		updates++
+		if A.Pressed("fire") {
+			actionPresses = append(actionPresses, updates)
+		}
+		if K.WasPressed(KeyCode(KeySpace)) {
+			keyPresses = append(keyPresses, updates)
+		}
+	}, func(alpha float32) {
+		if !tapped && updates > 0 && alpha > 0 {
+			// this frame ran no update: a tap that is over before the next one
+			K.Feed(CreateKeyEvent(KeyCode(KeySpace), glfw.Press, 0))
+			K.Feed(CreateKeyEvent(KeyCode(KeySpace), glfw.Release, 0))
+			tapped = true
+		}
+	})
+
+	if !tapped || updates < 3 {
+		t.Fatalf("the loop did not run as expected: %d updates, tapped %v", updates, tapped)
+	}
+	if len(actionPresses) != 1 || len(keyPresses) != 1 || actionPresses[0] != keyPresses[0] {
+		t.Fatalf("expected one press in one update, got action presses in updates %v and key presses in %v", actionPresses, keyPresses)
+	}
+}
+
+func TestActionsPressHoldRelease(t *testing.T) {
+	K := CreateKeyboard(nil)
+	A := CreateActions(K, nil, nil)
+	A.Bind("jump", KeyBinding(KeyCode(KeySpace)))
+
+	type frame struct {
+		feed                    []glfw.Action
+		pressed, down, released bool
+	}
+	frames := []frame{
+		{nil, false, false, false},
+		{[]glfw.Action{glfw.Press}, true, true, false},
+		{nil, false, true, false},
+		{[]glfw.Action{glfw.Release}, false, false, true},
+		{[]glfw.Action{glfw.Press, glfw.Release}, true, false, true},
+	}
+
+	for i, F := range frames {
+		for _, action := range F.feed {
+			K.Feed(CreateKeyEvent(KeyCode(KeySpace), action, 0))
+		}
+		A.Update()
+		K.EndFrame()
+
+		if A.Pressed("jump") != F.pressed || A.Down("jump") != F.down || A.Released("jump") != F.released {
+			t.Fatalf("frame %d: pressed %v down %v released %v, expected %v %v %v", i,
+				A.Pressed("jump"), A.Down("jump"), A.Released("jump"), F.pressed, F.down, F.released)
+		}
+	}
+}
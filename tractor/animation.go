@@ -0,0 +1,324 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+	"path"
+	"sort"
+)
+
+// How an animation goes on after its last frame
+type AnimationMode int
+
+const (
+	AnimLoop     AnimationMode = iota // start over at the first frame
+	AnimPingPong                      // play backwards to the first frame, then forwards again, and so on
+	AnimOnce                          // stop at the last frame
+)
+
+// One frame of an animation: a subtexture of an atlas, shown for Duration seconds
+type AnimationFrame struct {
+	Name      string  // of the subtexture
+	SubTexPos shed.V4 // the subtexture, in texture coordinates. Goes into Sprite.UniSubTexPos
+	Duration  float32 // seconds
+	Event     string  // Optional. Passed to OnEvent when the frame is shown
+}
+
+// =========================================================================
+// ||
+// || Animation.
+// ||
+// || Flip through the subtextures of an atlas, like a flip-book. Attach the
+// || animation to a Sprite, and Sprite.Update() advances it by Engine.Delta
+// || and shows the current frame.
+// ||
+// =========================================================================
+type Animation struct {
+	Frames []AnimationFrame
+	Mode   AnimationMode
+	Speed  float32 // 2 plays twice as fast. Negative speeds are treated as zero
+
+	OnFrame    func(A *Animation, frame int)    // a new frame is shown
+	OnEvent    func(A *Animation, event string) // a frame with an Event is shown
+	OnComplete func(A *Animation)               // an AnimOnce animation has finished, or a looping one has completed a cycle
+
+	current  int
+	elapsed  float32 // how long the current frame has been shown
+	backward bool    // ping-pong animations go back and forth
+	started  bool    // the callbacks for the first frame have fired
+	playing  bool
+	finished bool
+}
+
+// An animation of the named subtextures, in the given order. Every frame lasts frameDuration seconds.
+// The atlas must have been loaded by the engine (see Engine.LoadTextureAtlas), so it has a texture.
+func CreateAnimation(atlas *shed.AtlasDescriptor, names []string, frameDuration float32) *Animation {
+	if len(names) == 0 {
+		shed.GlPanic(fmt.Errorf("an animation needs at least one frame"))
+	}
+
+	w, h := atlas.Texture.GetSize()
+
+	A := Animation{
+		Frames:  make([]AnimationFrame, len(names)),
+		Speed:   1,
+		playing: true,
+	}
+
+	for i, name := range names {
+		sub := atlas.GetSubTexture(name)
+		if sub == nil {
+			shed.GlPanic(fmt.Errorf("could not find subtexture '%s' for animation", name))
+		}
+		A.Frames[i] = AnimationFrame{
+			Name:      name,
+			SubTexPos: sub.GetDims(float32(w), float32(h)),
+			Duration:  frameDuration,
+		}
+	}
+
+	return &A
+}
+
+// An animation of all the subtextures whose names match pattern (see path.Match), for instance "laserBlue*.png".
+// The frames are sorted by name, with numbers in numeric order, so "fire2.png" comes before "fire10.png".
+func CreateAnimationFromPattern(atlas *shed.AtlasDescriptor, pattern string, frameDuration float32) *Animation {
+	names := []string{}
+
+	for _, sub := range atlas.SubTextures {
+		matched, err := path.Match(pattern, sub.Name)
+		shed.GlPanicIfErrNotNil(err)
+		if matched {
+			names = append(names, sub.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		shed.GlPanic(fmt.Errorf("no subtextures match the animation pattern '%s'", pattern))
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return naturalLess(names[i], names[j])
+	})
+
+	return CreateAnimation(atlas, names, frameDuration)
+}
+
+// Set the duration of every frame, in seconds. There must be one duration per frame
+func (A *Animation) SetDurations(durations ...float32) {
+	if len(durations) != len(A.Frames) {
+		shed.GlPanic(fmt.Errorf("the animation has %d frames, but got %d durations", len(A.Frames), len(durations)))
+	}
+
+	for i := range A.Frames {
+		A.Frames[i].Duration = durations[i]
+	}
+}
+
+// Fire OnEvent with event when the frame is shown
+func (A *Animation) SetEvent(frame int, event string) {
+	A.Frames[frame].Event = event
+}
+
+// Continue from where the animation was paused. Restarts a finished AnimOnce animation
+func (A *Animation) Play() {
+	if A.finished {
+		A.Restart()
+	}
+	A.playing = true
+}
+
+func (A *Animation) Pause() {
+	A.playing = false
+}
+
+// Go back to the first frame, and play
+func (A *Animation) Restart() {
+	A.current = 0
+	A.elapsed = 0
+	A.backward = false
+	A.started = false
+	A.finished = false
+	A.playing = true
+}
+
+// Jump to a frame
+func (A *Animation) SetFrame(frame int) {
+	A.current = max(0, min(frame, len(A.Frames)-1))
+	A.elapsed = 0
+}
+
+// The index of the frame that is shown
+func (A *Animation) Frame() int {
+	return A.current
+}
+
+// The frame that is shown
+func (A *Animation) CurrentFrame() *AnimationFrame {
+	return &A.Frames[A.current]
+}
+
+func (A *Animation) IsPlaying() bool {
+	return A.playing
+}
+
+// True once an AnimOnce animation has shown its last frame for its full duration
+func (A *Animation) IsFinished() bool {
+	return A.finished
+}
+
+// Advance the animation by the time since the last frame
+func (A *Animation) Update() {
+	A.Advance(Engine.Delta)
+}
+
+// Advance the animation by dt seconds. A long dt may skip several frames:
+// the callbacks still fire for every one of them.
+func (A *Animation) Advance(dt float32) {
+	if !A.playing || A.finished || dt <= 0 || A.Speed <= 0 {
+		return
+	}
+
+	if !A.started {
+		A.started = true
+		A.frameShown()
+	}
+
+	A.elapsed += dt * A.Speed
+
+	// Frames with no duration are skipped. Give up after a full cycle of them, or we'd never stop
+	skipped := 0
+	for A.elapsed >= A.Frames[A.current].Duration {
+		duration := A.Frames[A.current].Duration
+		if duration <= 0 {
+			skipped++
+			if skipped > 2*len(A.Frames) {
+				A.elapsed = 0
+				return
+			}
+		} else {
+			skipped = 0
+		}
+
+		A.elapsed -= shed.Max(duration, 0)
+
+		if !A.step() {
+			A.elapsed = 0
+			return
+		}
+	}
+}
+
+// Move on to the next frame. Returns false if there is none
+func (A *Animation) step() bool {
+	last := len(A.Frames) - 1
+	cycled := false
+
+	switch A.Mode {
+	case AnimOnce:
+		if A.current == last {
+			A.finished = true
+			A.playing = false
+			if A.OnComplete != nil {
+				A.OnComplete(A)
+			}
+			return false
+		}
+		A.current++
+
+	case AnimPingPong:
+		if last == 0 {
+			cycled = true
+		} else if A.backward {
+			A.current--
+			if A.current == 0 {
+				A.backward = false
+				cycled = true
+			}
+		} else {
+			A.current++
+			if A.current == last {
+				A.backward = true
+			}
+		}
+
+	default:
+		A.current++
+		if A.current > last {
+			A.current = 0
+			cycled = true
+		}
+	}
+
+	A.frameShown()
+
+	if cycled && A.OnComplete != nil {
+		A.OnComplete(A)
+	}
+
+	return true
+}
+
+func (A *Animation) frameShown() {
+	if A.OnFrame != nil {
+		A.OnFrame(A, A.current)
+	}
+	if event := A.Frames[A.current].Event; event != "" && A.OnEvent != nil {
+		A.OnEvent(A, event)
+	}
+}
+
+// A copy with its own playback state, for instance to animate many sprites out of step.
+// The callbacks are shared.
+func (A *Animation) Clone() *Animation {
+	clone := *A
+	clone.Frames = append([]AnimationFrame{}, A.Frames...)
+
+	return &clone
+}
+
+// Compare strings with the digits in them compared as numbers: "fire2" < "fire10"
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the numbers by length (without leading zeros), then digit by digit
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na, nb := trimZeros(a[si:i]), trimZeros(b[sj:j])
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+
+	return len(a)-i < len(b)-j
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func trimZeros(digits string) string {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+
+	return digits
+}
@@ -0,0 +1,167 @@
+package tractor
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// An animation without an atlas: frames named after their index
+func framesAnimation(mode AnimationMode, durations ...float32) *Animation {
+	A := &Animation{Mode: mode, Speed: 1}
+	for i, d := range durations {
+		A.Frames = append(A.Frames, AnimationFrame{Name: fmt.Sprint(i), Duration: d})
+	}
+	A.Restart()
+
+	return A
+}
+
+func TestAnimationAdvance(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      AnimationMode
+		speed     float32
+		durations []float32
+		dts       []float32
+		frames    []int // the frame after each dt
+		shown     []int // every frame OnFrame was called for
+		completes int
+		finished  bool
+	}{
+		{"loop", AnimLoop, 1, []float32{0.25, 0.25, 0.25}, []float32{0.25, 0.25, 0.25, 0.25}, []int{1, 2, 0, 1}, []int{0, 1, 2, 0, 1}, 1, false},
+		{"loop, skipping frames", AnimLoop, 1, []float32{0.25, 0.25, 0.25}, []float32{0.8125, 0.1875}, []int{0, 1}, []int{0, 1, 2, 0, 1}, 1, false},
+		{"loop, twice as fast", AnimLoop, 2, []float32{0.25, 0.25}, []float32{0.125, 0.125}, []int{1, 0}, []int{0, 1, 0}, 1, false},
+		{"stopped", AnimLoop, 0, []float32{0.25, 0.25}, []float32{1}, []int{0}, nil, 0, false},
+		{"ping-pong", AnimPingPong, 1, []float32{0.25, 0.25, 0.25}, []float32{0.25, 0.25, 0.25, 0.25, 0.25, 0.25}, []int{1, 2, 1, 0, 1, 2}, []int{0, 1, 2, 1, 0, 1, 2}, 1, false},
+		{"ping-pong, skipping frames", AnimPingPong, 1, []float32{0.25, 0.25, 0.25}, []float32{1.125}, []int{0}, []int{0, 1, 2, 1, 0}, 1, false},
+		{"ping-pong, one frame", AnimPingPong, 1, []float32{0.25}, []float32{0.25, 0.25}, []int{0, 0}, []int{0, 0, 0}, 2, false},
+		{"once", AnimOnce, 1, []float32{0.25, 0.25, 0.25}, []float32{0.25, 0.25, 0.25, 0.25}, []int{1, 2, 2, 2}, []int{0, 1, 2}, 1, true},
+		{"once, past the end", AnimOnce, 1, []float32{0.25, 0.25, 0.25}, []float32{5}, []int{2}, []int{0, 1, 2}, 1, true},
+		{"once, not quite at the end", AnimOnce, 1, []float32{0.25, 0.25, 0.25}, []float32{0.5, 0.125}, []int{2, 2}, []int{0, 1, 2}, 0, false},
+		{"zero duration frame", AnimLoop, 1, []float32{0.25, 0, 0.25}, []float32{0.25, 0.25}, []int{2, 0}, []int{0, 1, 2, 0}, 1, false},
+		{"zero duration last frame", AnimOnce, 1, []float32{0.25, 0}, []float32{0.25}, []int{1}, []int{0, 1}, 1, true},
+		// Gives up after going around twice, instead of hanging
+		{"only zero duration frames", AnimLoop, 1, []float32{0, 0}, []float32{0.25}, []int{0}, []int{0, 1, 0, 1, 0}, 2, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			A := framesAnimation(test.mode, test.durations...)
+			A.Speed = test.speed
+			A.SetEvent(len(A.Frames)-1, "last")
+
+			shown, events, completes := []int{}, 0, 0
+			A.OnFrame = func(A *Animation, frame int) { shown = append(shown, frame) }
+			A.OnEvent = func(A *Animation, event string) {
+				if event != "last" {
+					t.Fatalf("got event '%s'", event)
+				}
+				events++
+			}
+			A.OnComplete = func(A *Animation) { completes++ }
+
+			for i, dt := range test.dts {
+				A.Advance(dt)
+				if A.Frame() != test.frames[i] {
+					t.Fatalf("after step %d, the frame is %d, expected %d", i, A.Frame(), test.frames[i])
+				}
+			}
+
+			if fmt.Sprint(shown) != fmt.Sprint(test.shown) {
+				t.Fatalf("OnFrame was called for %v, expected %v", shown, test.shown)
+			}
+
+			lasts := 0
+			for _, frame := range shown {
+				if frame == len(A.Frames)-1 {
+					lasts++
+				}
+			}
+			if events != lasts {
+				t.Fatalf("OnEvent fired %d times, but the last frame was shown %d times", events, lasts)
+			}
+
+			if completes != test.completes {
+				t.Fatalf("OnComplete fired %d times, expected %d", completes, test.completes)
+			}
+			if A.IsFinished() != test.finished || A.IsPlaying() == test.finished {
+				t.Fatalf("finished is %v and playing is %v, expected finished to be %v", A.IsFinished(), A.IsPlaying(), test.finished)
+			}
+		})
+	}
+}
+
+func TestAnimationPlayback(t *testing.T) {
+	A := framesAnimation(AnimOnce, 0.25, 0.25)
+
+	A.Pause()
+	A.Advance(1)
+	if A.Frame() != 0 || A.IsPlaying() {
+		t.Fatalf("a paused animation moved on to frame %d", A.Frame())
+	}
+
+	A.Play()
+	A.Advance(1)
+	if !A.IsFinished() || A.Frame() != 1 {
+		t.Fatalf("the animation did not finish on its last frame")
+	}
+
+	// Playing a finished animation starts it over
+	A.Play()
+	if A.IsFinished() || !A.IsPlaying() || A.Frame() != 0 {
+		t.Fatalf("playing the finished animation did not restart it")
+	}
+
+	A.SetFrame(5)
+	if A.Frame() != 1 {
+		t.Fatalf("SetFrame past the last frame went to %d", A.Frame())
+	}
+
+	// Clones play on their own
+	B := A.Clone()
+	B.Restart()
+	B.Advance(0.25)
+	if A.Frame() != 1 || B.Frame() != 1 {
+		t.Fatalf("the frames are %d and %d", A.Frame(), B.Frame())
+	}
+	B.Frames[0].Duration = 1
+	if A.Frames[0].Duration != 0.25 {
+		t.Fatalf("the clone shares its frames")
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"fire2.png", "fire10.png", true},
+		{"fire10.png", "fire2.png", false},
+		{"fire9", "fire10", true},
+		{"img100", "img99", false},
+		{"fire02", "fire2", false}, // the same number
+		{"fire2", "fire02", false},
+		{"fire", "fire1", true},
+		{"fire1", "fire", false},
+		{"a1b2", "a1b10", true},
+		{"a2b1", "a10b0", true},
+		{"abc", "abd", true},
+		{"fire2", "fire2", false},
+		{"", "a", true},
+		{"9", "10", true},
+		{"x00000000000000000000001", "x2", true}, // too long for an int
+	}
+
+	for _, test := range tests {
+		if less := naturalLess(test.a, test.b); less != test.less {
+			t.Fatalf("naturalLess(%q, %q) is %v, expected %v", test.a, test.b, less, test.less)
+		}
+	}
+
+	names := []string{"laser10.png", "laser1.png", "laser2.png", "laser.png", "laser20.png", "laser3.png"}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	if got := fmt.Sprint(names); got != "[laser.png laser1.png laser2.png laser3.png laser10.png laser20.png]" {
+		t.Fatalf("sorted to %s", got)
+	}
+}
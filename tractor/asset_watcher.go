@@ -0,0 +1,153 @@
+package tractor
+
+import (
+	"goat/shed"
+	"image"
+	"os"
+	"sort"
+	"time"
+)
+
+// What kind of asset was reloaded
+const (
+	AssetShader  = "shader"
+	AssetTexture = "texture"
+	AssetAtlas   = "atlas"
+)
+
+// =========================================================================
+// ||
+// || Asset Watcher.
+// ||
+// || Hot-reloading of shaders, textures and texture atlases.
+// ||
+// || Every asset the engine loads is registered here. When enabled,
+// || Engine.Loop() checks the files for changes between frames, on the
+// || main thread, and reloads the ones that changed. Assets are reloaded
+// || in place, so everything that holds a *ShaderProgram, *TextureWrapper or
+// || *AtlasDescriptor sees the new version.
+// ||
+// || Subtexture coordinates that were copied out of an atlas (for instance
+// || into Sprite.UniSubTexPos) are not updated. Use OnReload for that.
+// ||
+// =========================================================================
+type AssetWatcher struct {
+	Enabled  bool          // Disabled by default. Checking files costs a system call per file.
+	Interval time.Duration // How often the files are checked (wall clock time)
+
+	files     map[string]*watchedFile // file path => file
+	listeners []func(kind, name string)
+	lastCheck time.Time
+}
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	assets  []*watchedAsset // the assets loaded from the file
+}
+
+type watchedAsset struct {
+	kind   string
+	name   string // the name the asset was loaded by
+	reload func() error
+}
+
+func createAssetWatcher() *AssetWatcher {
+	return &AssetWatcher{
+		Interval: 250 * time.Millisecond,
+		files:    make(map[string]*watchedFile),
+	}
+}
+
+// Call fn every time an asset has been reloaded successfully.
+// kind is AssetShader, AssetTexture or AssetAtlas, and name is the name the asset was loaded by.
+func (A *AssetWatcher) OnReload(fn func(kind, name string)) {
+	A.listeners = append(A.listeners, fn)
+}
+
+// Register the file(s) an asset was loaded from
+func (A *AssetWatcher) watch(kind, name string, reload func() error, filePaths ...string) {
+	asset := &watchedAsset{kind: kind, name: name, reload: reload}
+
+	for _, filePath := range filePaths {
+		file, found := A.files[filePath]
+		if !found {
+			file = &watchedFile{}
+			if info, err := os.Stat(filePath); err == nil {
+				file.modTime, file.size = info.ModTime(), info.Size()
+			}
+			A.files[filePath] = file
+		}
+
+		file.assets = append(file.assets, asset)
+	}
+}
+
+// Check the files for changes (if enabled and Interval has passed), and reload the assets that changed.
+// Engine.Loop() calls this between frames.
+func (A *AssetWatcher) Poll() {
+	if !A.Enabled || time.Since(A.lastCheck) < A.Interval {
+		return
+	}
+	A.lastCheck = time.Now()
+
+	A.ReloadChanged()
+}
+
+// Check all files for changes right now, and reload the assets that changed.
+// Works even when the watcher is disabled.
+func (A *AssetWatcher) ReloadChanged() {
+	changed := []*watchedAsset{}
+	seen := map[*watchedAsset]bool{}
+
+	for filePath, file := range A.files {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			continue // probably in the middle of being saved. Try again next time
+		}
+
+		if info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+			continue
+		}
+		file.modTime, file.size = info.ModTime(), info.Size()
+
+		for _, asset := range file.assets {
+			if !seen[asset] {
+				seen[asset] = true
+				changed = append(changed, asset)
+			}
+		}
+	}
+
+	// Atlasses last, so they see their reloaded textures
+	order := map[string]int{AssetShader: 0, AssetTexture: 1, AssetAtlas: 2}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return order[changed[i].kind] < order[changed[j].kind]
+	})
+
+	for _, asset := range changed {
+		if err := asset.reload(); err != nil {
+			shed.GlLog("Could not reload %s '%s'. Keeping the old version. %v", asset.kind, asset.name, err)
+			continue
+		}
+
+		shed.GlLog("Reloaded %s '%s'", asset.kind, asset.name)
+		for _, fn := range A.listeners {
+			fn(asset.kind, asset.name)
+		}
+	}
+}
+
+// Decode an image file without panicking (unlike shed.LoadImage),
+// because a half-written file is an expected part of hot-reloading.
+func decodeImageFile(filePath string) (image.Image, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+
+	return img, err
+}
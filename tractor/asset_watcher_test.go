@@ -0,0 +1,136 @@
+package tractor
+
+import (
+	"errors"
+	"fmt"
+	"goat/shed"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssetWatcherReloadChanged(t *testing.T) {
+	out := shed.Logger.Writer() // reloads are logged
+	shed.Logger.SetOutput(io.Discard)
+	defer shed.Logger.SetOutput(out)
+
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	saves := 0
+
+	// Save a file with a newer modification time than the last save, however coarse the file system's clock is
+	save := func(name, content string) string {
+		filePath := filepath.Join(dir, name)
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		saves++
+		modTime := start.Add(time.Duration(saves) * time.Second)
+		if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return filePath
+	}
+
+	A := createAssetWatcher()
+	loaded := map[string]string{} // asset name => the contents of its files, as last loaded
+	reloads := []string{}
+
+	// An asset is the contents of its files. A file that starts with "broken" does not load
+	register := func(kind, name string, files ...string) {
+		load := func() error {
+			contents := []string{}
+			for _, filePath := range files {
+				data, err := os.ReadFile(filePath)
+				if err != nil {
+					return err
+				}
+				if strings.HasPrefix(string(data), "broken") {
+					return errors.New("syntax error")
+				}
+				contents = append(contents, string(data))
+			}
+			loaded[name] = strings.Join(contents, "+")
+			reloads = append(reloads, kind+" "+name)
+			return nil
+		}
+		if err := load(); err != nil {
+			t.Fatal(err)
+		}
+		reloads = reloads[:0]
+
+		A.watch(kind, name, load, files...)
+	}
+
+	vert, frag := save("basic.vert", "v1"), save("basic.frag", "f1")
+	sheet, xml := save("sheet.png", "png1"), save("sheet.xml", "xml1")
+
+	// Registered in the wrong order, to see that the reload order does not depend on it
+	register(AssetAtlas, "sheet.xml", xml, sheet) // the atlas uses the texture's image too
+	register(AssetTexture, "sheet.png", sheet)
+	register(AssetShader, "basic", vert, frag)
+
+	notified := []string{}
+	A.OnReload(func(kind, name string) { notified = append(notified, kind+" "+name) })
+
+	check := func(step string, expected []string, versions map[string]string) {
+		t.Helper()
+		if fmt.Sprint(reloads) != fmt.Sprint(expected) {
+			t.Fatalf("%s: reloaded %v, expected %v", step, reloads, expected)
+		}
+		if fmt.Sprint(notified) != fmt.Sprint(expected) {
+			t.Fatalf("%s: the listener heard of %v, expected %v", step, notified, expected)
+		}
+		for name, version := range versions {
+			if loaded[name] != version {
+				t.Fatalf("%s: %s is at '%s', expected '%s'", step, name, loaded[name], version)
+			}
+		}
+		reloads, notified = reloads[:0], notified[:0]
+	}
+
+	A.ReloadChanged()
+	check("nothing changed", nil, nil)
+
+	// Everything changes. Both files of the shader change, and the image is used by two assets, but all reload once
+	save("sheet.xml", "xml2")
+	save("sheet.png", "png2")
+	save("basic.frag", "f2")
+	save("basic.vert", "v2")
+	A.ReloadChanged()
+	check("everything changed", []string{"shader basic", "texture sheet.png", "atlas sheet.xml"},
+		map[string]string{"basic": "v2+f2", "sheet.png": "png2", "sheet.xml": "xml2+png2"})
+
+	// The image alone. The atlas reloads after the texture, so it sees the new image
+	save("sheet.png", "png3")
+	A.ReloadChanged()
+	check("the image changed", []string{"texture sheet.png", "atlas sheet.xml"},
+		map[string]string{"sheet.png": "png3", "sheet.xml": "xml2+png3"})
+
+	// A broken file keeps the old version, and nobody hears of it
+	save("basic.frag", "broken")
+	A.ReloadChanged()
+	check("a broken shader", nil, map[string]string{"basic": "v2+f2"})
+
+	// Nothing changed since, so it is not tried again
+	A.ReloadChanged()
+	check("still broken", nil, nil)
+
+	save("basic.frag", "f3")
+	A.ReloadChanged()
+	check("the shader is fixed", []string{"shader basic"}, map[string]string{"basic": "v2+f3"})
+
+	// A missing file, for instance in the middle of being saved, is tried again later
+	if err := os.Remove(xml); err != nil {
+		t.Fatal(err)
+	}
+	A.ReloadChanged()
+	check("a missing file", nil, map[string]string{"sheet.xml": "xml2+png3"})
+
+	save("sheet.xml", "xml3")
+	A.ReloadChanged()
+	check("the file is back", []string{"atlas sheet.xml"}, map[string]string{"sheet.xml": "xml3+png3"})
+}
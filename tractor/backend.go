@@ -0,0 +1,46 @@
+package tractor
+
+import (
+	"goat/shed"
+	"image"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// =============================================================================================
+// ||
+// || Backend.
+// ||
+// || The thing that actually puts pixels somewhere.
+// || The renderers only know *what* to draw, the backend knows *how*.
+// ||
+// || glBackend:   draws with opengl into a glfw window.
+// || SoftBackend: draws with a pure-go rasterizer into an image.RGBA. No window, no GPU.
+// ||
+// =============================================================================================
+type Backend interface {
+	Time() float64                    // Number of seconds since the backend was started
+	ShouldClose() bool                // Should the main loop stop?
+	SetShouldClose(bool)              // Ask the main loop to stop (or not)
+	BeginFrame()                      // Clear the screen
+	EndFrame()                        // Present the frame
+	PollEvents()                      // Process pending (input) events
+	Dispose()                         // Free all resources held by the backend
+	ReadPixels() (*image.RGBA, error) // Read back the frame currently being drawn
+	FramebufferSize() (int, int)      // Size (in pixels) of the thing we're drawing on
+	WaitUntil(t float64)              // Return when Time() reaches t. Used to limit the frame rate
+	SetVSync(on bool)                 // Wait for the screen to refresh before presenting a frame
+	FinalizeTexture(tex *shed.TextureWrapper)
+	FinalizeRect(R *BasicRectRenderer)
+	DrawRect(R *BasicRectRenderer, trMatrix mgl32.Mat3, color shed.V4)
+	FinalizeFancyRect(R *FancyRectRenderer)
+	DrawFancyRect(R *FancyRectRenderer, trMatrix mgl32.Mat3)
+	FinalizeLineStrip(R *LineStripRenderer)
+	DrawLineStrip(R *LineStripRenderer, trMatrix mgl32.Mat3, vertices []LineVertex)
+	FinalizePrimitives(R *PrimitiveRenderer)
+	DrawPrimitives(R *PrimitiveRenderer, trMatrix mgl32.Mat3, vertices []shed.V2, color shed.V4)
+	FinalizeTexQuad(R *TexQuadRenderer)
+	DrawTexQuad(R *TexQuadRenderer, trMatrix mgl32.Mat3)
+	FinalizeSpriteBatch(B *SpriteBatch)
+	DrawSpriteBatch(B *SpriteBatch, camMatrix mgl32.Mat3)
+}
@@ -0,0 +1,548 @@
+package tractor
+
+import (
+	u "goat/shed"
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || OpenGL backend.
+// ||
+// || Draws into a glfw window.
+// ||=============================
+type glBackend struct {
+	window *glfw.Window
+	free   func()
+}
+
+func createGlBackend(o *WindowOptions) (*glBackend, error) {
+	free, window, err := glfwCreateWin(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &glBackend{window: window, free: free}, nil
+}
+
+func (B *glBackend) Time() float64 {
+	return glfw.GetTime()
+}
+
+func (B *glBackend) ShouldClose() bool {
+	return B.window.ShouldClose()
+}
+
+func (B *glBackend) SetShouldClose(value bool) {
+	B.window.SetShouldClose(value)
+}
+
+func (B *glBackend) BeginFrame() {
+	u.Clear()
+}
+
+func (B *glBackend) EndFrame() {
+	B.window.SwapBuffers()
+
+	u.AssertGLOK("End Of Loop")
+}
+
+func (B *glBackend) PollEvents() {
+	glfw.PollEvents()
+}
+
+func (B *glBackend) WaitUntil(t float64) {
+	SleepUntil(glfw.GetTime, t)
+}
+
+func (B *glBackend) SetVSync(on bool) {
+	if on {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+}
+
+func (B *glBackend) Dispose() {
+	B.free()
+}
+
+func (B *glBackend) FramebufferSize() (int, int) {
+	return B.window.GetFramebufferSize()
+}
+
+// Read the back buffer, i.e. the frame that is currently being drawn.
+// Must be called before EndFrame() swaps the buffers.
+func (B *glBackend) ReadPixels() (*image.RGBA, error) {
+	w, h := B.window.GetFramebufferSize()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadBuffer(gl.BACK)
+	gl.ReadPixels(0, 0, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	if err := u.AssertGLOK("ReadPixels"); err != nil {
+		return nil, err
+	}
+
+	// opengl has (0, 0) in the lower left corner, images have it in the upper left.
+	u.FlipImageVertically(img)
+
+	return img, nil
+}
+
+// Upload a texture, unless it has been uploaded already
+func (B *glBackend) FinalizeTexture(tex *u.TextureWrapper) {
+	if !tex.IsFinalized() {
+		tex.Finalize()
+	}
+}
+
+// ||=============================
+// || Basic (Filled) Rects
+// ||=============================
+func (B *glBackend) FinalizeRect(R *BasicRectRenderer) {
+	if R.Shader == nil {
+		shader, err := Engine.GetShader(R.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		R.Shader = shader
+	}
+
+	R.Shader.Use()
+
+	R.Shader.SetUniformAttr("uniColor", R.UniColor)
+
+	if R.buffersReady {
+		return
+	}
+
+	const vt_floats_total = 4 * 5               // 4 strides and 5 floats per stride
+	const vt_len = u.F32_SIZE * vt_floats_total // total length (in bytes) of the VT buffer
+	const Z, HI, LO = 1.0, 0.5, -0.5            // convenience
+	vt_buffer := [vt_floats_total]float32{
+		HI, HI, Z,
+		LO, HI, Z,
+		LO, LO, Z,
+		HI, LO, Z,
+	}
+	vt_ptr := u.GlPtr32f(&vt_buffer[0])
+
+	// Create buffers
+	gl.GenBuffers(1, &R.bufferHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &R.vaoHandle)
+	gl.BindVertexArray(R.vaoHandle)
+
+	//
+	// Vertex Buffer Object
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	gl.BufferData(gl.ARRAY_BUFFER, vt_len, vt_ptr, gl.STATIC_DRAW)
+
+	R.Shader.EnableVertexAttribArray("iVert")
+	R.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, 0, 0)
+	defer R.Shader.DisableVertexAttribArray("iVert")
+
+	// Cleanup
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	R.Shader.DisableVertexAttribArray("iVert")
+
+	R.buffersReady = true
+}
+
+func (B *glBackend) DrawRect(R *BasicRectRenderer, trMatrix mgl32.Mat3, color u.V4) {
+
+	R.Shader.Use()
+	gl.BindVertexArray(R.vaoHandle)
+
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColor", color))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
+
+	gl.DrawArrays(gl.TRIANGLE_FAN, 0, 4)
+
+	u.AssertGLOK("BasicRectRenderer.Draw", R.Shader, 22)
+}
+
+// ||=============================
+// || Fancy (rounded, stroked) Rects
+// ||=============================
+func (B *glBackend) FinalizeFancyRect(R *FancyRectRenderer) {
+	if R.Shader == nil {
+		shader, err := Engine.GetShader(R.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		R.Shader = shader
+	}
+
+	if R.buffersReady {
+		return
+	}
+
+	R.Shader.Use()
+
+	// Interleaved array of verts and the directions they are pushed
+	// out in, to make room for the anti-aliased fringe.
+	const vt_bytes_pr_stride = u.F32_SIZE * 5   // 4 bytes per float and 5 floats per stride/vert
+	const vt_floats_total = 4 * 5               // 4 strides and 5 floats per stride
+	const vt_len = u.F32_SIZE * vt_floats_total // total length (in bytes) of the VT buffer
+	const Z, HI, LO = 1.0, 0.5, -0.5            // convenience
+	vt_buffer := [vt_floats_total]float32{
+		HI, HI, Z /* <== Vert | Outset ==> */, 1, 1,
+		LO, HI, Z /* <== Vert | Outset ==> */, -1, 1,
+		LO, LO, Z /* <== Vert | Outset ==> */, -1, -1,
+		HI, LO, Z /* <== Vert | Outset ==> */, 1, -1,
+	}
+	vt_ptr := u.GlPtr32f(&vt_buffer[0])
+
+	gl.GenBuffers(1, &R.bufferHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &R.vaoHandle)
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Vertex Buffer Object
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BufferData(gl.ARRAY_BUFFER, vt_len, vt_ptr, gl.STATIC_DRAW)
+
+	R.Shader.EnableVertexAttribArray("iVert")
+	R.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, vt_bytes_pr_stride, 0)
+	R.Shader.EnableVertexAttribArray("iOutset")
+	R.Shader.VertexAttribPointer("iOutset", 2, gl.FLOAT, false, vt_bytes_pr_stride, 3*u.F32_SIZE)
+
+	R.buffersReady = true
+}
+
+func (B *glBackend) DrawFancyRect(R *FancyRectRenderer, trMatrix mgl32.Mat3) {
+
+	R.Shader.Use()
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniSize", R.UniSize))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniFeather", R.UniFeather))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniRadius", R.UniRadius))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniStrokeWidth", R.UniStrokeWidth))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniFillColor", R.UniFillColor))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniStrokeColor", R.UniStrokeColor))
+
+	gl.DrawArrays(gl.TRIANGLE_FAN, 0, 4)
+
+	u.AssertGLOK("FancyRectRenderer.Draw", R.Shader, 22)
+}
+
+// ||=============================
+// || Primitives (triangle lists)
+// ||=============================
+func (B *glBackend) FinalizePrimitives(R *PrimitiveRenderer) {
+
+	if R.buffersReady {
+		return
+	}
+
+	if R.Shader == nil {
+		shader, err := Engine.GetShader(R.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		R.Shader = shader
+	}
+
+	R.Shader.Use()
+
+	gl.GenBuffers(1, &R.bufferHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &R.vaoHandle)
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Vertex Buffer Object. Filled on every draw.
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	R.Shader.EnableVertexAttribArray("iVert")
+	R.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, 0, 0)
+
+	R.buffersReady = true
+}
+
+func (B *glBackend) DrawPrimitives(R *PrimitiveRenderer, trMatrix mgl32.Mat3, vertices []u.V2, color u.V4) {
+
+	R.vertices = R.vertices[:0]
+	for _, v := range vertices {
+		R.vertices = append(R.vertices, v.X, v.Y, 1)
+	}
+
+	R.Shader.Use()
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Stream the vertices. Orphan the old buffer so we don't have to wait for the GPU to finish with it.
+	size := len(R.vertices) * u.F32_SIZE
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	gl.BufferData(gl.ARRAY_BUFFER, size, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, u.GlPtr32f(&R.vertices[0]))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColor", color))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
+
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(vertices)))
+
+	u.AssertGLOK("PrimitiveRenderer.Draw", R.Shader, 22)
+}
+
+// ||=============================
+// || Line Strips (colored triangle lists)
+// ||=============================
+func (B *glBackend) FinalizeLineStrip(R *LineStripRenderer) {
+
+	if R.buffersReady {
+		return
+	}
+
+	if R.Shader == nil {
+		shader, err := Engine.GetShader(R.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		R.Shader = shader
+	}
+
+	R.Shader.Use()
+
+	gl.GenBuffers(1, &R.bufferHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &R.vaoHandle)
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Vertex Buffer Object. Filled on every draw.
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	var vert LineVertex
+	stride := int32(unsafe.Sizeof(vert))
+	R.Shader.EnableVertexAttribArray("iVert")
+	R.Shader.VertexAttribPointer("iVert", 2, gl.FLOAT, false, stride, unsafe.Offsetof(vert.Pos))
+	R.Shader.EnableVertexAttribArray("iColor")
+	R.Shader.VertexAttribPointer("iColor", 4, gl.FLOAT, false, stride, unsafe.Offsetof(vert.Color))
+
+	R.buffersReady = true
+}
+
+func (B *glBackend) DrawLineStrip(R *LineStripRenderer, trMatrix mgl32.Mat3, vertices []LineVertex) {
+
+	R.Shader.Use()
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Stream the vertices. Orphan the old buffer so we don't have to wait for the GPU to finish with it.
+	size := len(vertices) * int(unsafe.Sizeof(vertices[0]))
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	gl.BufferData(gl.ARRAY_BUFFER, size, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, unsafe.Pointer(&vertices[0]))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
+
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(vertices)))
+
+	u.AssertGLOK("LineStripRenderer.Draw", R.Shader, 22)
+}
+
+// ||=============================
+// || Textured Quads
+// ||=============================
+func (B *glBackend) FinalizeTexQuad(R *TexQuadRenderer) {
+
+	if R.finalized {
+		return
+	}
+
+	if R.Shader == nil {
+		shader, err := Engine.GetShader(R.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		R.Shader = shader
+	}
+
+	R.Shader.Use()
+
+	R.Texture.Finalize()
+
+	if R.buffersReady {
+		return
+	}
+
+	// Create interleaved array of verts and
+	// texture coordinates.
+	const vt_bytes_pr_stride = u.F32_SIZE * 5   // 4 bytes per float and 5 floats per stride/vert
+	const vt_floats_total = 4 * 5               // 4 strides and 5 floats per stride
+	const vt_len = u.F32_SIZE * vt_floats_total // total length (in bytes) of the VT buffer
+	const Z, HI, LO = 1.0, 0.5, -0.5            // convenience
+	vt_buffer := [vt_floats_total]float32{
+		HI, HI, Z /* <== Vert | Tex ==> */, 1, 1,
+		LO, HI, Z /* <== Vert | Tex ==> */, 0, 1,
+		LO, LO, Z /* <== Vert | Tex ==> */, 0, 0,
+		HI, LO, Z /* <== Vert | Tex ==> */, 1, 0,
+	}
+	vt_ptr := u.GlPtr32f(&vt_buffer[0])
+
+	// Create buffer
+	gl.GenBuffers(1, &R.bufferHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &R.vaoHandle)
+	gl.BindVertexArray(R.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Vertex Buffer Object
+	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BufferData(gl.ARRAY_BUFFER, vt_len, vt_ptr, gl.STATIC_DRAW)
+
+	R.Shader.EnableVertexAttribArray("iVert")
+	R.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, vt_bytes_pr_stride, 0)
+	defer R.Shader.DisableVertexAttribArray("iVert")
+
+	//
+	// Texture Buffers
+	R.Texture.Bind()
+	defer R.Texture.Unbind()
+	R.Shader.EnableVertexAttribArray("iTexCoord")
+	R.Shader.VertexAttribPointer("iTexCoord", 2, gl.FLOAT, false, vt_bytes_pr_stride, 3*u.F32_SIZE)
+	defer R.Shader.DisableVertexAttribArray("iTexCoord")
+
+	R.buffersReady = true
+	R.finalized = true
+}
+
+func (B *glBackend) DrawTexQuad(R *TexQuadRenderer, trMatrix mgl32.Mat3) {
+
+	R.Shader.Use()
+	gl.BindVertexArray(R.vaoHandle)
+	R.Texture.Bind()
+
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColor", R.UniColor))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColorMix", R.UniColorMix))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniSubTexPos", R.UniSubTexPos))
+	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
+
+	gl.DrawArrays(gl.TRIANGLE_FAN, 0, 4)
+
+	u.AssertGLOK("SpriteRenderable.Draw", R.Shader, 22)
+}
+
+// ||=============================
+// || Sprite Batches
+// ||=============================
+func (B *glBackend) FinalizeSpriteBatch(SB *SpriteBatch) {
+
+	if SB.buffersReady {
+		return
+	}
+
+	if SB.Shader == nil {
+		shader, err := Engine.GetShader(SB.shaderName)
+		u.GlPanicIfErrNotNil(err)
+		SB.Shader = shader
+	}
+
+	SB.Shader.Use()
+
+	// Same quad as the TexQuadRenderer
+	const vt_bytes_pr_stride = u.F32_SIZE * 5   // 4 bytes per float and 5 floats per stride/vert
+	const vt_floats_total = 4 * 5               // 4 strides and 5 floats per stride
+	const vt_len = u.F32_SIZE * vt_floats_total // total length (in bytes) of the VT buffer
+	const Z, HI, LO = 1.0, 0.5, -0.5            // convenience
+	vt_buffer := [vt_floats_total]float32{
+		HI, HI, Z /* <== Vert | Tex ==> */, 1, 1,
+		LO, HI, Z /* <== Vert | Tex ==> */, 0, 1,
+		LO, LO, Z /* <== Vert | Tex ==> */, 0, 0,
+		HI, LO, Z /* <== Vert | Tex ==> */, 1, 0,
+	}
+	vt_ptr := u.GlPtr32f(&vt_buffer[0])
+
+	gl.GenBuffers(1, &SB.quadHandle)
+	gl.GenBuffers(1, &SB.instanceHandle)
+
+	//
+	// Vertex Array Object
+	gl.GenVertexArrays(1, &SB.vaoHandle)
+	gl.BindVertexArray(SB.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	//
+	// Per-vertex data
+	gl.BindBuffer(gl.ARRAY_BUFFER, SB.quadHandle)
+	gl.BufferData(gl.ARRAY_BUFFER, vt_len, vt_ptr, gl.STATIC_DRAW)
+
+	SB.Shader.EnableVertexAttribArray("iVert")
+	SB.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, vt_bytes_pr_stride, 0)
+	SB.Shader.EnableVertexAttribArray("iTexCoord")
+	SB.Shader.VertexAttribPointer("iTexCoord", 2, gl.FLOAT, false, vt_bytes_pr_stride, 3*u.F32_SIZE)
+
+	//
+	// Per-instance data. The buffer is filled on every flush.
+	gl.BindBuffer(gl.ARRAY_BUFFER, SB.instanceHandle)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	var inst batchInstance
+	stride := int32(unsafe.Sizeof(inst))
+	perInstance := func(name string, size int32, offset uintptr) {
+		SB.Shader.EnableVertexAttribArray(name)
+		SB.Shader.VertexAttribPointer(name, size, gl.FLOAT, false, stride, offset)
+		SB.Shader.VertexAttribDivisor(name, 1)
+	}
+
+	trOffset := unsafe.Offsetof(inst.transform)
+	perInstance("iTrCol0", 3, trOffset)
+	perInstance("iTrCol1", 3, trOffset+3*u.F32_SIZE)
+	perInstance("iTrCol2", 3, trOffset+6*u.F32_SIZE)
+	perInstance("iSubTexPos", 4, unsafe.Offsetof(inst.subTexPos))
+	perInstance("iColor", 4, unsafe.Offsetof(inst.color))
+	perInstance("iColorMix", 1, unsafe.Offsetof(inst.colorMix))
+
+	SB.buffersReady = true
+}
+
+func (B *glBackend) DrawSpriteBatch(SB *SpriteBatch, camMatrix mgl32.Mat3) {
+
+	SB.Shader.Use()
+	gl.BindVertexArray(SB.vaoHandle)
+	defer gl.BindVertexArray(0)
+
+	// Any texture can be added to the batch, so it is uploaded the first time it is drawn
+	B.FinalizeTexture(SB.texture)
+	SB.texture.Bind()
+
+	//
+	// Stream the instance data. Orphan the old buffer so we don't have to wait for the GPU to finish with it.
+	size := len(SB.instances) * int(unsafe.Sizeof(SB.instances[0]))
+	gl.BindBuffer(gl.ARRAY_BUFFER, SB.instanceHandle)
+	gl.BufferData(gl.ARRAY_BUFFER, size, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, unsafe.Pointer(&SB.instances[0]))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	u.GlPanicIfErrNotNil(SB.Shader.SetUniformAttr("uniCamera", camMatrix))
+
+	gl.DrawArraysInstanced(gl.TRIANGLE_FAN, 0, 4, int32(len(SB.instances)))
+
+	u.AssertGLOK("SpriteBatch.Draw", SB.Shader, 22)
+}
@@ -0,0 +1,208 @@
+package tractor
+
+import (
+	u "goat/shed"
+	"image"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || Software backend.
+// ||
+// || Draws into an image.RGBA.
+// || No window, no GPU, no cgo calls.
+// || Time is simulated: every frame
+// || takes exactly FrameTime seconds.
+// ||=============================
+type SoftBackend struct {
+	Canvas     *image.RGBA // The "framebuffer"
+	ClearColor u.V4        // Color used when clearing the canvas at the beginning of each frame
+	FrameTime  float64     // Simulated number of seconds per frame
+	MaxFrames  uint64      // Stop the main loop after this many frames. Zero means run until shut down.
+
+	frameCount  uint64
+	shouldClose bool
+}
+
+func CreateSoftBackend(w, h int) *SoftBackend {
+	return &SoftBackend{
+		Canvas:    u.CreateCanvas(w, h, u.V4{}),
+		FrameTime: 1.0 / 60.0,
+	}
+}
+
+// Number of frames rendered so far
+func (B *SoftBackend) FrameCount() uint64 {
+	return B.frameCount
+}
+
+func (B *SoftBackend) Time() float64 {
+	return float64(B.frameCount) * B.FrameTime
+}
+
+func (B *SoftBackend) ShouldClose() bool {
+	return B.shouldClose || (B.MaxFrames > 0 && B.frameCount >= B.MaxFrames)
+}
+
+func (B *SoftBackend) SetShouldClose(value bool) {
+	B.shouldClose = value
+}
+
+func (B *SoftBackend) BeginFrame() {
+	u.FillCanvas(B.Canvas, B.ClearColor)
+}
+
+func (B *SoftBackend) EndFrame() {
+	B.frameCount++
+}
+
+func (B *SoftBackend) PollEvents() {
+}
+
+// Time is simulated, so there is nothing to wait for
+func (B *SoftBackend) WaitUntil(t float64) {
+}
+
+func (B *SoftBackend) SetVSync(on bool) {
+}
+
+func (B *SoftBackend) Dispose() {
+}
+
+func (B *SoftBackend) FramebufferSize() (int, int) {
+	return B.Canvas.Rect.Dx(), B.Canvas.Rect.Dy()
+}
+
+// Return a copy of the canvas
+func (B *SoftBackend) ReadPixels() (*image.RGBA, error) {
+	img := image.NewRGBA(B.Canvas.Bounds())
+	copy(img.Pix, B.Canvas.Pix)
+
+	return img, nil
+}
+
+// Nothing to upload, the rasterizer reads the renderer's fields directly
+func (B *SoftBackend) FinalizeRect(R *BasicRectRenderer) {
+}
+
+func (B *SoftBackend) DrawRect(R *BasicRectRenderer, trMatrix mgl32.Mat3, color u.V4) {
+	u.RasterQuad(B.Canvas, trMatrix, func(s, t float32) u.V4 {
+		return color
+	})
+}
+
+// Nothing to upload, the rasterizer reads the renderer's fields directly
+func (B *SoftBackend) FinalizeFancyRect(R *FancyRectRenderer) {
+}
+
+// Mimics shaders/fancy_rect.vert and shaders/fancy_rect.frag
+func (B *SoftBackend) DrawFancyRect(R *FancyRectRenderer, trMatrix mgl32.Mat3) {
+	if R.UniSize.X <= 0 || R.UniSize.Y <= 0 {
+		return
+	}
+
+	// The quad is grown by the fringe on every side. (s, t) covers the grown quad
+	growX := 1 + 2*R.UniFeather.X/R.UniSize.X
+	growY := 1 + 2*R.UniFeather.Y/R.UniSize.Y
+
+	// The size of a pixel in world units. Stands in for fwidth()
+	aa := (R.UniFeather.X + R.UniFeather.Y) / 2
+
+	u.RasterQuad(B.Canvas, trMatrix.Mul3(mgl32.Scale2D(growX, growY)), func(s, t float32) u.V4 {
+		dist := R.distance(u.V2{X: (s - 0.5) * growX * R.UniSize.X, Y: (t - 0.5) * growY * R.UniSize.Y})
+
+		coverage := mgl32.Clamp(0.5-dist/aa, 0, 1)
+		fill := float32(1)
+		if R.UniStrokeWidth > 0 {
+			fill = mgl32.Clamp(0.5-(dist+R.UniStrokeWidth)/aa, 0, 1)
+		}
+
+		color := R.UniStrokeColor.Mix(R.UniFillColor, fill)
+		color.C4 *= coverage
+
+		return color
+	})
+}
+
+// Nothing to upload, the rasterizer reads the vertices directly
+func (B *SoftBackend) FinalizeLineStrip(R *LineStripRenderer) {
+}
+
+// Mimics shaders/line_strip.frag: the vertex colors are interpolated across each triangle
+func (B *SoftBackend) DrawLineStrip(R *LineStripRenderer, trMatrix mgl32.Mat3, vertices []LineVertex) {
+	for i := 0; i+2 < len(vertices); i += 3 {
+		a, b, c := vertices[i], vertices[i+1], vertices[i+2]
+
+		u.RasterTriangle(B.Canvas, trMatrix, a.Pos, b.Pos, c.Pos, func(wb, wc float32) u.V4 {
+			wa := 1 - wb - wc
+			return u.V4{
+				C1: a.Color.C1*wa + b.Color.C1*wb + c.Color.C1*wc,
+				C2: a.Color.C2*wa + b.Color.C2*wb + c.Color.C2*wc,
+				C3: a.Color.C3*wa + b.Color.C3*wb + c.Color.C3*wc,
+				C4: a.Color.C4*wa + b.Color.C4*wb + c.Color.C4*wc,
+			}
+		})
+	}
+}
+
+// Nothing to upload, the rasterizer reads the vertices directly
+func (B *SoftBackend) FinalizePrimitives(R *PrimitiveRenderer) {
+}
+
+func (B *SoftBackend) DrawPrimitives(R *PrimitiveRenderer, trMatrix mgl32.Mat3, vertices []u.V2, color u.V4) {
+	for i := 0; i+2 < len(vertices); i += 3 {
+		u.RasterTriangle(B.Canvas, trMatrix, vertices[i], vertices[i+1], vertices[i+2], func(wb, wc float32) u.V4 {
+			return color
+		})
+	}
+}
+
+// The texture is NOT finalized, so its pixels stay in memory where the rasterizer can reach them.
+func (B *SoftBackend) FinalizeTexQuad(R *TexQuadRenderer) {
+}
+
+// Mimics shaders/sprite.frag
+func (B *SoftBackend) DrawTexQuad(R *TexQuadRenderer, trMatrix mgl32.Mat3) {
+	sub := R.UniSubTexPos
+	color := R.UniColor
+	colorMix := R.UniColorMix
+
+	u.RasterQuad(B.Canvas, trMatrix, func(s, t float32) u.V4 {
+		texel := R.Texture.Sample(
+			u.LerpU(sub.C1, sub.C3, s),
+			u.LerpU(sub.C2, sub.C4, t),
+		)
+		return texel.Mix(color, colorMix)
+	})
+}
+
+// Nothing to upload. The textures of the batch are never finalized, so the rasterizer can reach their pixels
+func (B *SoftBackend) FinalizeSpriteBatch(SB *SpriteBatch) {
+}
+
+// Mimics shaders/sprite_batch.frag - which is the same as drawing the sprites one by one.
+// Or shaders/text.frag if the batch tints
+func (B *SoftBackend) DrawSpriteBatch(SB *SpriteBatch, camMatrix mgl32.Mat3) {
+	tex := SB.texture
+
+	for i := range SB.instances {
+		inst := &SB.instances[i]
+
+		u.RasterQuad(B.Canvas, camMatrix.Mul3(inst.transform), func(s, t float32) u.V4 {
+			texel := tex.Sample(
+				u.LerpU(inst.subTexPos.C1, inst.subTexPos.C3, s),
+				u.LerpU(inst.subTexPos.C2, inst.subTexPos.C4, t),
+			)
+			if SB.Tint {
+				return texel.Times(u.OPAQ_WHITE().Mix(inst.color, inst.colorMix))
+			}
+			return texel.Mix(inst.color, inst.colorMix)
+		})
+	}
+}
+
+// The texture is NOT finalized, so its pixels stay in memory where the rasterizer can reach them.
+func (B *SoftBackend) FinalizeTexture(tex *u.TextureWrapper) {
+}
@@ -62,6 +62,16 @@ func (C *Camera) SetXY(x, y float32) {
 	C.wPosY = -y // behave as expected
 }
 
+// The point the camera looks at
+func (C *Camera) GetXY() (float32, float32) {
+	return -C.wPosX, -C.wPosY
+}
+
+// The angle set by SetAngle and Rotate
+func (C *Camera) GetAngle() float32 {
+	return -C.wAngle
+}
+
 func (C *Camera) Move(x, y float32) {
 	C.cacheValid = false
 	C.wPosX -= x // camera movement must be negative to
@@ -90,3 +100,12 @@ func (C *Camera) GetFrameSize() (float32, float32) {
 func (C *Camera) GetFrameSizeV() shed.V2 {
 	return shed.Vec2(C.wFrameWidth, C.wFrameHeight)
 }
+
+// Convert a point on the screen (in pixels from the top left, like the mouse cursor) to world coordinates.
+// screenW and screenH are the size of the screen, in pixels.
+func (C *Camera) ScreenToWorld(pixel shed.V2, screenW, screenH float32) shed.V2 {
+	ndc := mgl32.Vec3{2*pixel.X/screenW - 1, 1 - 2*pixel.Y/screenH, 1}
+	world := C.GetMatrix().Inv().Mul3x1(ndc)
+
+	return shed.Vec2(world[0], world[1])
+}
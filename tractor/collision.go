@@ -0,0 +1,417 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The shape of a collider
+type ColliderShape int
+
+const (
+	ColliderBox     ColliderShape = iota // the unit square, like sprites and rects. An oriented box once it is transformed
+	ColliderCircle                       // the unit circle (diameter 1), like Ellipse. Non-uniform scales use the larger scale
+	ColliderPolygon                      // a convex polygon
+)
+
+// =========================================================================
+// ||
+// || Collider.
+// ||
+// || A collision shape that moves with a Position. Like the things it is
+// || for, the shape is a unit shape transformed by the Position's matrix,
+// || so a box collider on a sprite covers the sprite exactly.
+// ||
+// =========================================================================
+type Collider struct {
+	Shape    ColliderShape
+	Position *Position
+	Points   []shed.V2 // corners of ColliderPolygon shapes, before they are transformed
+	Layer    uint32    // the layers this collider is on. A bit mask. Zero means all
+	Mask     uint32    // the layers this collider collides with. A bit mask. Zero means all
+	Data     interface{}
+	Deleted  bool
+
+	// World space shape, cached by update()
+	world   []shed.V2 // corners of boxes and polygons
+	circle  shed.Circle
+	bounds  shed.AABB
+	version uint64 // of the Position's matrix when the cache was made. Zero means there is no cache
+}
+
+// The unit square, the same as the quads of sprites and rects
+var unitBox = []shed.V2{{X: -0.5, Y: -0.5}, {X: 0.5, Y: -0.5}, {X: 0.5, Y: 0.5}, {X: -0.5, Y: 0.5}}
+
+func CreateBoxCollider(position *Position) *Collider {
+	return &Collider{Shape: ColliderBox, Position: position, Points: unitBox}
+}
+
+func CreateCircleCollider(position *Position) *Collider {
+	return &Collider{Shape: ColliderCircle, Position: position}
+}
+
+// The polygon must be convex. Concave ones collide like their convex hull would, more or less
+func CreatePolygonCollider(position *Position, points []shed.V2) *Collider {
+	if len(points) < 3 {
+		shed.GlPanic(fmt.Errorf("a polygon collider needs at least 3 points, got %d", len(points)))
+	}
+
+	return &Collider{Shape: ColliderPolygon, Position: position, Points: points}
+}
+
+func (C *Collider) IsDeleted() bool {
+	return C.Deleted
+}
+
+// Do the layers of the colliders let them collide?
+func (C *Collider) CanCollideWith(other *Collider) bool {
+	return layersMatch(C.Mask, other.Layer) && layersMatch(other.Mask, C.Layer)
+}
+
+func layersMatch(mask, layer uint32) bool {
+	return mask == 0 || layer == 0 || mask&layer != 0
+}
+
+// Recalculate the world space shape if the Position has changed
+func (C *Collider) update() {
+	matrix := C.Position.GetMatrix()
+	if C.version != 0 && C.version == C.Position.cacheVersion {
+		return
+	}
+	C.version = C.Position.cacheVersion
+
+	if C.Shape == ColliderCircle {
+		center := matrix.Mul3x1(mgl32.Vec3{0, 0, 1})
+		sx := mgl32.Vec2{matrix[0], matrix[1]}.Len()
+		sy := mgl32.Vec2{matrix[3], matrix[4]}.Len()
+
+		C.circle = shed.Circle{Center: shed.Vec2(center[0], center[1]), Radius: shed.Max(sx, sy) / 2}
+		C.bounds = C.circle.Bounds()
+		return
+	}
+
+	C.world = C.world[:0]
+	for _, p := range C.Points {
+		w := matrix.Mul3x1(mgl32.Vec3{p.X, p.Y, 1})
+		C.world = append(C.world, shed.Vec2(w[0], w[1]))
+	}
+	C.bounds = shed.AABBAround(C.world)
+}
+
+// The box around the collider, in world space
+func (C *Collider) Bounds() shed.AABB {
+	C.update()
+	return C.bounds
+}
+
+// The corners of a box or polygon collider, in world space
+func (C *Collider) WorldPoints() []shed.V2 {
+	C.update()
+	return C.world
+}
+
+// The circle of a circle collider, in world space
+func (C *Collider) WorldCircle() shed.Circle {
+	C.update()
+	return C.circle
+}
+
+// Is the point (in world space) inside the collider?
+func (C *Collider) ContainsPoint(p shed.V2) bool {
+	C.update()
+
+	if !C.bounds.Contains(p) {
+		return false
+	}
+	if C.Shape == ColliderCircle {
+		return C.circle.Contains(p)
+	}
+
+	return shed.PointInConvex(p, C.world)
+}
+
+// Do the colliders overlap? The contact normal points from C towards other.
+// Layers are not checked. See CanCollideWith
+func (C *Collider) Collide(other *Collider) (shed.Contact, bool) {
+	C.update()
+	other.update()
+
+	if !C.bounds.Overlaps(other.bounds) {
+		return shed.Contact{}, false
+	}
+
+	switch {
+	case C.Shape == ColliderCircle && other.Shape == ColliderCircle:
+		return shed.CollideCircles(C.circle, other.circle)
+	case C.Shape == ColliderCircle:
+		return shed.CollideCirclePolygon(C.circle, other.world)
+	case other.Shape == ColliderCircle:
+		return shed.CollidePolygonCircle(C.world, other.circle)
+	}
+
+	return shed.CollidePolygons(C.world, other.world)
+}
+
+// ||=============================
+// || Broadphase
+// ||=============================
+
+// Two colliders that overlap
+type CollisionPair struct {
+	A, B    *Collider
+	Contact shed.Contact // the normal points from A towards B
+}
+
+// =========================================================================
+// ||
+// || Collision World.
+// ||
+// || Finds the colliders that overlap, without testing every collider
+// || against every other one: colliders are sorted into the cells of a
+// || uniform grid, and only colliders that share a cell are tested.
+// ||
+// || The cells should be about as big as the typical collider. Colliders
+// || that would cover more than maxColliderCells of them (or that have
+// || flown off to infinity) are kept aside, and tested against everything.
+// ||
+// =========================================================================
+type CollisionWorld struct {
+	CellSize float32
+
+	colliders []*Collider
+	order     map[*Collider]int // where each collider is in colliders
+	cells     map[cellKey][]*Collider
+	oversized []*Collider // too big for the cells
+	dirty     bool        // colliders were added or removed, or have not been sorted into cells this frame
+	sortedAt  worldTime   // when the colliders were sorted into cells
+}
+
+// How many cells a collider may cover before it is kept out of the grid
+const maxColliderCells = 64
+
+type cellKey struct {
+	x, y int32
+}
+
+func CreateCollisionWorld(cellSize float32) *CollisionWorld {
+	if cellSize <= 0 {
+		shed.GlPanic(fmt.Errorf("the cell size must be > 0, got %f", cellSize))
+	}
+
+	return &CollisionWorld{
+		CellSize: cellSize,
+		order:    make(map[*Collider]int),
+		cells:    make(map[cellKey][]*Collider),
+		dirty:    true,
+	}
+}
+
+func (W *CollisionWorld) Add(colliders ...*Collider) {
+	W.colliders = append(W.colliders, colliders...)
+	W.dirty = true
+}
+
+func (W *CollisionWorld) Remove(collider *Collider) {
+	for i, c := range W.colliders {
+		if c == collider {
+			W.colliders = append(W.colliders[:i], W.colliders[i+1:]...)
+			W.dirty = true
+			return
+		}
+	}
+}
+
+func (W *CollisionWorld) Len() int {
+	return len(W.colliders)
+}
+
+// Sort the colliders into cells. Queries do this on their own once per frame (per Engine.TickCount),
+// and once per fixed update of LoopFixed() (per Engine.UpdateCount), since a frame may run several.
+// Call it after moving colliders if you query more than once per frame or update.
+func (W *CollisionWorld) Update() {
+	for key := range W.cells {
+		delete(W.cells, key)
+	}
+	for c := range W.order {
+		delete(W.order, c)
+	}
+	W.oversized = W.oversized[:0]
+
+	alive := W.colliders[:0]
+	for _, c := range W.colliders {
+		if c.Deleted {
+			continue
+		}
+		W.order[c] = len(alive)
+		alive = append(alive, c)
+
+		if !W.forCells(c.Bounds(), func(key cellKey) { W.cells[key] = append(W.cells[key], c) }) {
+			W.oversized = append(W.oversized, c)
+		}
+	}
+	for i := len(alive); i < len(W.colliders); i++ {
+		W.colliders[i] = nil // let go of the deleted ones
+	}
+	W.colliders = alive
+
+	W.dirty = false
+	W.sortedAt = currentWorldTime()
+}
+
+func (W *CollisionWorld) updateIfStale() {
+	if W.dirty || W.sortedAt != currentWorldTime() {
+		W.Update()
+	}
+}
+
+// The frame, and the fixed update within it
+type worldTime struct {
+	tick, update uint64
+}
+
+// Without an engine (for instance in tools) there are no frames, and the grid is only rebuilt by Update()
+func currentWorldTime() worldTime {
+	if Engine == nil {
+		return worldTime{}
+	}
+	return worldTime{Engine.TickCount, Engine.UpdateCount}
+}
+
+// Call fn for every cell the box touches. Returns false, without calling fn, if the box
+// touches more than maxColliderCells cells, or is not a box at all (NaN or infinite)
+func (W *CollisionWorld) forCells(box shed.AABB, fn func(key cellKey)) bool {
+	x0, y0, ok0 := W.cell(box.Min)
+	x1, y1, ok1 := W.cell(box.Max)
+
+	if !ok0 || !ok1 || x1 < x0 || y1 < y0 || (int64(x1)-int64(x0)+1)*(int64(y1)-int64(y0)+1) > maxColliderCells {
+		return false
+	}
+
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			fn(cellKey{x, y})
+		}
+	}
+	return true
+}
+
+// The cell the point is in. false if there is no such cell: the point is NaN, or too far away
+func (W *CollisionWorld) cell(p shed.V2) (int32, int32, bool) {
+	x := math.Floor(float64(p.X) / float64(W.CellSize))
+	y := math.Floor(float64(p.Y) / float64(W.CellSize))
+
+	inside := func(v float64) bool { return v >= math.MinInt32 && v <= math.MaxInt32 } // false for NaN
+	if !inside(x) || !inside(y) {
+		return 0, 0, false
+	}
+
+	return int32(x), int32(y), true
+}
+
+// Call fn once for every collider that shares a cell with the box, and for the oversized ones.
+// A box too big for the cells gets every collider
+func (W *CollisionWorld) candidates(box shed.AABB, fn func(c *Collider)) {
+	seen := make(map[*Collider]bool)
+
+	inCells := W.forCells(box, func(key cellKey) {
+		for _, c := range W.cells[key] {
+			if !seen[c] {
+				seen[c] = true
+				fn(c)
+			}
+		}
+	})
+
+	if !inCells {
+		for _, c := range W.colliders {
+			fn(c)
+		}
+		return
+	}
+
+	for _, c := range W.oversized {
+		fn(c)
+	}
+}
+
+// All pairs of colliders that overlap, and may collide (see Collider.CanCollideWith).
+// In each pair, A was added to the world before B.
+func (W *CollisionWorld) Pairs() []CollisionPair {
+	W.updateIfStale()
+
+	pairs := []CollisionPair{}
+	for _, a := range W.colliders {
+		W.candidates(a.Bounds(), func(b *Collider) {
+			if W.order[b] <= W.order[a] || !a.CanCollideWith(b) {
+				return // every pair once
+			}
+			if contact, hit := a.Collide(b); hit {
+				pairs = append(pairs, CollisionPair{A: a, B: b, Contact: contact})
+			}
+		})
+	}
+
+	return pairs
+}
+
+// The colliders in the world that overlap C (which does not have to be in the world).
+// The contact normals point from C towards them.
+func (W *CollisionWorld) Query(C *Collider) []CollisionPair {
+	W.updateIfStale()
+
+	pairs := []CollisionPair{}
+	W.candidates(C.Bounds(), func(other *Collider) {
+		if other == C || !C.CanCollideWith(other) {
+			return
+		}
+		if contact, hit := C.Collide(other); hit {
+			pairs = append(pairs, CollisionPair{A: C, B: other, Contact: contact})
+		}
+	})
+
+	return pairs
+}
+
+// The colliders whose bounding boxes overlap the box
+func (W *CollisionWorld) QueryAABB(box shed.AABB) []*Collider {
+	W.updateIfStale()
+
+	found := []*Collider{}
+	W.candidates(box, func(c *Collider) {
+		if c.Bounds().Overlaps(box) {
+			found = append(found, c)
+		}
+	})
+
+	return found
+}
+
+// The colliders under a point (in world space), for instance the mouse cursor.
+// The ones added last come first, since things drawn last are on top.
+func (W *CollisionWorld) Pick(p shed.V2) []*Collider {
+	W.updateIfStale()
+
+	found := []*Collider{}
+	under := func(c *Collider) {
+		if c.ContainsPoint(p) {
+			found = append(found, c)
+		}
+	}
+
+	if x, y, ok := W.cell(p); ok {
+		for _, c := range W.cells[cellKey{x, y}] {
+			under(c)
+		}
+	}
+	for _, c := range W.oversized {
+		under(c)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return W.order[found[i]] > W.order[found[j]] })
+
+	return found
+}
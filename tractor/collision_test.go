@@ -0,0 +1,186 @@
+package tractor
+
+import (
+	"goat/shed"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func boxAt(x, y, size float32) *Collider {
+	P := CreatePosition()
+	P.SetXY(x, y)
+	P.SetScale(size, size)
+	return CreateBoxCollider(&P)
+}
+
+// Huge, infinite and NaN colliders must not hang the grid, and still collide like any other
+func TestCollisionWorldOversizedColliders(t *testing.T) {
+	inf := float32(math.Inf(1))
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name    string
+		big     *Collider
+		hitsAll bool // the small colliders all overlap it
+	}{
+		{"a bit too big", boxAt(0, 0, 1000), true},
+		{"huge", boxAt(0, 0, 1e30), true},
+		{"far away", boxAt(1e30, 1e30, 10), false},
+		{"infinite", boxAt(0, 0, inf), false}, // the corners are NaN: it overlaps nothing
+		{"at infinity", boxAt(inf, 0, 10), false},
+		{"nan", boxAt(nan, nan, 10), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			W := CreateCollisionWorld(10)
+			small := []*Collider{boxAt(5, 5, 2), boxAt(-305, 200, 2), boxAt(400, -1, 2)}
+			W.Add(small[0], small[1])
+			W.Add(test.big)
+			W.Add(small[2])
+
+			done := make(chan bool)
+			go func() {
+				W.Update()
+				done <- true
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("sorting the colliders into cells did not finish")
+			}
+
+			added := map[*Collider]int{small[0]: 0, small[1]: 1, test.big: 2, small[2]: 3}
+
+			withBig := 0
+			for _, pair := range W.Pairs() {
+				if pair.A != test.big && pair.B != test.big {
+					t.Fatalf("the small colliders do not overlap")
+				}
+				if added[pair.A] >= added[pair.B] {
+					t.Fatalf("the pair is not in the order the colliders were added")
+				}
+				withBig++
+			}
+
+			expected := 0
+			if test.hitsAll {
+				expected = len(small)
+			}
+			if withBig != expected {
+				t.Fatalf("%d pairs with the big collider, expected %d", withBig, expected)
+			}
+			if hits := len(W.Query(small[1])); hits != withBig/len(small) {
+				t.Fatalf("the query found %d colliders, expected only the big one if it overlaps", hits)
+			}
+
+			picked := W.Pick(shed.Vec2(5, 5))
+			if test.hitsAll && (len(picked) != 2 || picked[0] != test.big || picked[1] != small[0]) {
+				t.Fatalf("expected the big collider over the small one, picked %v", picked)
+			}
+
+			if all := W.QueryAABB(shed.AABB{Min: shed.Vec2(-inf, -inf), Max: shed.Vec2(inf, inf)}); len(all) < len(small) {
+				t.Fatalf("an infinite box holds only %d colliders", len(all))
+			}
+		})
+	}
+}
+
+func TestCollisionWorldPickOrder(t *testing.T) {
+	W := CreateCollisionWorld(10)
+	first, second := boxAt(5, 5, 4), boxAt(6, 6, 4)
+	W.Add(first, second)
+
+	picked := W.Pick(shed.Vec2(5.5, 5.5))
+	if len(picked) != 2 || picked[0] != second || picked[1] != first {
+		t.Fatalf("the last added collider should come first")
+	}
+}
+
+func TestColliderContainsPointRotated(t *testing.T) {
+	turned := func(sx, sy float32) *Collider {
+		C := boxAt(10, 20, 1)
+		C.Position.AllowNegativeScale(true)
+		C.Position.SetScale(sx, sy)
+		C.Position.SetAngle(mgl32.DegToRad(45))
+		return C
+	}
+	triangle := func(sx float32) *Collider {
+		P := CreatePosition()
+		P.AllowNegativeScale(true)
+		P.SetXY(10, 20)
+		P.SetScale(sx, 1)
+		return CreatePolygonCollider(&P, []shed.V2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}})
+	}
+
+	tests := []struct {
+		name    string
+		C       *Collider
+		inside  []shed.V2
+		outside []shed.V2
+	}{
+		// A 2 by 2 box turned into a diamond. Its corners are sqrt(2) from the center, on the axes
+		{"turned box", turned(2, 2), []shed.V2{{X: 10, Y: 20}, {X: 11.4, Y: 20}, {X: 10, Y: 18.6}}, []shed.V2{{X: 10.9, Y: 20.9}, {X: 11.5, Y: 20}}},
+		{"mirrored turned box", turned(-2, 2), []shed.V2{{X: 11.4, Y: 20}, {X: 10, Y: 21.4}}, []shed.V2{{X: 10.9, Y: 19.1}}},
+		{"turned long box", turned(4, 1), []shed.V2{{X: 11.2, Y: 21.2}, {X: 8.8, Y: 18.8}}, []shed.V2{{X: 11.2, Y: 18.8}}},
+		{"triangle", triangle(1), []shed.V2{{X: 10.2, Y: 20.2}}, []shed.V2{{X: 10.6, Y: 20.6}, {X: 9.8, Y: 20.2}}},
+		{"mirrored triangle", triangle(-1), []shed.V2{{X: 9.8, Y: 20.2}}, []shed.V2{{X: 9.4, Y: 20.6}, {X: 10.2, Y: 20.2}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, p := range test.inside {
+				if !test.C.ContainsPoint(p) {
+					t.Fatalf("%v is not inside %v", p, test.C.WorldPoints())
+				}
+			}
+			for _, p := range test.outside {
+				if test.C.ContainsPoint(p) {
+					t.Fatalf("%v is inside %v", p, test.C.WorldPoints())
+				}
+			}
+		})
+	}
+}
+
+// LoopFixed may run several updates in one frame. Each of them must see where the colliders are now
+func TestCollisionWorldSeesEveryFixedUpdate(t *testing.T) {
+	StartMainHeadless(&WindowOptions{Width: 8, Height: 8}, 4) // the world reads the ticks of the global Engine
+	W := Engine
+	W.Backend.(*SoftBackend).FrameTime = 4.0 / 60 // four updates a frame
+
+	world := CreateCollisionWorld(10)
+	mover, target := boxAt(0, 0, 4), boxAt(40, 0, 4)
+	world.Add(mover, target)
+
+	updates, hits, perFrame := 0, 0, map[uint64]int{}
+	W.LoopFixed(func(dt float32) {
+		updates++
+		perFrame[W.TickCount]++
+
+		x, _, _ := mover.Position.GetXYA()
+		mover.Position.SetXY(x+10, 0)
+
+		overlaps := x+10 == 40
+		if found := len(world.Pairs()) == 1; found != overlaps {
+			t.Fatalf("update %d, with the mover at %v: found a pair is %v", updates, x+10, found)
+		}
+		if found := len(world.Pick(shed.Vec2(x+10, 0))) > 0; !found {
+			t.Fatalf("update %d: the mover is not under its own center at %v", updates, x+10)
+		}
+		if overlaps {
+			hits++
+		}
+	}, func(alpha float32) {})
+
+	several := false
+	for _, n := range perFrame {
+		several = several || n > 1
+	}
+	if !several || hits != 1 {
+		t.Fatalf("the loop did not run as expected: %d updates in frames %v, %d hits", updates, perFrame, hits)
+	}
+}
@@ -1,7 +1,7 @@
 package tractor
 
 import (
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"goat/shed"
 )
 
 var (
@@ -26,56 +26,107 @@ func (C *ControlsType) lazyInit() {
 // =========================================================================
 // || Is the given key pressed?
 // ||
-// || If engine is not set, the main engine (defined in a global variable)
-// || will be used.
+// || Asks the engine's Keyboard, so replays and focused text edits
+// || work here too. If engine is not set, the main engine (defined
+// || in a global variable) will be used.
 // =========================================================================
 func (C *ControlsType) KeyPressed(key KeyCode, engine ...*EngineType) bool {
 	C.lazyInit()
 
-	k := glfw.Key(key)
-	e := *C.E
 	if len(engine) > 0 {
-		e = *engine[0]
+		return engine[0].Controls.Keyboard().IsDown(key)
 	}
 
-	return e.Window.GetKey(k) != Release
+	return C.Keyboard().IsDown(key)
 }
 
 func (C *ControlsType) HandleKeys(kh KeyboardHandler) {
+	C.Keyboard().Handle(kh)
+}
+
+// Call th for every typed character. Use this, not KeyEvent.Str(), for text
+func (C *ControlsType) HandleText(th TextHandler) {
+	C.Keyboard().HandleText(th)
+}
+
+// =========================================================================
+// || The keyboard of the engine's window.
+// ||
+// || Created the first time it is asked for. Headless engines get a
+// || keyboard that only knows what it is fed.
+// =========================================================================
+func (C *ControlsType) Keyboard() *Keyboard {
+	C.lazyInit()
+
+	if C.E.keyboard == nil {
+		C.E.keyboard = CreateKeyboard(C.E.Window)
+	}
+
+	return C.E.keyboard
+}
+
+// =========================================================================
+// || The mouse of the engine's window.
+// ||
+// || Created the first time it is asked for. Headless engines get a mouse
+// || that only knows what it is fed.
+// =========================================================================
+func (C *ControlsType) Mouse() *Mouse {
+	C.lazyInit()
+
+	if C.E.mouse == nil {
+		C.E.mouse = CreateMouse(C.E.Window)
+	}
+
+	return C.E.mouse
+}
+
+func (C *ControlsType) HandleMouse(mh MouseHandler) {
+	C.Mouse().Handle(mh)
+}
+
+// Is the mouse button held down?
+func (C *ControlsType) MouseDown(button MouseButton) bool {
+	return C.Mouse().IsDown(button)
+}
+
+// The mouse cursor, in the world as seen by the camera
+func (C *ControlsType) MouseWorldXY(cam *Camera) shed.V2 {
+	return C.Mouse().WorldXY(cam)
+}
+
+// =========================================================================
+// || The gamepads.
+// ||
+// || Created the first time they are asked for. With a window, glfw finds
+// || them. Headless engines get none, unless they are connected by hand.
+// =========================================================================
+func (C *ControlsType) Gamepads() *Gamepads {
 	C.lazyInit()
 
-	//
-	glfw.GetCurrentContext().SetKeyCallback(func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-
-		kev := KeyEvent{
-			Key:      KeyCode(key),
-			ScanCode: scancode,
-			Pressed:  action == glfw.Press,
-			Repeated: action == glfw.Repeat,
-			Released: action == glfw.Release,
-			Ctrl:     mods&glfw.ModControl != 0,
-			Shift:    mods&glfw.ModShift != 0,
-			Alt:      mods&glfw.ModAlt != 0,
-			Gui:      mods&glfw.ModSuper != 0,
-			L_Ctrl:   key == glfw.KeyLeftControl,
-			R_Ctrl:   key == glfw.KeyRightControl,
-			L_Alt:    key == glfw.KeyLeftAlt,
-			R_Alt:    key == glfw.KeyRightAlt,
-			AltGr:    key == glfw.KeyRightAlt,
-			L_Shift:  key == glfw.KeyLeftShift,
-			R_Shift:  key == glfw.KeyRightShift,
-
-			Up:    key == glfw.KeyDown,
-			Down:  key == glfw.KeyDown,
-			Left:  key == glfw.KeyLeft,
-			Right: key == glfw.KeyRight,
-
-			Escape: key == glfw.KeyEscape,
+	if C.E.gamepads == nil {
+		if C.E.Window != nil {
+			C.E.gamepads = CreateGlfwGamepads()
+		} else {
+			C.E.gamepads = CreateGamepads()
 		}
+	}
+
+	return C.E.gamepads
+}
+
+// =========================================================================
+// || The actions the game is played with. See Actions.
+// ||
+// || Created the first time they are asked for, with no bindings. The
+// || engine updates them every frame.
+// =========================================================================
+func (C *ControlsType) Actions() *Actions {
+	C.lazyInit()
 
-		// ModCapsLock ModifierKey = C.GLFW_MOD_CAPS_LOCK
-		// ModNumLock  ModifierKey = C.GLFW_MOD_NUM_LOCK
+	if C.E.actions == nil {
+		C.E.actions = CreateActions(C.Keyboard(), C.Mouse(), C.Gamepads())
+	}
 
-		kh(&kev)
-	})
+	return C.E.actions
 }
@@ -3,7 +3,9 @@ package tractor
 import (
 	"fmt"
 	shed "goat/shed"
+	"image"
 	"path"
+	"strings"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -30,10 +32,21 @@ type EngineType struct {
 	subTextureDims   map[string]shed.V4               // stores subtextures as "sheet.png/image.png" => minX, minY, maxX, maxY
 	atlasDescriptors map[string]*shed.AtlasDescriptor // stores atlasses as "sheet.png", not "sheet.xml"
 	textures         map[string]*shed.TextureWrapper  // Pointers to all active textures
+	fonts            map[string]*Font                 // TrueType fonts, by filename
+	layers           []*Layer                         // Retained-mode draw lists, sorted by Z
 	cameras          map[string]*Camera               // Contains the projection matrices. You may want to render ceretain things with one cam, and other things with another cam
 	AssetPath        string                           // Base path for all assets
 	MainCamera       *Camera
 	Controls         *ControlsType
+	keyboard         *Keyboard     // See Controls.Keyboard()
+	mouse            *Mouse        // See Controls.Mouse()
+	gamepads         *Gamepads     // See Controls.Gamepads()
+	actions          *Actions      // See Controls.Actions()
+	Backend          Backend       // The thing that does the actual drawing
+	activeBatch      *SpriteBatch  // The sprite batch currently collecting sprites. Flushed when something else is drawn
+	PostDraw         func()        // Called by Loop() after the loop function, before the frame is presented. Good place for CaptureFrame()
+	Assets           *AssetWatcher // Hot-reloading of shaders, textures and atlasses. Disabled by default
+	Tweens           *TweenManager // Started tweens. Advanced by Tick()
 
 	// Timing
 	Now64     float64
@@ -43,8 +56,27 @@ type EngineType struct {
 	Now       float32
 	Prev      float32
 	TickCount uint64
-	Window    *glfw.Window
-	Dispose   func()
+
+	// Fixed timestep. See LoopFixed()
+	TickRate     float64     // Updates per second. 60 by default
+	MaxFrameTime float64     // Longer frames are cut short, so slow updates can't snowball into ever slower frames. 0.25 s by default
+	Alpha        float32     // How far between the last update and the next one the frame is drawn. 0 to 1
+	UpdateCount  uint64      // Number of fixed updates so far
+	Pacer        *FramePacer // Limits the frame rate. Set Pacer.TargetFPS
+	accumulator  float64     // time that has not been updated yet
+	inputPerStep bool        // input is consumed by the updates, not the frames. Frames without an update keep it for the next one
+	interpolated []*interpolatedPosition
+
+	// Input recording and replay. See RecordInput() and ReplayInput()
+	recorder *inputRecorder
+	player   *inputPlayer
+
+	// Statistics
+	DrawCalls     int // Number of draw calls issued so far in the current frame
+	LastDrawCalls int // Number of draw calls the previous frame used
+
+	Window  *glfw.Window // nil when running headless
+	Dispose func()
 }
 
 // Start the goat Motor and assign it to the global variable Motor
@@ -54,24 +86,56 @@ func StartMain(o *WindowOptions) {
 
 // Spin up a goat Motor and return it.
 func StartCustom(o *WindowOptions) *EngineType {
+	backend, err := createGlBackend(o)
+	shed.GlPanicIfErrNotNil(err)
+
+	M := createEngine(backend)
+	M.Window = backend.window
+
+	return M
+}
+
+// Start a headless goat Motor and assign it to the global variable Motor
+func StartMainHeadless(o *WindowOptions, frames uint64) {
+	Engine = StartHeadless(o, frames)
+}
+
+// Spin up a goat Motor that renders into memory instead of a window.
+// No window is opened, and no GPU is needed.
+// Loop() returns after the given number of frames (zero means run until GracefulShutdown)
+//
+// The rendered image is available via Engine.Backend.(*SoftBackend).Canvas
+func StartHeadless(o *WindowOptions, frames uint64) *EngineType {
+	backend := CreateSoftBackend(o.Width, o.Height)
+	backend.MaxFrames = frames
+
+	return createEngine(backend)
+}
+
+func createEngine(backend Backend) *EngineType {
 	M := &EngineType{
 		shaders:          make(map[string]*shed.ShaderProgram),
 		subTextureDims:   make(map[string]shed.V4),
 		atlasDescriptors: make(map[string]*shed.AtlasDescriptor),
 		textures:         make(map[string]*shed.TextureWrapper),
+		fonts:            make(map[string]*Font),
 		cameras:          make(map[string]*Camera),
 		AssetPath:        "assets",
 		Window:           nil,
+		Backend:          backend,
+		Dispose:          backend.Dispose,
+		Assets:           createAssetWatcher(),
+		Tweens:           CreateTweenManager(),
+		TickRate:         60,
+		MaxFrameTime:     0.25,
 	}
 
-	M.Controls = &ControlsType{E: M}
-
-	var err error
+	M.Pacer = CreateFramePacer(backend.Time, backend.WaitUntil)
 
-	M.Dispose, M.Window, err = glfwCreateWin(o)
-	shed.GlPanicIfErrNotNil(err)
+	M.Controls = &ControlsType{E: M}
 
 	M.GetCamera("main")
+	M.createDefaultLayers()
 
 	return M
 }
@@ -84,35 +148,210 @@ func StartCustom(o *WindowOptions) *EngineType {
 func (W *EngineType) Tick() {
 	W.TickCount += 1
 	W.Prev64 = W.Now64
-	W.Now64 = glfw.GetTime()
+	W.Now64 = W.Backend.Time()
+	if W.player != nil {
+		W.player.play(W) // the recorded time and events
+	}
 	W.Delta64 = W.Now64 - W.Prev64
 	W.Delta = float32(W.Delta64)
 	W.Now = float32(W.Now64)
 	W.Prev = float32(W.Prev64)
+
+	if W.gamepads != nil {
+		W.gamepads.Update()
+	}
+	if W.recorder != nil {
+		W.recorder.record(W)
+	}
+	if W.actions != nil && !W.inputPerStep {
+		W.actions.Update()
+	}
+
+	W.Tweens.Advance(W.Delta)
 }
 
 // ============================================
 // || LOOP:
 // ||
+// || Reload changed assets
 // || Clear screen
 // || Call fn(),
+// || Draw all layers
 // || Update screen
 // ============================================
 func (W *EngineType) Loop(fn func()) {
 
-	for !W.Window.ShouldClose() {
-		shed.Clear()
+	for !W.Backend.ShouldClose() {
+		W.Assets.Poll()
+
+		W.Backend.BeginFrame()
 
 		W.Tick()
 
 		fn()
 
-		W.Window.SwapBuffers()
+		W.endFrame()
+	}
+}
+
+// ============================================
+// || LOOP FIXED:
+// ||
+// || Like Loop(), but the game is updated
+// || TickRate times per second, however fast
+// || the frames are drawn:
+// ||
+// || Call update(dt) zero or more times
+// || Call render(alpha)
+// || Draw all layers
+// || Update screen
+// ||
+// || dt is always 1 / TickRate. alpha is how
+// || far the frame is between the last update
+// || and the next one. Positions passed to
+// || Interpolate() are drawn there.
+// ||
+// || Presses and releases are kept until an
+// || update has seen them, and then only
+// || that update sees them.
+// ============================================
+func (W *EngineType) LoopFixed(update func(dt float32), render func(alpha float32)) {
+	W.accumulator = 0
+	W.inputPerStep = true
+	defer func() { W.inputPerStep = false }()
+
+	for !W.Backend.ShouldClose() {
+		W.Assets.Poll()
+
+		W.Backend.BeginFrame()
+
+		W.Tick()
+
+		step := 1 / W.TickRate
+		W.accumulator += min(W.Delta64, W.MaxFrameTime)
+
+		for W.accumulator >= step {
+			for _, I := range W.interpolated {
+				I.prev = I.P.GetState()
+			}
+
+			if W.actions != nil {
+				W.actions.Update()
+			}
+
+			update(float32(step))
+
+			W.endInputFrame() // a press is seen by one update, not by every update of the frame
+			W.UpdateCount++
+			W.accumulator -= step
+		}
+
+		W.Alpha = float32(W.accumulator / step)
+
+		// Draw the interpolated positions, then put them back where the updates left them
+		for _, I := range W.interpolated {
+			I.current = I.P.GetState()
+			I.P.SetState(I.prev.Lerp(I.current, W.Alpha))
+		}
+
+		render(W.Alpha)
+
+		W.endFrame()
+
+		for _, I := range W.interpolated {
+			I.P.SetState(I.current)
+		}
+	}
+}
+
+// Draw the layers, present the frame, and wait for the next one
+func (W *EngineType) endFrame() {
+	W.DrawLayers()
+
+	W.flushActiveBatch()
+	W.LastDrawCalls, W.DrawCalls = W.DrawCalls, 0
+
+	if W.PostDraw != nil {
+		W.PostDraw()
+	}
+
+	W.Backend.EndFrame()
+
+	W.Pacer.Wait()
+
+	if !W.inputPerStep {
+		W.endInputFrame()
+	}
+	W.Backend.PollEvents()
+}
+
+// Forget which keys and buttons were pressed and released, and how far the wheel scrolled
+func (W *EngineType) endInputFrame() {
+	if W.keyboard != nil {
+		W.keyboard.EndFrame()
+	}
+	if W.mouse != nil {
+		W.mouse.EndFrame()
+	}
+	if W.gamepads != nil {
+		W.gamepads.EndFrame()
+	}
+}
+
+// A position that is drawn between its last two updates
+type interpolatedPosition struct {
+	P             *Position
+	prev, current PositionState
+}
+
+// Draw the positions between their states before and after the last update (see LoopFixed),
+// so movement looks smooth when there are more frames than updates.
+func (W *EngineType) Interpolate(positions ...*Position) {
+	for _, P := range positions {
+		W.interpolated = append(W.interpolated, &interpolatedPosition{P: P, prev: P.GetState()})
+	}
+}
+
+func (W *EngineType) StopInterpolating(P *Position) {
+	for i, I := range W.interpolated {
+		if I.P == P {
+			W.interpolated = append(W.interpolated[:i], W.interpolated[i+1:]...)
+			return
+		}
+	}
+}
+
+// Wait for the screen to refresh before presenting a frame. Limits the frame rate to the refresh rate, and prevents tearing
+func (W *EngineType) SetVSync(on bool) {
+	W.Backend.SetVSync(on)
+}
+
+// Draw whatever sprites are waiting in the active sprite batch.
+// Called automatically when the frame ends, and before any other renderer draws.
+func (W *EngineType) flushActiveBatch() {
+	if W.activeBatch != nil {
+		W.activeBatch.Flush()
+	}
+}
 
-		shed.AssertGLOK("End Of Loop")
+// Read back the framebuffer.
+//
+// The frame is only complete after the loop function has run, so call this from
+// PostDraw, at the end of the loop function, or (when headless) after Loop() has returned.
+//
+// The frame is opaque, as it is on screen. Blending leaves the framebuffer's alpha
+// below one where translucent things were drawn, but the window does not show it.
+func (W *EngineType) CaptureFrame() (*image.RGBA, error) {
+	img, err := W.Backend.ReadPixels()
+	if err != nil {
+		return nil, err
+	}
 
-		glfw.PollEvents()
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
 	}
+
+	return img, nil
 }
 
 // Append AssetPath to a file path
@@ -157,16 +396,44 @@ func (W *EngineType) LoadTextureAtlas(filename string) *shed.AtlasDescriptor {
 	descriptor.Texture, err = W.GetTexture(iamgePath)
 	shed.GlPanicIfErrNotNil(err)
 
-	//
-	// Populate the SubTextures table with subtexture dimensions
-	// for use in the shader
+	W.updateSubTextureDims(filename, descriptor)
+
+	W.Assets.watch(AssetAtlas, filename, func() error {
+		return W.reloadTextureAtlas(filename, descriptor)
+	}, assetPath)
+
+	return descriptor
+}
+
+// Re-read an atlas file into an existing descriptor
+func (W *EngineType) reloadTextureAtlas(filename string, descriptor *shed.AtlasDescriptor) error {
+	fresh, err := shed.LoadTextureAtlasFile(W.getPathForAsset(filename))
+	if err != nil {
+		return err
+	}
+
+	fresh.Texture = descriptor.Texture
+	if fresh.ImagePath != descriptor.ImagePath {
+		fresh.Texture, err = W.GetTexture(path.Dir(filename) + "/" + fresh.ImagePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	*descriptor = *fresh
+	W.updateSubTextureDims(filename, descriptor)
+
+	return nil
+}
+
+// Populate the SubTextures table with subtexture dimensions
+// for use in the shader
+func (W *EngineType) updateSubTextureDims(filename string, descriptor *shed.AtlasDescriptor) {
 	w, h := descriptor.Texture.GetSize()
 	w_f32, h_f32 := float32(w), float32(h)
 	for _, sub := range descriptor.SubTextures {
 		W.subTextureDims[filename+"/"+sub.Name] = sub.GetDims(w_f32, h_f32)
 	}
-
-	return descriptor
 }
 
 // Load a texture from a file, or retrieve it from the cache if it had previously been loaded
@@ -187,16 +454,60 @@ func (W *EngineType) GetTexture(filename string) (*shed.TextureWrapper, error) {
 
 	W.textures[filename] = tex
 
+	W.Assets.watch(AssetTexture, filename, func() error {
+		img, err := decodeImageFile(assetPath)
+		if err != nil {
+			return err
+		}
+		if err := tex.ReplaceImage(img); err != nil {
+			return err
+		}
+
+		// the size of the texture may have changed
+		for atlasName, descriptor := range W.atlasDescriptors {
+			if descriptor.Texture == tex {
+				W.updateSubTextureDims(atlasName, descriptor)
+			}
+		}
+		return nil
+	}, assetPath)
+
 	return tex, nil
 }
 
+// Load a TrueType font, or retrieve it from the cache if it had previously been loaded.
+// The font is drawn with TextShader, and is ready to use.
+func (W *EngineType) LoadFont(filename string) *Font {
+
+	if font, found := W.fonts[filename]; found {
+		return font
+	}
+
+	ttf, err := shed.LoadTrueTypeFile(W.getPathForAsset(filename))
+	if err != nil {
+		shed.GlPanic(fmt.Errorf("cannot load font '%s': %v", filename, err))
+	}
+
+	font := CreateFont(TextShader, filename, ttf)
+	font.Finalize()
+
+	W.fonts[filename] = font
+
+	return font
+}
+
 // Load a shader program from the BASENAME of a file.
 // The vert shader must have the .vert extension
 // The frag shader must have the .frag extension
+// Programs that share a vert shader are named "vert basename+frag basename", see ShaderName()
 func (W *EngineType) GetShader(filename string) (*shed.ShaderProgram, error) {
 
 	vert := filename + ".vert"
 	frag := filename + ".frag"
+	if vertBasename, fragBasename, found := strings.Cut(filename, "+"); found {
+		vert = vertBasename + ".vert"
+		frag = fragBasename + ".frag"
+	}
 
 	// Do we already have this shader in the cache
 	if prog, found := W.shaders[filename]; found {
@@ -207,9 +518,19 @@ func (W *EngineType) GetShader(filename string) (*shed.ShaderProgram, error) {
 
 	W.shaders[filename] = prog
 
+	W.Assets.watch(AssetShader, filename, prog.Reload, vert, frag)
+
 	return prog, nil
 }
 
+// The name of a shader program made of the vert shader of one basename and the frag shader of another
+func ShaderName(vertBasename, fragBasename string) string {
+	return vertBasename + "+" + fragBasename
+}
+
+// Text is drawn like a sprite batch, with a frag shader that tints the glyphs
+var TextShader = ShaderName("shaders/sprite_batch", "shaders/text")
+
 // Acquire a camera by the given name.
 func (W *EngineType) GetCamera(name string) (cam *Camera, existsAlready bool) {
 
@@ -232,6 +553,20 @@ func (W *EngineType) GetCamera(name string) (cam *Camera, existsAlready bool) {
 	return
 }
 
+// Acquire a camera that maps world units 1:1 to pixels, with (0, 0) in the center of the screen.
+// It is never moved, rotated or zoomed, so it is useful for HUDs, text, etc.
+// The frame size is updated to match the framebuffer every time this function is called.
+func (W *EngineType) GetScreenCamera() *Camera {
+	cam, _ := W.GetCamera("screen")
+
+	w, h := W.Backend.FramebufferSize()
+	if fw, fh := cam.GetFrameSize(); fw != float32(w) || fh != float32(h) {
+		cam.SetFrameSize(float32(w), float32(h))
+	}
+
+	return cam
+}
+
 // Get the location and size of a given subtexture
 func (W *EngineType) GetDimsForSubtexture(atlasFilename, subTexFilename string) shed.V4 {
 	key := atlasFilename + "/" + subTexFilename
@@ -253,5 +588,5 @@ func (W *EngineType) GetAspectRatioForSubTexture(atlasFilename, subTexFilename s
 }
 
 func (W *EngineType) GracefulShutdown() {
-	W.Window.SetShouldClose(true)
+	W.Backend.SetShouldClose(true)
 }
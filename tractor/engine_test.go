@@ -0,0 +1,97 @@
+package tractor
+
+import (
+	"testing"
+)
+
+func TestLoopFixedSteps(t *testing.T) {
+	tests := []struct {
+		name         string
+		frameTime    float64
+		maxFrameTime float64
+		updates      []uint64  // UpdateCount when each frame is drawn
+		alphas       []float32 // Alpha when each frame is drawn
+	}{
+		// Eight updates a second. Three updates every two frames
+		{"one and a half updates a frame", 0.1875, 0.25, []uint64{0, 1, 3, 4, 6}, []float32{0, 0.5, 0, 0.5, 0}},
+		{"two frames an update", 0.0625, 0.25, []uint64{0, 0, 1, 1, 2}, []float32{0, 0.5, 0, 0.5, 0}},
+		// Frames of a second are cut short, to two updates each
+		{"slow frames", 1, 0.25, []uint64{0, 2, 4, 6, 8}, []float32{0, 0, 0, 0, 0}},
+		{"slow frames, a longer limit", 1, 0.5625, []uint64{0, 4, 9, 13, 18}, []float32{0, 0.5, 0, 0.5, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, uint64(len(test.updates)))
+			W.Backend.(*SoftBackend).FrameTime = test.frameTime
+			W.TickRate = 8
+			W.MaxFrameTime = test.maxFrameTime
+
+			updates, alphas := []uint64{}, []float32{}
+			W.LoopFixed(func(dt float32) {
+				if dt != 0.125 {
+					t.Fatalf("an update of %v seconds", dt)
+				}
+			}, func(alpha float32) {
+				if alpha != W.Alpha {
+					t.Fatalf("render got an alpha of %v, but Engine.Alpha is %v", alpha, W.Alpha)
+				}
+				updates = append(updates, W.UpdateCount)
+				alphas = append(alphas, alpha)
+			})
+
+			for i := range test.updates {
+				if i >= len(updates) || updates[i] != test.updates[i] || alphas[i] != test.alphas[i] {
+					t.Fatalf("frames were drawn after %v updates with alphas %v, expected %v and %v", updates, alphas, test.updates, test.alphas)
+				}
+			}
+		})
+	}
+}
+
+// Frames are drawn between the last two updates, and the updates see where they left things
+func TestLoopFixedInterpolation(t *testing.T) {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 8)
+	W.Backend.(*SoftBackend).FrameTime = 0.1875 // one and a half updates a frame
+	W.TickRate = 8
+
+	P := CreatePosition()
+	P.SetXY(0, 0)
+	W.Interpolate(&P)
+
+	still := CreatePosition() // not interpolated
+	still.SetXY(0, 0)
+
+	drawn := []float32{}
+	W.LoopFixed(func(dt float32) {
+		if x, _, _ := P.GetXYA(); x != 8*float32(W.UpdateCount) {
+			t.Fatalf("update %d starts with x at %v, where the last update left it", W.UpdateCount, x)
+		}
+		P.SetXY(8*float32(W.UpdateCount+1), 0)
+		still.SetXY(8*float32(W.UpdateCount+1), 0)
+	}, func(alpha float32) {
+		x, _, _ := P.GetXYA()
+		drawn = append(drawn, x)
+
+		if sx, _, _ := still.GetXYA(); sx != 8*float32(W.UpdateCount) {
+			t.Fatalf("a position that is not interpolated was moved to %v", sx)
+		}
+	})
+
+	// The updates move 8 units each. After n updates, a frame is drawn alpha of the way from where update n-1 left x to where update n did.
+	// The frames come after 0, 1, 3, 4, 6, 7, 9 and 10 updates, with alphas of 0 and 0.5 in turn
+	expected := []float32{0, 4, 16, 28, 40, 52, 64, 76}
+	for i := range expected {
+		if i >= len(drawn) || drawn[i] != expected[i] {
+			t.Fatalf("drawn at %v, expected %v", drawn, expected)
+		}
+	}
+	if x, _, _ := P.GetXYA(); x != 8*float32(W.UpdateCount) {
+		t.Fatalf("after the loop, x is %v instead of where the last update left it", x)
+	}
+
+	W.StopInterpolating(&P)
+	if len(W.interpolated) != 0 {
+		t.Fatalf("the position is still interpolated")
+	}
+}
@@ -0,0 +1,309 @@
+package tractor
+
+import (
+	u "goat/shed"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	fontAtlasSize    = 1024 // width and height of a font's glyph atlas, in pixels
+	fontGlyphPadding = 1    // empty pixels around each glyph in the atlas, so neighbours don't bleed
+	fontMaxPpem      = 256  // glyphs are never rasterized larger than this. Larger text is scaled up
+)
+
+// =========================================================================
+// ||
+// || TrueType Font
+// ||
+// || Glyphs are rasterized on demand, at the size they are drawn at,
+// || into a glyph atlas texture that belongs to the font.
+// || When the atlas is full, it is emptied and refilled with the glyphs
+// || that are actually being used.
+// ||
+// || All glyphs of a font are drawn through the font's SpriteBatch,
+// || so a string is (usually) a single draw call.
+// ||
+// =========================================================================
+type Font struct {
+	Name    string
+	TTF     *u.TrueTypeFont
+	Texture *u.TextureWrapper // the glyph atlas
+	Batch   *SpriteBatch
+
+	kerning map[[2]rune]float32 // extra space between pairs of characters, relative to the font size
+	glyphs  map[fontGlyphKey]*fontGlyph
+
+	// Where the next glyph goes in the atlas. Glyphs are packed in rows (shelves)
+	packX, packY, rowHeight int
+}
+
+// Vertical metrics of a font at a given size. Y points up.
+type FontMetrics struct {
+	Ascent     float32 // Distance from the baseline to the top of the tallest glyphs
+	Descent    float32 // Distance from the baseline to the bottom of the lowest glyphs. Negative
+	LineHeight float32 // Distance between the baselines of two lines
+}
+
+// A glyph at a given size
+type fontGlyphKey struct {
+	glyph uint16
+	ppem  int
+}
+
+// A glyph in the atlas
+type fontGlyph struct {
+	subTexPos u.V4    // flipped vertically, like the glyphs of TextRenderer
+	w, h      float32 // size of the bitmap, in pixels
+	left, top float32 // position of the bitmap's top-left corner relative to the origin, in pixels. Y points down
+	empty     bool    // glyphs without an outline (space) are not drawn
+}
+
+// Create a font from a parsed TrueType file.
+// The shader must have the same attributes and uniforms as TextShader
+func CreateFont(shaderFileBasename, name string, ttf *u.TrueTypeFont) *Font {
+	tex, err := u.CreateTexture(image.NewRGBA(image.Rect(0, 0, fontAtlasSize, fontAtlasSize)), gl.CLAMP_TO_EDGE, gl.CLAMP_TO_EDGE)
+	u.GlPanicIfErrNotNil(err)
+
+	F := &Font{
+		Name:    name,
+		TTF:     ttf,
+		Texture: tex,
+		Batch:   CreateSpriteBatch(shaderFileBasename),
+		kerning: make(map[[2]rune]float32),
+		glyphs:  make(map[fontGlyphKey]*fontGlyph),
+	}
+	F.Batch.Tint = true
+
+	return F
+}
+
+// Upload the glyph atlas and prepare the batch for drawing.
+// Glyphs rasterized later are uploaded as they are needed.
+func (F *Font) Finalize() {
+	Engine.Backend.FinalizeTexture(F.Texture)
+	F.Batch.Finalize()
+}
+
+// Add (or remove) space between two characters, on top of the kerning in the font file.
+// The amount is relative to the font size. Negative amounts move the characters closer together.
+func (F *Font) SetKerning(left, right rune, amount float32) {
+	if amount == 0 {
+		delete(F.kerning, [2]rune{left, right})
+		return
+	}
+
+	F.kerning[[2]rune{left, right}] = amount
+}
+
+// The vertical metrics of the font, at the given size
+func (F *Font) Metrics(size float32) FontMetrics {
+	scale := size / float32(F.TTF.UnitsPerEm)
+
+	return FontMetrics{
+		Ascent:     float32(F.TTF.Ascent) * scale,
+		Descent:    float32(F.TTF.Descent) * scale,
+		LineHeight: float32(F.TTF.Ascent-F.TTF.Descent+F.TTF.LineGap) * scale,
+	}
+}
+
+// The width and height of the text, if it was drawn at the given size.
+// The height goes from the ascent of the first line to the descent of the last.
+func (F *Font) Measure(size float32, str string) (w, h float32) {
+	lines := strings.Split(str, "\n")
+
+	for _, line := range lines {
+		if lw := F.lineWidth(size, line); lw > w {
+			w = lw
+		}
+	}
+
+	m := F.Metrics(size)
+
+	return w, m.Ascent - m.Descent + float32(len(lines)-1)*m.LineHeight
+}
+
+// Draw text. size is the height of the em square, in the camera's units.
+// (x, y) is the left end of the baseline of the first line.
+// Lines are separated by "\n"
+func (F *Font) Draw(camera *Camera, size, x, y float32, str string, color u.V4) {
+	scale := size / float32(F.TTF.UnitsPerEm)
+	ppem := clampPpem(size)
+	pixel := size / float32(ppem) // size of an atlas pixel, in camera units
+
+	lineHeight := F.Metrics(size).LineHeight
+
+	for n, line := range strings.Split(str, "\n") {
+		pen := x
+		baseline := y - float32(n)*lineHeight
+
+		var prevRune rune
+		var prevGlyph uint16
+		for i, r := range line {
+			g := F.TTF.GlyphIndex(r)
+			if i > 0 {
+				pen += F.kern(prevRune, r, prevGlyph, g, size)
+			}
+			prevRune, prevGlyph = r, g
+
+			glyph := F.glyph(g, ppem)
+			if glyph != nil && !glyph.empty {
+				w, h := glyph.w*pixel, glyph.h*pixel
+				left := pen + glyph.left*pixel
+				top := baseline - glyph.top*pixel
+
+				transform := mgl32.Translate2D(left+w/2, top-h/2).Mul3(mgl32.Scale2D(w, h))
+				F.Batch.AddQuad(F.Texture, camera, transform, glyph.subTexPos, color, 1)
+			}
+
+			pen += float32(F.TTF.Advance(g)) * scale
+		}
+	}
+}
+
+func (F *Font) lineWidth(size float32, line string) float32 {
+	scale := size / float32(F.TTF.UnitsPerEm)
+
+	var w float32
+	var prevRune rune
+	var prevGlyph uint16
+	for i, r := range line {
+		g := F.TTF.GlyphIndex(r)
+		if i > 0 {
+			w += F.kern(prevRune, r, prevGlyph, g, size)
+		}
+		prevRune, prevGlyph = r, g
+
+		w += float32(F.TTF.Advance(g)) * scale
+	}
+
+	return w
+}
+
+func (F *Font) kern(left, right rune, leftGlyph, rightGlyph uint16, size float32) float32 {
+	fromFile := float32(F.TTF.Kern(leftGlyph, rightGlyph)) * size / float32(F.TTF.UnitsPerEm)
+
+	return fromFile + F.kerning[[2]rune{left, right}]*size
+}
+
+// Get a glyph from the atlas, rasterizing it if needed.
+// Returns nil if the glyph cannot be rasterized.
+func (F *Font) glyph(g uint16, ppem int) *fontGlyph {
+	key := fontGlyphKey{g, ppem}
+	if glyph, found := F.glyphs[key]; found {
+		return glyph
+	}
+
+	img, left, top, err := F.TTF.RasterizeGlyph(g, float32(ppem))
+	if err != nil {
+		u.GlLog("Could not rasterize glyph %d of font '%s': %v", g, F.Name, err)
+		F.glyphs[key] = nil
+		return nil
+	}
+
+	if img == nil {
+		F.glyphs[key] = &fontGlyph{empty: true}
+		return F.glyphs[key]
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x, y, ok := F.allocate(w+2*fontGlyphPadding, h+2*fontGlyphPadding)
+	if !ok {
+		u.GlLog("Glyph %d of font '%s' is too large for the glyph atlas", g, F.Name)
+		F.glyphs[key] = nil
+		return nil
+	}
+
+	// white pixels, with the coverage as alpha. The text shader tints them.
+	padded := image.NewRGBA(image.Rect(0, 0, w+2*fontGlyphPadding, h+2*fontGlyphPadding))
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			i := padded.PixOffset(px+fontGlyphPadding, py+fontGlyphPadding)
+			padded.Pix[i+0] = 255
+			padded.Pix[i+1] = 255
+			padded.Pix[i+2] = 255
+			padded.Pix[i+3] = img.AlphaAt(px, py).A
+		}
+	}
+	F.Texture.UpdateRegion(x, y, padded)
+
+	gx, gy := x+fontGlyphPadding, y+fontGlyphPadding
+	glyph := &fontGlyph{
+		// Sprites are drawn upside down, so glyphs are flipped to come out upright.
+		subTexPos: u.V4{
+			C1: float32(gx) / fontAtlasSize,
+			C2: float32(gy+h) / fontAtlasSize,
+			C3: float32(gx+w) / fontAtlasSize,
+			C4: float32(gy) / fontAtlasSize,
+		},
+		w:    float32(w),
+		h:    float32(h),
+		left: float32(left),
+		top:  float32(top),
+	}
+	F.glyphs[key] = glyph
+
+	return glyph
+}
+
+// Find room for a w*h pixel rectangle in the atlas.
+// If the atlas is full, everything queued so far is drawn, and the atlas is emptied.
+func (F *Font) allocate(w, h int) (x, y int, ok bool) {
+	if w > fontAtlasSize || h > fontAtlasSize {
+		return 0, 0, false
+	}
+
+	if F.packX+w > fontAtlasSize {
+		F.packX = 0
+		F.packY += F.rowHeight
+		F.rowHeight = 0
+	}
+
+	if F.packY+h > fontAtlasSize {
+		F.Batch.Flush() // the queued glyphs must be drawn before their pixels are overwritten
+		F.glyphs = make(map[fontGlyphKey]*fontGlyph)
+		F.packX, F.packY, F.rowHeight = 0, 0, 0
+	}
+
+	x, y = F.packX, F.packY
+	F.packX += w
+	if h > F.rowHeight {
+		F.rowHeight = h
+	}
+
+	return x, y, true
+}
+
+// Glyphs are rasterized at a whole number of pixels per em
+func clampPpem(size float32) int {
+	ppem := int(math.Round(float64(size)))
+
+	if ppem < 1 {
+		return 1
+	}
+	if ppem > fontMaxPpem {
+		return fontMaxPpem
+	}
+
+	return ppem
+}
+
+// ||=============================
+// ||
+// || Engine helpers
+// ||
+// ||=============================
+
+// Draw text with a font loaded via LoadFont(). See Font.Draw()
+func (W *EngineType) DrawText(camera *Camera, font *Font, size, x, y float32, str string, color u.V4) {
+	font.Draw(camera, size, x, y, str, color)
+}
+
+// Measure text drawn with a font loaded via LoadFont(). See Font.Measure()
+func (W *EngineType) MeasureText(font *Font, size float32, str string) (w, h float32) {
+	return font.Measure(size, str)
+}
@@ -0,0 +1,66 @@
+package tractor
+
+import (
+	"time"
+)
+
+// =========================================================================
+// ||
+// || Frame Pacer.
+// ||
+// || Limits the frame rate. Frames are released on a steady beat, rather
+// || than a fixed time after the previous one, so short and long frames
+// || even out. A pacer that falls more than a frame behind starts over
+// || instead of rushing to catch up.
+// ||
+// =========================================================================
+type FramePacer struct {
+	TargetFPS float64 // Frames per second. Zero (or less) means no limit
+
+	clock     func() float64  // seconds
+	waitUntil func(t float64) // returns when clock() >= t
+	last      float64         // when the previous frame was released
+	started   bool
+}
+
+func CreateFramePacer(clock func() float64, waitUntil func(t float64)) *FramePacer {
+	return &FramePacer{clock: clock, waitUntil: waitUntil}
+}
+
+// Wait until it is time for the next frame
+func (P *FramePacer) Wait() {
+	if P.TargetFPS <= 0 {
+		P.started = false
+		return
+	}
+
+	period := 1 / P.TargetFPS
+	target := P.last + period
+	now := P.clock()
+
+	if !P.started || now > target+period {
+		P.last, P.started = now, true
+		return
+	}
+
+	if now < target {
+		P.waitUntil(target)
+	}
+	P.last = target
+}
+
+// Sleep until clock() reaches t. Sleeps are not precise, so the last
+// millisecond or so is spent spinning.
+func SleepUntil(clock func() float64, t float64) {
+	const spin = 0.002 // seconds
+
+	for {
+		left := t - clock()
+		if left <= 0 {
+			return
+		}
+		if left > spin {
+			time.Sleep(time.Duration((left - spin) * float64(time.Second)))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package tractor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFramePacer(t *testing.T) {
+	now := 0.0
+	waited := false
+	P := CreateFramePacer(
+		func() float64 { return now },
+		func(t float64) { now, waited = t, true },
+	)
+
+	// 8 frames a second: one every 0.125 seconds
+	frames := []struct {
+		fps     float64
+		work    float64 // seconds the frame takes before it waits
+		release float64 // when Wait() returns
+		waits   bool
+	}{
+		{8, 0, 0, false},                  // the first frame starts the beat
+		{8, 0.0625, 0.125, true},          // a short frame waits for the beat
+		{8, 0.1875, 0.3125, false},        // a long frame is late...
+		{8, 0.03125, 0.375, true},         // ...and the next one is back on the beat
+		{8, 0.5, 0.875, false},            // more than a frame behind: start over...
+		{8, 0.0625, 1, true},              // ...from when the late frame was released
+		{0, 0.0625, 1.0625, false},        // no limit
+		{8, 0, 1.0625, false},             // a new beat
+		{8, 0.0625, 1.1875, true},         //
+		{8, 0.125 + 0.0625, 1.375, false}, // late by less than a frame. No wait, but the beat goes on
+		{8, 0, 1.4375, true},              //
+	}
+
+	for i, frame := range frames {
+		now += frame.work
+		waited = false
+
+		P.TargetFPS = frame.fps
+		P.Wait()
+
+		if now != frame.release || waited != frame.waits {
+			t.Fatalf("frame %d was released at %v (waited: %v), expected %v (waited: %v)", i, now, waited, frame.release, frame.waits)
+		}
+	}
+}
+
+func TestSleepUntil(t *testing.T) {
+	start := time.Now()
+	clock := func() float64 { return time.Since(start).Seconds() }
+
+	SleepUntil(clock, 0.01)
+	if now := clock(); now < 0.01 {
+		t.Fatalf("SleepUntil returned at %v", now)
+	}
+
+	SleepUntil(clock, 0) // in the past
+}
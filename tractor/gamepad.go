@@ -0,0 +1,491 @@
+package tractor
+
+import (
+	"goat/shed"
+	"os"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+type GamepadButton glfw.GamepadButton
+
+const (
+	GamepadA           = GamepadButton(glfw.ButtonA)
+	GamepadB           = GamepadButton(glfw.ButtonB)
+	GamepadX           = GamepadButton(glfw.ButtonX)
+	GamepadY           = GamepadButton(glfw.ButtonY)
+	GamepadLeftBumper  = GamepadButton(glfw.ButtonLeftBumper)
+	GamepadRightBumper = GamepadButton(glfw.ButtonRightBumper)
+	GamepadBack        = GamepadButton(glfw.ButtonBack)
+	GamepadStart       = GamepadButton(glfw.ButtonStart)
+	GamepadGuide       = GamepadButton(glfw.ButtonGuide)
+	GamepadLeftThumb   = GamepadButton(glfw.ButtonLeftThumb)
+	GamepadRightThumb  = GamepadButton(glfw.ButtonRightThumb)
+	GamepadDpadUp      = GamepadButton(glfw.ButtonDpadUp)
+	GamepadDpadRight   = GamepadButton(glfw.ButtonDpadRight)
+	GamepadDpadDown    = GamepadButton(glfw.ButtonDpadDown)
+	GamepadDpadLeft    = GamepadButton(glfw.ButtonDpadLeft)
+
+	gamepadButtonCount = int(glfw.ButtonLast) + 1
+)
+
+type GamepadAxis glfw.GamepadAxis
+
+const (
+	GamepadLeftX        = GamepadAxis(glfw.AxisLeftX)
+	GamepadLeftY        = GamepadAxis(glfw.AxisLeftY) // down is positive
+	GamepadRightX       = GamepadAxis(glfw.AxisRightX)
+	GamepadRightY       = GamepadAxis(glfw.AxisRightY) // down is positive
+	GamepadLeftTrigger  = GamepadAxis(glfw.AxisLeftTrigger)
+	GamepadRightTrigger = GamepadAxis(glfw.AxisRightTrigger)
+
+	gamepadAxisCount = int(glfw.AxisLast) + 1
+)
+
+const gamepadSlots = int(glfw.JoystickLast) + 1
+
+// The buttons and axes of a gamepad, laid out like an Xbox controller.
+// Sticks go from -1 to 1, and triggers from -1 (released) to 1 (pulled), like glfw reports them.
+type GamepadState struct {
+	Buttons [gamepadButtonCount]bool
+	Axes    [gamepadAxisCount]float32
+}
+
+// Nothing pressed: the triggers are at -1
+func releasedGamepadState() GamepadState {
+	S := GamepadState{}
+	S.Axes[GamepadLeftTrigger], S.Axes[GamepadRightTrigger] = -1, -1
+
+	return S
+}
+
+// =========================================================================
+// ||
+// || Gamepad Device.
+// ||
+// || Where the state of a gamepad comes from: a joystick that glfw found,
+// || or a FakeGamepad in a test.
+// ||
+// =========================================================================
+type GamepadDevice interface {
+	Name() string
+	GUID() string // identifies the model, for the mapping database
+
+	// The state, if the device knows which of its buttons is which. Otherwise Raw() is mapped with the mapping database
+	State() (GamepadState, bool)
+
+	// The buttons, axes and hats as the hardware numbers them. Hats are bit masks: 1 up, 2 right, 4 down, 8 left
+	Raw() (axes []float32, buttons []bool, hats []int)
+}
+
+// A gamepad without hardware, for tests. Set Pad to pretend to be a known gamepad,
+// or leave it nil and set the raw inputs, to test a mapping.
+type FakeGamepad struct {
+	DeviceName string
+	DeviceGUID string
+	Pad        *GamepadState
+	Axes       []float32
+	Buttons    []bool
+	Hats       []int
+}
+
+func (F *FakeGamepad) Name() string { return F.DeviceName }
+func (F *FakeGamepad) GUID() string { return F.DeviceGUID }
+
+func (F *FakeGamepad) State() (GamepadState, bool) {
+	if F.Pad == nil {
+		return GamepadState{}, false
+	}
+	return *F.Pad, true
+}
+
+func (F *FakeGamepad) Raw() ([]float32, []bool, []int) {
+	return F.Axes, F.Buttons, F.Hats
+}
+
+// A joystick glfw knows about
+type glfwGamepad struct {
+	joy glfw.Joystick
+}
+
+func (G glfwGamepad) Name() string {
+	if G.joy.IsGamepad() {
+		return G.joy.GetGamepadName()
+	}
+	return G.joy.GetName()
+}
+
+func (G glfwGamepad) GUID() string {
+	return G.joy.GetGUID()
+}
+
+func (G glfwGamepad) State() (GamepadState, bool) {
+	if !G.joy.IsGamepad() {
+		return GamepadState{}, false
+	}
+
+	state := G.joy.GetGamepadState()
+	if state == nil {
+		return GamepadState{}, false
+	}
+
+	S := GamepadState{Axes: state.Axes}
+	for i, action := range state.Buttons {
+		S.Buttons[i] = action == glfw.Press
+	}
+	return S, true
+}
+
+func (G glfwGamepad) Raw() ([]float32, []bool, []int) {
+	actions := G.joy.GetButtons()
+	buttons := make([]bool, len(actions))
+	for i, action := range actions {
+		buttons[i] = action == glfw.Press
+	}
+
+	states := G.joy.GetHats()
+	hats := make([]int, len(states))
+	for i, state := range states {
+		hats[i] = int(state)
+	}
+
+	return G.joy.GetAxes(), buttons, hats
+}
+
+// =========================================================================
+// ||
+// || Gamepad.
+// ||
+// || One connected controller. The state is read once per frame by
+// || Gamepads.Update(). Like the Mouse, "pressed" and "released" are
+// || collected until Gamepads.EndFrame().
+// ||
+// =========================================================================
+type Gamepad struct {
+	ID              int     // the slot the gamepad is in. Stays the same while it is connected
+	DeadZone        float32 // sticks closer to the center than this count as centered. 0.15 by default
+	TriggerDeadZone float32 // triggers pulled less than this count as released. 0.05 by default
+
+	device    GamepadDevice
+	mapping   *GamepadMapping // nil if the device maps itself, or if there is no mapping for it
+	state     GamepadState
+	prev      GamepadState
+	pressed   [gamepadButtonCount]bool
+	released  [gamepadButtonCount]bool
+	mapped    bool // state is a real gamepad layout, not guesswork
+	connected bool
+}
+
+func (G *Gamepad) Name() string {
+	if G.mapping != nil && G.mapping.Name != "" {
+		return G.mapping.Name
+	}
+	return G.device.Name()
+}
+
+func (G *Gamepad) GUID() string {
+	return G.device.GUID()
+}
+
+func (G *Gamepad) IsConnected() bool {
+	return G.connected
+}
+
+// Do we know which of the device's buttons is which? If not, use the raw inputs (see Device)
+func (G *Gamepad) IsMapped() bool {
+	return G.mapped
+}
+
+// The device the gamepad reads, for its raw inputs
+func (G *Gamepad) Device() GamepadDevice {
+	return G.device
+}
+
+// Read the state of the device. Gamepads.Update() does this once per frame
+func (G *Gamepad) update() {
+	G.prev = G.state
+
+	state, ok := G.device.State()
+	if !ok && G.mapping != nil {
+		state, ok = G.mapping.Apply(G.device.Raw())
+	}
+	if !ok {
+		state = releasedGamepadState()
+	}
+	G.state, G.mapped = state, ok
+
+	for b := range G.state.Buttons {
+		G.pressed[b] = G.pressed[b] || G.state.Buttons[b] && !G.prev.Buttons[b]
+		G.released[b] = G.released[b] || !G.state.Buttons[b] && G.prev.Buttons[b]
+	}
+}
+
+// Forget which buttons were pressed and released
+func (G *Gamepad) endFrame() {
+	G.pressed = [gamepadButtonCount]bool{}
+	G.released = [gamepadButtonCount]bool{}
+}
+
+// Is the button held down?
+func (G *Gamepad) IsDown(button GamepadButton) bool {
+	return validGamepadButton(button) && G.state.Buttons[button]
+}
+
+// Did the button go down this frame?
+func (G *Gamepad) WasPressed(button GamepadButton) bool {
+	return validGamepadButton(button) && G.pressed[button]
+}
+
+// Did the button go up this frame?
+func (G *Gamepad) WasReleased(button GamepadButton) bool {
+	return validGamepadButton(button) && G.released[button]
+}
+
+func validGamepadButton(button GamepadButton) bool {
+	return int(button) >= 0 && int(button) < gamepadButtonCount
+}
+
+// The left stick, with the dead zone applied. Down is positive y
+func (G *Gamepad) LeftStick() shed.V2 {
+	return applyDeadZone(shed.Vec2(G.state.Axes[GamepadLeftX], G.state.Axes[GamepadLeftY]), G.DeadZone)
+}
+
+// The right stick, with the dead zone applied. Down is positive y
+func (G *Gamepad) RightStick() shed.V2 {
+	return applyDeadZone(shed.Vec2(G.state.Axes[GamepadRightX], G.state.Axes[GamepadRightY]), G.DeadZone)
+}
+
+// An axis, with the dead zone applied. Sticks go from -1 to 1, and triggers from 0 (released) to 1 (pulled)
+func (G *Gamepad) Axis(axis GamepadAxis) float32 {
+	switch axis {
+	case GamepadLeftX:
+		return G.LeftStick().X
+	case GamepadLeftY:
+		return G.LeftStick().Y
+	case GamepadRightX:
+		return G.RightStick().X
+	case GamepadRightY:
+		return G.RightStick().Y
+	case GamepadLeftTrigger, GamepadRightTrigger:
+		pulled := (G.state.Axes[axis] + 1) / 2
+		if pulled <= G.TriggerDeadZone {
+			return 0
+		}
+		return shed.Min((pulled-G.TriggerDeadZone)/(1-G.TriggerDeadZone), 1)
+	}
+
+	return 0
+}
+
+// The state as the device reported it, without dead zones
+func (G *Gamepad) RawState() GamepadState {
+	return G.state
+}
+
+// Sticks are never quite centered. Ignore small deflections, and rescale the rest so it still starts at zero.
+// The dead zone is round, so diagonals are not favored
+func applyDeadZone(stick shed.V2, deadZone float32) shed.V2 {
+	length := stick.Len()
+	if length <= deadZone {
+		return shed.V2{}
+	}
+
+	scaled := shed.Min((length-deadZone)/(1-deadZone), 1)
+	return stick.Scaled(scaled / length)
+}
+
+// =========================================================================
+// ||
+// || Gamepads.
+// ||
+// || The connected gamepads, in slots (0 to 15), like glfw numbers its
+// || joysticks. Devices are plugged in by glfw, or by hand with Connect().
+// ||
+// =========================================================================
+type Gamepads struct {
+	OnConnect    func(G *Gamepad)
+	OnDisconnect func(G *Gamepad)
+
+	pads      [gamepadSlots]*Gamepad
+	mappings  map[string]*GamepadMapping // by GUID
+	live      bool                       // glfw is watching the joysticks
+	replaying bool                       // glfw's devices are ignored while a replay plugs in its own
+}
+
+// Gamepads without hardware: connect devices by hand
+func CreateGamepads() *Gamepads {
+	return &Gamepads{mappings: make(map[string]*GamepadMapping)}
+}
+
+// Gamepads that glfw finds. Only one of these should exist, since glfw has only one joystick callback
+func CreateGlfwGamepads() *Gamepads {
+	P := CreateGamepads()
+	P.live = true
+	P.connectPresent()
+
+	glfw.SetJoystickCallback(func(joy glfw.Joystick, event glfw.PeripheralEvent) {
+		if P.replaying {
+			return
+		}
+
+		switch event {
+		case glfw.Connected:
+			P.connectSlot(int(joy), glfwGamepad{joy})
+		case glfw.Disconnected:
+			if G := P.pads[joy]; G != nil {
+				P.Disconnect(G)
+			}
+		}
+	})
+
+	return P
+}
+
+// Plug in the joysticks glfw knows about
+func (P *Gamepads) connectPresent() {
+	for id := 0; id < gamepadSlots; id++ {
+		if joy := glfw.Joystick(id); joy.Present() {
+			P.connectSlot(id, glfwGamepad{joy})
+		}
+	}
+}
+
+// Plug in a device, in the first free slot. Returns nil if all slots are taken
+func (P *Gamepads) Connect(device GamepadDevice) *Gamepad {
+	for id, G := range P.pads {
+		if G == nil {
+			return P.connectSlot(id, device)
+		}
+	}
+	return nil
+}
+
+func (P *Gamepads) connectSlot(id int, device GamepadDevice) *Gamepad {
+	if old := P.pads[id]; old != nil {
+		P.Disconnect(old)
+	}
+
+	G := &Gamepad{
+		ID:              id,
+		DeadZone:        0.15,
+		TriggerDeadZone: 0.05,
+		device:          device,
+		mapping:         P.mappings[device.GUID()],
+		connected:       true,
+	}
+	P.pads[id] = G
+	G.update()
+	G.prev = G.state // buttons held while plugging in were not pressed
+	G.endFrame()
+
+	if P.OnConnect != nil {
+		P.OnConnect(G)
+	}
+
+	return G
+}
+
+// Unplug a gamepad. Its state is cleared
+func (P *Gamepads) Disconnect(G *Gamepad) {
+	if P.pads[G.ID] != G {
+		return
+	}
+
+	P.pads[G.ID] = nil
+	G.connected = false
+	G.state, G.prev = releasedGamepadState(), releasedGamepadState()
+	G.endFrame()
+
+	if P.OnDisconnect != nil {
+		P.OnDisconnect(G)
+	}
+}
+
+// The gamepad in a slot, or nil
+func (P *Gamepads) Get(id int) *Gamepad {
+	if id < 0 || id >= gamepadSlots {
+		return nil
+	}
+	return P.pads[id]
+}
+
+// The connected gamepads, by slot
+func (P *Gamepads) Connected() []*Gamepad {
+	pads := []*Gamepad{}
+	for _, G := range P.pads {
+		if G != nil {
+			pads = append(pads, G)
+		}
+	}
+	return pads
+}
+
+// The connected gamepad in the lowest slot, or nil. Handy for single player games
+func (P *Gamepads) First() *Gamepad {
+	for _, G := range P.pads {
+		if G != nil {
+			return G
+		}
+	}
+	return nil
+}
+
+// Read the state of every gamepad. Engine.Tick() does this once per frame
+func (P *Gamepads) Update() {
+	for _, G := range P.pads {
+		if G != nil {
+			G.update()
+		}
+	}
+}
+
+// Start a new frame: forget which buttons were pressed and released. Engine loops call it themselves
+func (P *Gamepads) EndFrame() {
+	for _, G := range P.pads {
+		if G != nil {
+			G.endFrame()
+		}
+	}
+}
+
+// Add SDL-style mappings (see ParseGamepadMapping), one per line. Newer mappings replace older ones for the same GUID,
+// and mappings for other operating systems are skipped.
+// Connected gamepads use the new mappings from the next Update() on.
+func (P *Gamepads) AddMappings(text string) error {
+	mappings, err := ParseGamepadMappings(text)
+	if err != nil {
+		return err
+	}
+
+	for i := range mappings {
+		if mappings[i].ForThisPlatform() {
+			P.mappings[mappings[i].GUID] = &mappings[i]
+		}
+	}
+	for _, G := range P.pads {
+		if G != nil {
+			G.mapping = P.mappings[G.GUID()]
+		}
+	}
+
+	if P.live {
+		glfw.UpdateGamepadMappings(text)
+	}
+
+	return nil
+}
+
+// Add the mappings in a file, for instance gamecontrollerdb.txt
+func (P *Gamepads) LoadMappingFile(filename string) error {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return P.AddMappings(string(text))
+}
+
+// How many mappings are known
+func (P *Gamepads) MappingCount() int {
+	return len(P.mappings)
+}
@@ -0,0 +1,286 @@
+package tractor
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// =========================================================================
+// ||
+// || Gamepad Mapping.
+// ||
+// || Which raw button, axis or hat of a device is which gamepad button or
+// || axis. Written in the format of SDL's gamecontrollerdb.txt:
+// ||
+// ||   GUID,name,a:b0,b:b1,leftx:a0,lefty:a1,dpup:h0.1,-leftx:h0.8,...
+// ||
+// || Inputs: bN (button), aN (axis), hN.M (hat N, bit mask M). A "+" or
+// || "-" in front of an axis uses only that half of it, a "~" after one
+// || inverts it. A "+" or "-" in front of a gamepad axis fills only that
+// || half of it.
+// ||
+// =========================================================================
+type GamepadMapping struct {
+	GUID     string
+	Name     string
+	Platform string // empty means all platforms
+
+	buttons map[GamepadButton][]mappingInput
+	axes    map[GamepadAxis][]mappingInput
+}
+
+// One raw input of a device
+type mappingInput struct {
+	kind    byte // 'b', 'a' or 'h'
+	index   int
+	hatMask int
+	half    byte // '+' or '-': only that half of an input axis. 0 for all of it
+	invert  bool
+	target  byte // '+' or '-': the input fills only that half of the output axis. 0 for all of it
+}
+
+var gamepadButtonNames = map[string]GamepadButton{
+	"a":             GamepadA,
+	"b":             GamepadB,
+	"x":             GamepadX,
+	"y":             GamepadY,
+	"back":          GamepadBack,
+	"guide":         GamepadGuide,
+	"start":         GamepadStart,
+	"leftstick":     GamepadLeftThumb,
+	"rightstick":    GamepadRightThumb,
+	"leftshoulder":  GamepadLeftBumper,
+	"rightshoulder": GamepadRightBumper,
+	"dpup":          GamepadDpadUp,
+	"dpright":       GamepadDpadRight,
+	"dpdown":        GamepadDpadDown,
+	"dpleft":        GamepadDpadLeft,
+}
+
+var gamepadAxisNames = map[string]GamepadAxis{
+	"leftx":        GamepadLeftX,
+	"lefty":        GamepadLeftY,
+	"rightx":       GamepadRightX,
+	"righty":       GamepadRightY,
+	"lefttrigger":  GamepadLeftTrigger,
+	"righttrigger": GamepadRightTrigger,
+}
+
+// Parse mappings, one per line. Empty lines and lines starting with # are skipped.
+// Fields SDL knows and we don't (paddles, touchpads, ...) are ignored.
+func ParseGamepadMappings(text string) ([]GamepadMapping, error) {
+	mappings := []GamepadMapping{}
+
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		mapping, err := ParseGamepadMapping(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings, nil
+}
+
+// Parse one mapping
+func ParseGamepadMapping(line string) (GamepadMapping, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 2 || fields[0] == "" {
+		return GamepadMapping{}, fmt.Errorf("a gamepad mapping starts with a GUID and a name: '%s'", line)
+	}
+
+	M := GamepadMapping{
+		GUID:    fields[0],
+		Name:    fields[1],
+		buttons: make(map[GamepadButton][]mappingInput),
+		axes:    make(map[GamepadAxis][]mappingInput),
+	}
+
+	for _, field := range fields[2:] {
+		if field == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(field, ":")
+		if !found {
+			return GamepadMapping{}, fmt.Errorf("'%s' in the mapping of '%s' is not key:value", field, M.Name)
+		}
+
+		if key == "platform" {
+			M.Platform = value
+			continue
+		}
+
+		var target byte
+		if key != "" && (key[0] == '+' || key[0] == '-') {
+			target, key = key[0], key[1:]
+		}
+		if key == "" {
+			return GamepadMapping{}, fmt.Errorf("'%s' in the mapping of '%s' has no name", field, M.Name)
+		}
+
+		button, isButton := gamepadButtonNames[key]
+		axis, isAxis := gamepadAxisNames[key]
+		if !isButton && !isAxis {
+			continue // something we don't support
+		}
+
+		input, err := parseMappingInput(value)
+		if err != nil {
+			return GamepadMapping{}, fmt.Errorf("'%s' in the mapping of '%s': %w", field, M.Name, err)
+		}
+		input.target = target
+
+		if isButton {
+			M.buttons[button] = append(M.buttons[button], input)
+		} else {
+			M.axes[axis] = append(M.axes[axis], input)
+		}
+	}
+
+	return M, nil
+}
+
+func parseMappingInput(value string) (mappingInput, error) {
+	input := mappingInput{}
+
+	if value != "" && (value[0] == '+' || value[0] == '-') {
+		input.half, value = value[0], value[1:]
+	}
+	if strings.HasSuffix(value, "~") {
+		input.invert, value = true, strings.TrimSuffix(value, "~")
+	}
+	if len(value) < 2 {
+		return input, fmt.Errorf("unknown input '%s'", value)
+	}
+
+	input.kind = value[0]
+	var err error
+
+	switch input.kind {
+	case 'b', 'a':
+		input.index, err = strconv.Atoi(value[1:])
+	case 'h':
+		hat, mask, found := strings.Cut(value[1:], ".")
+		if !found {
+			return input, fmt.Errorf("a hat needs a bit mask: '%s'", value)
+		}
+		if input.index, err = strconv.Atoi(hat); err == nil {
+			input.hatMask, err = strconv.Atoi(mask)
+		}
+	default:
+		return input, fmt.Errorf("unknown input '%s'", value)
+	}
+
+	if err != nil {
+		return input, fmt.Errorf("bad input '%s': %w", value, err)
+	}
+	if input.index < 0 || input.hatMask < 0 {
+		return input, fmt.Errorf("bad input '%s': negative numbers are not inputs", value)
+	}
+
+	return input, nil
+}
+
+// Read an input as a number: buttons and hats from 0 to 1, axes from -1 to 1 (0 to 1 for half axes).
+// Inputs the device does not have read as 0
+func (I mappingInput) read(axes []float32, buttons []bool, hats []int) float32 {
+	var value float32
+
+	switch I.kind {
+	case 'b':
+		if I.index >= 0 && I.index < len(buttons) && buttons[I.index] {
+			value = 1
+		}
+	case 'h':
+		if I.index >= 0 && I.index < len(hats) && hats[I.index]&I.hatMask != 0 {
+			value = 1
+		}
+	case 'a':
+		if I.index >= 0 && I.index < len(axes) {
+			value = axes[I.index]
+		}
+		if I.invert {
+			value = -value
+		}
+		switch I.half {
+		case '+':
+			value = max(value, 0)
+		case '-':
+			value = max(-value, 0)
+		}
+	}
+
+	return value
+}
+
+// Does the input only go from 0 to 1?
+func (I mappingInput) oneSided() bool {
+	return I.kind != 'a' || I.half != 0
+}
+
+// SDL's names of the operating systems
+var sdlPlatforms = map[string]string{
+	"windows": "Windows",
+	"darwin":  "Mac OS X",
+	"linux":   "Linux",
+	"android": "Android",
+	"ios":     "iOS",
+}
+
+// Is the mapping meant for the operating system we run on?
+func (M *GamepadMapping) ForThisPlatform() bool {
+	return M.Platform == "" || M.Platform == sdlPlatforms[runtime.GOOS]
+}
+
+// Turn the raw inputs of a device into a gamepad state
+func (M *GamepadMapping) Apply(axes []float32, buttons []bool, hats []int) (GamepadState, bool) {
+	S := releasedGamepadState()
+
+	for button, inputs := range M.buttons {
+		for _, input := range inputs {
+			value := input.read(axes, buttons, hats)
+			if input.kind == 'a' && input.half == 0 {
+				value = max(value, 0) // an axis as a button: pushed forwards
+			}
+			S.Buttons[button] = S.Buttons[button] || value > 0.5
+		}
+	}
+
+	for axis, inputs := range M.axes {
+		var sum float32
+		halves := false
+
+		for _, input := range inputs {
+			value := input.read(axes, buttons, hats)
+
+			switch input.target {
+			case '+':
+				sum += value
+				halves = true
+			case '-':
+				sum -= value
+				halves = true
+			default:
+				if input.oneSided() {
+					value = value*2 - 1 // fill the whole axis
+				}
+				sum += value
+			}
+		}
+
+		if halves && (axis == GamepadLeftTrigger || axis == GamepadRightTrigger) {
+			sum = sum*2 - 1 // the half of a trigger is from released to pulled
+		}
+		S.Axes[axis] = max(-1, min(sum, 1))
+	}
+
+	return S, true
+}
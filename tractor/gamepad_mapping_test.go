@@ -0,0 +1,128 @@
+package tractor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGamepadMappingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string // empty: no error
+	}{
+		{"plain", "0300,pad,a:b0,leftx:a0,dpup:h0.1", ""},
+		{"halves and inversion", "0300,pad,+leftx:-a0~,lefttrigger:+a2", ""},
+		{"unknown keys are ignored", "0300,pad,paddle1:b9,touchpad:b10", ""},
+		{"platform", "0300,pad,a:b0,platform:Linux", ""},
+		{"no name", "0300", "starts with a GUID"},
+		{"no guid", ",pad,a:b0", "starts with a GUID"},
+		{"not key:value", "0300,pad,a", "not key:value"},
+		{"empty key", "0300,pad,:b0", "has no name"},
+		{"only a sign", "0300,pad,+:a0", "has no name"},
+		{"unknown input", "0300,pad,a:x0", "unknown input"},
+		{"short input", "0300,pad,a:b", "unknown input"},
+		{"bad number", "0300,pad,a:bz", "bad input"},
+		{"negative button", "0300,pad,a:b-1", "negative"},
+		{"negative axis", "0300,pad,leftx:a-2", "negative"},
+		{"negative hat", "0300,pad,a:h-1.1", "negative"},
+		{"negative hat mask", "0300,pad,a:h0.-1", "negative"},
+		{"hat without mask", "0300,pad,a:h0", "bit mask"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseGamepadMapping(test.line)
+			switch {
+			case test.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.wantErr != "" && err == nil:
+				t.Fatalf("expected an error containing %q", test.wantErr)
+			case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+				t.Fatalf("error %q does not contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGamepadMappingsLineNumbers(t *testing.T) {
+	_, err := ParseGamepadMappings("# comment\n\n0300,pad,a:b0\n0301,pad,:b0\n")
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Fatalf("expected an error on line 4, got %v", err)
+	}
+}
+
+func TestGamepadMappingApply(t *testing.T) {
+	const mapping = "0300,Test Pad,a:b0,b:b3,leftx:a0,lefty:a1~,lefttrigger:+a2,righttrigger:b2," +
+		"dpup:h0.1,-rightx:h0.8,+rightx:h0.2,dpdown:+a3"
+
+	tests := []struct {
+		name    string
+		axes    []float32
+		buttons []bool
+		hats    []int
+		check   func(S GamepadState) bool
+	}{
+		{"nothing", nil, nil, nil, func(S GamepadState) bool {
+			return !S.Buttons[GamepadA] && S.Axes[GamepadLeftTrigger] == -1 && S.Axes[GamepadRightTrigger] == -1
+		}},
+		{"button", nil, []bool{true}, nil, func(S GamepadState) bool {
+			return S.Buttons[GamepadA] && !S.Buttons[GamepadB]
+		}},
+		{"missing inputs read as released", nil, []bool{false}, nil, func(S GamepadState) bool {
+			return !S.Buttons[GamepadB]
+		}},
+		{"axis", []float32{0.5}, nil, nil, func(S GamepadState) bool {
+			return S.Axes[GamepadLeftX] == 0.5
+		}},
+		{"inverted axis", []float32{0, 0.25}, nil, nil, func(S GamepadState) bool {
+			return S.Axes[GamepadLeftY] == -0.25
+		}},
+		{"half axis as trigger", []float32{0, 0, 1}, nil, nil, func(S GamepadState) bool {
+			return S.Axes[GamepadLeftTrigger] == 1
+		}},
+		{"button as trigger", nil, []bool{false, false, true}, nil, func(S GamepadState) bool {
+			return S.Axes[GamepadRightTrigger] == 1
+		}},
+		{"hat", nil, nil, []int{1}, func(S GamepadState) bool {
+			return S.Buttons[GamepadDpadUp]
+		}},
+		{"hats as axis halves", nil, nil, []int{8}, func(S GamepadState) bool {
+			return S.Axes[GamepadRightX] == -1
+		}},
+		{"axis as button", []float32{0, 0, -1, 0.9}, nil, nil, func(S GamepadState) bool {
+			return S.Buttons[GamepadDpadDown]
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pads := CreateGamepads()
+			if err := pads.AddMappings(mapping); err != nil {
+				t.Fatal(err)
+			}
+
+			fake := &FakeGamepad{DeviceName: "raw", DeviceGUID: "0300", Axes: test.axes, Buttons: test.buttons, Hats: test.hats}
+			G := pads.Connect(fake)
+			pads.Update()
+
+			if !G.IsMapped() || G.Name() != "Test Pad" {
+				t.Fatalf("the fake gamepad is not mapped (mapped %v, name %q)", G.IsMapped(), G.Name())
+			}
+			if S := G.RawState(); !test.check(S) {
+				t.Fatalf("unexpected state %+v", S)
+			}
+		})
+	}
+}
+
+func TestGamepadMappingOtherPlatform(t *testing.T) {
+	pads := CreateGamepads()
+	err := pads.AddMappings("0300,pad,a:b0,platform:Not An OS\n0301,pad,a:b0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pads.MappingCount() != 1 {
+		t.Fatalf("expected 1 mapping, got %d", pads.MappingCount())
+	}
+}
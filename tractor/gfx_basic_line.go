@@ -2,6 +2,8 @@ package tractor
 
 import (
 	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 type BasicLine struct {
@@ -10,6 +12,7 @@ type BasicLine struct {
 	thickness float32
 	color     shed.V4
 	pos       Position
+	Deleted   bool
 }
 
 func CreateBasicLine(x1, y1, x2, y2, thickness float32, camera *Camera, renderer *BasicRectRenderer) *BasicLine {
@@ -54,5 +57,20 @@ func (L *BasicLine) SetPoints(p1, p2 shed.V2) {
 }
 
 func (L *BasicLine) Draw() {
+	if L.Deleted {
+		return
+	}
 	L.renderer.Draw(L.camera.GetMatrix(), L.pos.GetMatrix(), L.color)
 }
+
+// Draw the line relative to a parent transformation, for instance a Node
+func (L *BasicLine) DrawRelative(parent mgl32.Mat3) {
+	if L.Deleted {
+		return
+	}
+	L.renderer.Draw(L.camera.GetMatrix(), parent.Mul3(L.pos.GetMatrix()), L.color)
+}
+
+func (L *BasicLine) IsDeleted() bool {
+	return L.Deleted
+}
@@ -2,6 +2,8 @@ package tractor
 
 import (
 	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 type BasicRect struct {
@@ -32,9 +34,24 @@ func (R *BasicRect) Draw() {
 	if R.Deleted {
 		return
 	}
+	R.drawMatrix(R.GetMatrix())
+}
+
+// Draw the rect relative to a parent transformation, for instance a Node
+func (R *BasicRect) DrawRelative(parent mgl32.Mat3) {
+	if R.Deleted {
+		return
+	}
+	R.drawMatrix(parent.Mul3(R.GetMatrix()))
+}
+
+func (R *BasicRect) drawMatrix(thingMatrix mgl32.Mat3) {
 	camMatrix := R.Camera.GetMatrix()
-	thingMatrix := R.GetMatrix()
 
 	R.Renderer.UniColor = R.Color
 	R.Renderer.Draw(camMatrix, thingMatrix, R.Color)
 }
+
+func (R *BasicRect) IsDeleted() bool {
+	return R.Deleted
+}
@@ -0,0 +1,227 @@
+package tractor
+
+import (
+	"goat/shed"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	ellipseMinSegments = 8    // a full ellipse never has fewer segments than this
+	ellipseMaxSegments = 512  // ... nor more than this
+	ellipseTolerance   = 0.25 // how far (in pixels) the segments may stray from the true curve
+)
+
+// =========================================================================
+// ||
+// || Ellipse.
+// ||
+// || Circles, ellipses, arcs and pie slices, filled and/or outlined.
+// ||
+// || Like BasicRect, the shape is a unit shape (a circle with a diameter of 1)
+// || transformed by its Position, so SetScale(w, h) sets the width and height.
+// || The curve is split into as many segments as it needs to look smooth at
+// || the size it is drawn (unless Segments is set).
+// ||
+// =========================================================================
+type Ellipse struct {
+	Renderer *PrimitiveRenderer
+	Camera   *Camera
+	Deleted  bool
+	Position
+
+	Fill        shed.V4 // Infill color. Fully transparent means no infill
+	Stroke      shed.V4 // Outline color
+	StrokeWidth float32 // Outline thickness, in world units. Zero means no outline. The outline is centered on the edge
+	Segments    int     // Number of segments in a full turn. Zero means it is chosen automatically
+
+	startAngle float32 // radians
+	endAngle   float32 // radians. A whole ellipse when equal to startAngle
+	pie        bool    // connect the ends of an arc to the center
+}
+
+func CreateEllipse(x, y, w, h float32, camera *Camera, renderer *PrimitiveRenderer) *Ellipse {
+	E := Ellipse{
+		Renderer: renderer,
+		Camera:   camera,
+		Fill:     shed.OPAQ_WHITE(),
+		Stroke:   shed.OPAQ_WHITE(),
+	}
+
+	E.SetXY(x, y)
+	E.SetScale(w, h)
+
+	return &E
+}
+
+func (E *Ellipse) Draw() {
+	if E.Deleted {
+		return
+	}
+	E.drawMatrix(E.GetMatrix())
+}
+
+// Draw the ellipse relative to a parent transformation, for instance a Node
+func (E *Ellipse) DrawRelative(parent mgl32.Mat3) {
+	if E.Deleted {
+		return
+	}
+	E.drawMatrix(parent.Mul3(E.GetMatrix()))
+}
+
+func (E *Ellipse) IsDeleted() bool {
+	return E.Deleted
+}
+
+// The triangles are built in world space, so the outline has the same width all the way around.
+func (E *Ellipse) drawMatrix(thingMatrix mgl32.Mat3) {
+	camMatrix := E.Camera.GetMatrix()
+
+	sweep := E.endAngle - E.startAngle
+	whole := sweep == 0 || mgl32.Abs(sweep) >= 2*math.Pi
+	if whole {
+		sweep = 2 * math.Pi
+	}
+
+	n := E.Segments
+	if n <= 0 {
+		n = ellipseSegments(camMatrix, thingMatrix)
+	}
+	n = int(math.Ceil(float64(n) * float64(mgl32.Abs(sweep)) / (2 * math.Pi)))
+	if n < 2 {
+		n = 2
+	}
+
+	//
+	// Points on the curve
+	center := thingMatrix.Mul3x1(mgl32.Vec3{0, 0, 1}).Vec2()
+	curve := make([]mgl32.Vec2, n+1)
+
+	for i := range curve {
+		a := float64(E.startAngle + sweep*float32(i)/float32(n))
+		sin, cos := math.Sincos(a)
+
+		curve[i] = thingMatrix.Mul3x1(mgl32.Vec3{float32(cos) / 2, float32(sin) / 2, 1}).Vec2()
+	}
+	if whole {
+		curve[n] = curve[0] // close the gap left by rounding
+	}
+
+	if E.Fill.C4 > 0 {
+		// A fan around the center. Arcs that are not pie slices fan around their
+		// first point instead, which fills the area between the curve and the chord.
+		hub := center
+		if !whole && !E.pie {
+			hub = curve[0]
+		}
+
+		triangles := make([]shed.V2, 0, 3*n)
+		for i := 0; i < n; i++ {
+			triangles = append(triangles, v2(hub), v2(curve[i]), v2(curve[i+1]))
+		}
+
+		E.Renderer.DrawTriangles(camMatrix, mgl32.Ident3(), triangles, E.Fill)
+	}
+
+	if E.StrokeWidth > 0 && E.Stroke.C4 > 0 {
+		// Pie slices are outlined all the way around, through the center
+		outline := make([]shed.V2, 0, n+2)
+		if !whole && E.pie {
+			outline = append(outline, v2(center))
+		}
+		for _, p := range curve {
+			outline = append(outline, v2(p))
+		}
+
+		style := shed.StrokeStyle{Width: E.StrokeWidth, Join: shed.JoinMiter, Cap: shed.CapButt}
+		triangles := shed.StrokePolyline(outline, whole || E.pie, style)
+
+		E.Renderer.DrawTriangles(camMatrix, mgl32.Ident3(), triangles, E.Stroke)
+	}
+}
+
+// How many segments a full turn needs to stay within ellipseTolerance pixels of the true curve
+func ellipseSegments(camMatrix, thingMatrix mgl32.Mat3) int {
+	w, h := Engine.Backend.FramebufferSize()
+
+	// The largest radius, in pixels
+	m := camMatrix.Mul3(thingMatrix)
+	rx := m.Mul3x1(mgl32.Vec3{0.5, 0, 0}).Vec2()
+	ry := m.Mul3x1(mgl32.Vec3{0, 0.5, 0}).Vec2()
+	toPixels := func(v mgl32.Vec2) float32 {
+		return mgl32.Vec2{v[0] * float32(w) / 2, v[1] * float32(h) / 2}.Len()
+	}
+	radius := shed.Max(toPixels(rx), toPixels(ry))
+
+	if radius <= ellipseTolerance {
+		return ellipseMinSegments
+	}
+
+	// the largest angle a segment can span before its midpoint strays too far from the curve
+	step := 2 * math.Acos(1-ellipseTolerance/float64(radius))
+	n := int(math.Ceil(2 * math.Pi / step))
+
+	if n < ellipseMinSegments {
+		return ellipseMinSegments
+	}
+	if n > ellipseMaxSegments {
+		return ellipseMaxSegments
+	}
+
+	return n
+}
+
+func v2(v mgl32.Vec2) shed.V2 {
+	return shed.V2{X: v[0], Y: v[1]}
+}
+
+// =========================================================================
+// ||
+// || Circle.
+// ||
+// =========================================================================
+type Circle struct {
+	Ellipse
+}
+
+func CreateCircle(x, y, radius float32, camera *Camera, renderer *PrimitiveRenderer) *Circle {
+	return &Circle{*CreateEllipse(x, y, 2*radius, 2*radius, camera, renderer)}
+}
+
+func (C *Circle) SetRadius(radius float32) {
+	C.SetScale(2*radius, 2*radius)
+}
+
+// =========================================================================
+// ||
+// || Arc.
+// ||
+// || A part of an ellipse, from one angle to another (counter-clockwise, in radians).
+// || A pie slice after SetPie(true), otherwise the area between the curve and its chord.
+// ||
+// =========================================================================
+type Arc struct {
+	Ellipse
+}
+
+func CreateArc(x, y, w, h, startAngle, endAngle float32, camera *Camera, renderer *PrimitiveRenderer) *Arc {
+	A := &Arc{*CreateEllipse(x, y, w, h, camera, renderer)}
+	A.SetAngles(startAngle, endAngle)
+
+	return A
+}
+
+func (A *Arc) SetAngles(startAngle, endAngle float32) {
+	A.startAngle = startAngle
+	A.endAngle = endAngle
+}
+
+func (A *Arc) GetAngles() (startAngle, endAngle float32) {
+	return A.startAngle, A.endAngle
+}
+
+// Draw the arc as a pie slice (connected to the center) or not
+func (A *Arc) SetPie(pie bool) {
+	A.pie = pie
+}
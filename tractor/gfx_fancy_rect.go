@@ -0,0 +1,86 @@
+package tractor
+
+import (
+	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A rect with rounded corners, and an outline.
+// Works like BasicRect: the unit quad transformed by a Position.
+type FancyRect struct {
+	Renderer *FancyRectRenderer
+	Camera   *Camera
+	Deleted  bool
+	Position
+
+	Fill        shed.V4 // Infill color
+	Stroke      shed.V4 // Outline color
+	StrokeWidth float32 // Outline thickness, in world units. The outline is drawn inside the edge. Zero means no outline
+	Radius      shed.V4 // Corner radii, in world units: top left, top right, bottom right, bottom left
+}
+
+func CreateFancyRect(x, y, w, h, a float32, camera *Camera, renderer *FancyRectRenderer) *FancyRect {
+
+	R := FancyRect{
+		Camera:   camera,
+		Renderer: renderer,
+		Fill:     shed.OPAQ_WHITE(),
+		Stroke:   shed.OPAQ_WHITE(),
+	}
+
+	R.SetXY(x, y)
+	R.SetScale(w, h)
+	R.SetAngle(a)
+
+	return &R
+}
+
+// Give all four corners the same radius
+func (R *FancyRect) SetRadius(radius float32) {
+	R.Radius = shed.V4{C1: radius, C2: radius, C3: radius, C4: radius}
+}
+
+func (R *FancyRect) Draw() {
+	if R.Deleted {
+		return
+	}
+	R.drawMatrix(R.GetMatrix())
+}
+
+// Draw the rect relative to a parent transformation, for instance a Node
+func (R *FancyRect) DrawRelative(parent mgl32.Mat3) {
+	if R.Deleted {
+		return
+	}
+	R.drawMatrix(parent.Mul3(R.GetMatrix()))
+}
+
+func (R *FancyRect) drawMatrix(thingMatrix mgl32.Mat3) {
+	// The size of the rect in world units, including any scaling done by a parent
+	w := thingMatrix.Col(0).Vec2().Len()
+	h := thingMatrix.Col(1).Vec2().Len()
+
+	// A corner cannot be rounder than half the shortest side
+	maxRadius := shed.Min(w, h) / 2
+	clampRadius := func(r float32) float32 {
+		return mgl32.Clamp(r, 0, maxRadius)
+	}
+
+	R.Renderer.UniSize = shed.V2{X: w, Y: h}
+	R.Renderer.UniRadius = shed.V4{
+		C1: clampRadius(R.Radius.C1),
+		C2: clampRadius(R.Radius.C2),
+		C3: clampRadius(R.Radius.C3),
+		C4: clampRadius(R.Radius.C4),
+	}
+	R.Renderer.UniStrokeWidth = mgl32.Clamp(R.StrokeWidth, 0, maxRadius*2)
+	R.Renderer.UniFillColor = R.Fill
+	R.Renderer.UniStrokeColor = R.Stroke
+
+	R.Renderer.Draw(R.Camera.GetMatrix(), thingMatrix)
+}
+
+func (R *FancyRect) IsDeleted() bool {
+	return R.Deleted
+}
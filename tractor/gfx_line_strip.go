@@ -0,0 +1,113 @@
+package tractor
+
+import (
+	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// =========================================================================
+// ||
+// || Line Strip.
+// ||
+// || A thick line through a list of points, drawn as one mesh. Unlike a row
+// || of BasicLines, the segments don't overlap, so translucent lines have
+// || the same color everywhere, corners included.
+// ||
+// || The edges fade out over Feather pixels, which anti-aliases them.
+// ||
+// =========================================================================
+type LineStrip struct {
+	Renderer *LineStripRenderer
+	Camera   *Camera
+	Deleted  bool
+	Position
+
+	Points     []shed.V2
+	Colors     []shed.V4 // One color per point, blended along the line. Used instead of Color when there is one for every point
+	Color      shed.V4
+	Width      float32 // in the units of Points
+	Join       shed.LineJoin
+	Cap        shed.LineCap
+	MiterLimit float32 // longest allowed miter, in line widths. Zero means 4
+	Closed     bool    // join the last point to the first
+
+	Dashes     []float32 // lengths of the dashes and the gaps between them, alternating. An odd list is repeated. Empty means a solid line
+	DashOffset float32   // how far into the dash pattern the line starts
+	Feather    float32   // width of the anti-aliased edges, in pixels. Zero means 1 pixel. Negative means hard edges
+}
+
+func CreateLineStrip(points []shed.V2, width float32, camera *Camera, renderer *LineStripRenderer) *LineStrip {
+	L := LineStrip{
+		Renderer: renderer,
+		Camera:   camera,
+		Points:   points,
+		Color:    shed.OPAQ_WHITE(),
+		Width:    width,
+		Join:     shed.JoinMiter,
+		Cap:      shed.CapButt,
+	}
+
+	L.SetScale(1, 1)
+
+	return &L
+}
+
+func (L *LineStrip) Draw() {
+	if L.Deleted {
+		return
+	}
+	L.drawMatrix(L.GetMatrix())
+}
+
+// Draw the line relative to a parent transformation, for instance a Node
+func (L *LineStrip) DrawRelative(parent mgl32.Mat3) {
+	if L.Deleted {
+		return
+	}
+	L.drawMatrix(parent.Mul3(L.GetMatrix()))
+}
+
+func (L *LineStrip) IsDeleted() bool {
+	return L.Deleted
+}
+
+func (L *LineStrip) drawMatrix(thingMatrix mgl32.Mat3) {
+	if len(L.Points) == 0 || L.Width <= 0 {
+		return
+	}
+
+	camMatrix := L.Camera.GetMatrix()
+	ppu := pixelsPerUnit(camMatrix.Mul3(thingMatrix))
+
+	feather := L.Feather
+	if feather == 0 {
+		feather = 1
+	}
+
+	L.Renderer.Draw(camMatrix, thingMatrix, L.Mesh(feather/ppu, 0.25/ppu))
+}
+
+// Build the triangles of the line. feather and tolerance (how far round joins
+// and caps may stray from a true circle) are in the units of Points.
+// Every three vertices make a triangle.
+func (L *LineStrip) Mesh(feather, tolerance float32) []LineVertex {
+	colors := L.Colors
+	if len(colors) != len(L.Points) {
+		colors = make([]shed.V4, len(L.Points))
+		for i := range colors {
+			colors[i] = L.Color
+		}
+	}
+
+	return shed.StrokeColoredPolyline(L.Points, colors, L.Closed, shed.StrokeStyle{
+		Width:      L.Width,
+		Join:       L.Join,
+		Cap:        L.Cap,
+		MiterLimit: L.MiterLimit,
+		Tolerance:  tolerance,
+		Feather:    feather,
+		Dashes:     L.Dashes,
+		DashOffset: L.DashOffset,
+	})
+}
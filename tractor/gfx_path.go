@@ -0,0 +1,108 @@
+package tractor
+
+import (
+	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// A vector path (see shed.Path), filled and/or stroked, and transformed by a Position.
+// The path is in its own units: SetScale(1, 1) draws it at its original size.
+// SVG paths have y pointing down, and are flipped right side up by CreateSVGPathShape.
+type PathShape struct {
+	Renderer *PrimitiveRenderer
+	Camera   *Camera
+	Deleted  bool
+	Position
+
+	Path   *shed.Path
+	Fill   shed.V4          // Infill color. Fully transparent means no infill
+	Stroke shed.V4          // Outline color
+	Style  shed.StrokeStyle // Outline width (in path units), joins and caps. A width of zero means no outline
+}
+
+func CreatePathShape(x, y float32, path *shed.Path, camera *Camera, renderer *PrimitiveRenderer) *PathShape {
+	S := PathShape{
+		Renderer: renderer,
+		Camera:   camera,
+		Path:     path,
+		Fill:     shed.OPAQ_WHITE(),
+		Stroke:   shed.OPAQ_WHITE(),
+		Style:    shed.StrokeStyle{Join: shed.JoinMiter, Cap: shed.CapButt},
+	}
+
+	S.SetXY(x, y)
+	S.SetScale(1, 1)
+
+	return &S
+}
+
+// Parse an SVG path (the d attribute of a <path>), and flip it so it is not upside down.
+// (x, y) is where the origin of the SVG ends up.
+func CreateSVGPathShape(x, y float32, d string, camera *Camera, renderer *PrimitiveRenderer) (*PathShape, error) {
+	path, err := shed.ParseSVGPath(d)
+	if err != nil {
+		return nil, err
+	}
+
+	S := CreatePathShape(x, y, path, camera, renderer)
+	S.AllowNegativeScale(true)
+	S.SetScale(1, -1)
+
+	return S, nil
+}
+
+func (S *PathShape) Draw() {
+	if S.Deleted {
+		return
+	}
+	S.drawMatrix(S.GetMatrix())
+}
+
+// Draw the path relative to a parent transformation, for instance a Node
+func (S *PathShape) DrawRelative(parent mgl32.Mat3) {
+	if S.Deleted {
+		return
+	}
+	S.drawMatrix(parent.Mul3(S.GetMatrix()))
+}
+
+func (S *PathShape) IsDeleted() bool {
+	return S.Deleted
+}
+
+func (S *PathShape) drawMatrix(thingMatrix mgl32.Mat3) {
+	if S.Path == nil {
+		return
+	}
+
+	camMatrix := S.Camera.GetMatrix()
+
+	// Flatten curves to within a quarter of a pixel
+	tolerance := float32(0.25) / pixelsPerUnit(camMatrix.Mul3(thingMatrix))
+
+	if S.Fill.C4 > 0 {
+		S.Renderer.DrawTriangles(camMatrix, thingMatrix, S.Path.Fill(tolerance), S.Fill)
+	}
+
+	if S.Style.Width > 0 && S.Stroke.C4 > 0 {
+		style := S.Style
+		style.Tolerance = tolerance
+		S.Renderer.DrawTriangles(camMatrix, thingMatrix, S.Path.Stroke(style, tolerance), S.Stroke)
+	}
+}
+
+// How many pixels one unit (in the coordinate system that trMatrix transforms into NDC) covers.
+// The larger of the two directions.
+func pixelsPerUnit(trMatrix mgl32.Mat3) float32 {
+	w, h := Engine.Backend.FramebufferSize()
+
+	x := mgl32.Vec2{trMatrix[0] * float32(w) / 2, trMatrix[1] * float32(h) / 2}.Len()
+	y := mgl32.Vec2{trMatrix[3] * float32(w) / 2, trMatrix[4] * float32(h) / 2}.Len()
+
+	if p := shed.Max(x, y); p > 0 {
+		return p
+	}
+
+	return 1
+}
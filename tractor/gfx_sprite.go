@@ -2,6 +2,8 @@ package tractor
 
 import (
 	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 // Draw a sprite on screen
@@ -14,6 +16,8 @@ type Sprite struct {
 	UniColor     shed.V4
 	UniColorMix  float32
 
+	Animation *Animation // Optional. Update() advances it, and shows its current frame
+
 	Deleted bool
 }
 
@@ -30,8 +34,19 @@ func (E *Sprite) Draw() {
 	if E.Deleted {
 		return
 	}
+	E.drawMatrix(E.GetMatrix())
+}
+
+// Draw the sprite relative to a parent transformation, for instance a Node
+func (E *Sprite) DrawRelative(parent mgl32.Mat3) {
+	if E.Deleted {
+		return
+	}
+	E.drawMatrix(parent.Mul3(E.GetMatrix()))
+}
+
+func (E *Sprite) drawMatrix(thingMatrix mgl32.Mat3) {
 	camMatrix := E.Camera.GetMatrix()
-	thingMatrix := E.GetMatrix()
 
 	E.Renderer.UniSubTexPos = E.UniSubTexPos
 	E.Renderer.UniColorMix = E.UniColorMix
@@ -39,14 +54,24 @@ func (E *Sprite) Draw() {
 	E.Renderer.Draw(camMatrix, thingMatrix)
 }
 
+func (E *Sprite) IsDeleted() bool {
+	return E.Deleted
+}
+
 func (E *Sprite) Update() {
 	if E.Deleted {
 		return
 	}
+
+	if E.Animation != nil {
+		E.Animation.Update()
+		E.UniSubTexPos = E.Animation.CurrentFrame().SubTexPos
+	}
 }
 
+// The clone gets its own copy of the animation, if any
 func (E *Sprite) Clone() *Sprite {
-	return &Sprite{
+	S := &Sprite{
 		Renderer:     E.Renderer,
 		Camera:       E.Camera,
 		Position:     E.Position,
@@ -54,4 +79,10 @@ func (E *Sprite) Clone() *Sprite {
 		UniColor:     E.UniColor,
 		UniColorMix:  E.UniColorMix,
 	}
+
+	if E.Animation != nil {
+		S.Animation = E.Animation.Clone()
+	}
+
+	return S
 }
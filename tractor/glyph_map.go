@@ -0,0 +1,92 @@
+package tractor
+
+import (
+	"goat/shed"
+	"unicode"
+)
+
+// =========================================================================
+// ||
+// || Glyph Map
+// ||
+// || Maps runes to the subtextures (glyphs) of a texture atlas.
+// || Several runes may map to the same glyph, for instance to make a
+// || font case insensitive, or to show all unknown characters as "?"
+// ||
+// =========================================================================
+type GlyphMap struct {
+	glyphs   map[rune]string // rune => name of subtexture
+	FoldCase bool            // If a rune is not mapped, try its upper- and lowercase versions
+	Fallback rune            // Used when a rune is not mapped at all. Zero means "skip the character"
+}
+
+func CreateGlyphMap() *GlyphMap {
+	return &GlyphMap{
+		glyphs: make(map[rune]string),
+	}
+}
+
+// Create a glyph map from the "runes" attributes of an atlas' subtextures:
+//
+//	<SubTexture name="letterA.png" runes="Aa" .../>
+func CreateGlyphMapFromAtlas(atlas *shed.AtlasDescriptor) *GlyphMap {
+	G := CreateGlyphMap()
+
+	for _, sub := range atlas.SubTextures {
+		G.Map(sub.Name, []rune(sub.Runes)...)
+	}
+
+	return G
+}
+
+// Glyph map for the numerals in the kenney space shooter sheet (sheet.xml)
+// Useful for score counters
+func CreateKenneyNumeralGlyphMap() *GlyphMap {
+	G := CreateGlyphMap()
+
+	for r := '0'; r <= '9'; r++ {
+		G.Map("numeral"+string(r)+".png", r)
+	}
+	G.Map("numeralX.png", 'x', 'X', '*')
+
+	return G
+}
+
+// Map one or more runes to the given subtexture
+func (G *GlyphMap) Map(subTexName string, runes ...rune) {
+	for _, r := range runes {
+		G.glyphs[r] = subTexName
+	}
+}
+
+// Remove the mapping for the given runes
+func (G *GlyphMap) Unmap(runes ...rune) {
+	for _, r := range runes {
+		delete(G.glyphs, r)
+	}
+}
+
+// Find the subtexture for a given rune, honoring FoldCase and Fallback
+func (G *GlyphMap) Lookup(r rune) (subTexName string, found bool) {
+
+	if name, found := G.glyphs[r]; found {
+		return name, true
+	}
+
+	if G.FoldCase {
+		if name, found := G.glyphs[unicode.ToUpper(r)]; found {
+			return name, true
+		}
+		if name, found := G.glyphs[unicode.ToLower(r)]; found {
+			return name, true
+		}
+	}
+
+	if G.Fallback != 0 && G.Fallback != r {
+		if name, found := G.glyphs[G.Fallback]; found {
+			return name, true
+		}
+	}
+
+	return "", false
+}
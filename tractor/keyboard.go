@@ -0,0 +1,210 @@
+package tractor
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const keyCount = int(glfw.KeyLast) + 1
+
+// Gets every character that is typed, with the keyboard layout, shift, dead keys and input methods applied
+type TextHandler func(char rune)
+
+// =========================================================================
+// ||
+// || Keyboard.
+// ||
+// || Keeps track of the keys, so they can be polled, and passes the events
+// || on to a handler. Like the Mouse, "pressed" and "released" are
+// || collected over a frame: EndFrame() starts a new one.
+// ||
+// || Typed text comes separately, one character at a time: a key is not a
+// || character ("a" with shift is "A", and one character can take several
+// || keys). While a TextEdit has the focus, it gets the characters and the
+// || keys, and the handlers and the key state don't, except for the keys
+// || the text edit passes on (TextEdit.PassKeys).
+// ||
+// =========================================================================
+type Keyboard struct {
+	window      *glfw.Window // nil when headless
+	handler     KeyboardHandler
+	textHandler TextHandler
+	focus       *TextEdit
+	clipboard   string // when headless
+
+	tap       func(kev KeyEvent) // sees every event. See InputRecorder
+	tapChar   func(char rune)    // sees every character
+	replaying bool               // the window is ignored while a replay feeds the events
+
+	down     [keyCount]bool
+	pressed  [keyCount]bool
+	released [keyCount]bool
+}
+
+// Listen to the keyboard of a window. Without a window (headless) the keyboard only knows what it is fed (see Feed)
+func CreateKeyboard(window *glfw.Window) *Keyboard {
+	K := &Keyboard{window: window}
+
+	if window == nil {
+		return K
+	}
+
+	window.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		kev := CreateKeyEvent(KeyCode(key), action, mods)
+		kev.ScanCode = scancode
+		if !K.replaying {
+			K.Feed(kev)
+		}
+	})
+
+	window.SetCharCallback(func(_ *glfw.Window, char rune) {
+		if !K.replaying {
+			K.FeedChar(char)
+		}
+	})
+
+	return K
+}
+
+// The event glfw would send for a key
+func CreateKeyEvent(key KeyCode, action glfw.Action, mods glfw.ModifierKey) KeyEvent {
+	k := glfw.Key(key)
+
+	// ModCapsLock ModifierKey = C.GLFW_MOD_CAPS_LOCK
+	// ModNumLock  ModifierKey = C.GLFW_MOD_NUM_LOCK
+
+	return KeyEvent{
+		Key:      key,
+		Pressed:  action == glfw.Press,
+		Repeated: action == glfw.Repeat,
+		Released: action == glfw.Release,
+		Ctrl:     mods&glfw.ModControl != 0,
+		Shift:    mods&glfw.ModShift != 0,
+		Alt:      mods&glfw.ModAlt != 0,
+		Gui:      mods&glfw.ModSuper != 0,
+		L_Ctrl:   k == glfw.KeyLeftControl,
+		R_Ctrl:   k == glfw.KeyRightControl,
+		L_Alt:    k == glfw.KeyLeftAlt,
+		R_Alt:    k == glfw.KeyRightAlt,
+		AltGr:    k == glfw.KeyRightAlt,
+		L_Shift:  k == glfw.KeyLeftShift,
+		R_Shift:  k == glfw.KeyRightShift,
+		L_Gui:    k == glfw.KeyLeftSuper,
+		R_Gui:    k == glfw.KeyRightSuper,
+
+		Up:    k == glfw.KeyUp,
+		Down:  k == glfw.KeyDown,
+		Left:  k == glfw.KeyLeft,
+		Right: k == glfw.KeyRight,
+
+		Escape: k == glfw.KeyEscape,
+	}
+}
+
+// Call kh for every key event. Replaces the previous handler. nil stops the events
+func (K *Keyboard) Handle(kh KeyboardHandler) {
+	K.handler = kh
+}
+
+// Process an event as if it came from the window. Useful for tests and replays
+func (K *Keyboard) Feed(kev KeyEvent) {
+	if K.tap != nil {
+		K.tap(kev)
+	}
+
+	// Typing is not playing: the text edit gets the keys, and the game does not know they are down.
+	// Keys that were down before the text edit got the focus may still go up, or they would stick
+	if K.focus != nil && !K.focus.Passes(kev.Key) && !(kev.Released && K.IsDown(kev.Key)) {
+		K.focus.HandleKey(&kev)
+		return
+	}
+
+	if validKey(kev.Key) {
+		switch {
+		case kev.Pressed:
+			K.down[kev.Key], K.pressed[kev.Key] = true, true
+		case kev.Released:
+			K.down[kev.Key], K.released[kev.Key] = false, true
+		}
+	}
+
+	if K.handler != nil {
+		K.handler(&kev)
+	}
+}
+
+// Call th for every typed character. Replaces the previous handler. nil stops the characters
+func (K *Keyboard) HandleText(th TextHandler) {
+	K.textHandler = th
+}
+
+// Process a typed character as if it came from the window
+func (K *Keyboard) FeedChar(char rune) {
+	if K.tapChar != nil {
+		K.tapChar(char)
+	}
+
+	if K.focus != nil {
+		K.focus.HandleChar(char)
+		return
+	}
+	if K.textHandler != nil {
+		K.textHandler(char)
+	}
+}
+
+// Send the typing to a text edit. nil sends it back to the handlers
+func (K *Keyboard) Focus(E *TextEdit) {
+	K.focus = E
+}
+
+// The text edit that gets the typing, or nil
+func (K *Keyboard) Focused() *TextEdit {
+	return K.focus
+}
+
+// The text on the system clipboard. Headless keyboards have a clipboard of their own
+func (K *Keyboard) Clipboard() string {
+	if K.window == nil {
+		return K.clipboard
+	}
+	return K.window.GetClipboardString()
+}
+
+func (K *Keyboard) SetClipboard(text string) {
+	if K.window == nil {
+		K.clipboard = text
+		return
+	}
+	K.window.SetClipboardString(text)
+}
+
+// Start a new frame: forget which keys were pressed and released
+func (K *Keyboard) EndFrame() {
+	K.pressed = [keyCount]bool{}
+	K.released = [keyCount]bool{}
+}
+
+// Let go of every key, without events
+func (K *Keyboard) reset() {
+	K.down = [keyCount]bool{}
+	K.EndFrame()
+}
+
+// Is the key held down?
+func (K *Keyboard) IsDown(key KeyCode) bool {
+	return validKey(key) && K.down[key]
+}
+
+// Did the key go down this frame? Repeats don't count
+func (K *Keyboard) WasPressed(key KeyCode) bool {
+	return validKey(key) && K.pressed[key]
+}
+
+// Did the key go up this frame?
+func (K *Keyboard) WasReleased(key KeyCode) bool {
+	return validKey(key) && K.released[key]
+}
+
+func validKey(key KeyCode) bool {
+	return int(key) >= 0 && int(key) < keyCount
+}
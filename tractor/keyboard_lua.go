@@ -0,0 +1,142 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+
+	lua "github.com/yuin/gopher-lua"
+	luar "layeh.com/gopher-luar"
+)
+
+// Make typing available to a lua script:
+//
+//	local name = TextEdit{text = "Player", maxLength = 16, onSubmit = function(text) Log("hello %s", text) end}
+//	name:Focus()                                -- the typing goes to the text edit, until name:Blur()
+//	local text, cursor = name:Text(), name:Cursor()
+//	local from, to = name:Selection()
+//	OnText(function(char) Log("typed %s", char) end)   -- while no text edit has the focus
+//	SetClipboard(Clipboard() .. "!")
+//
+// Positions count characters, not bytes. Lua errors in onSubmit, onChange and OnText are passed to onError.
+// If it is nil, they panic.
+//
+// Like Mouse.ExportToLua, the text handler and the focus the script sets are kept aside until activate() is called.
+func (K *Keyboard) ExportToLua(L *lua.LState, onError func(error)) (activate func()) {
+
+	S := &luaTyping{keyboard: K}
+
+	call := func(fn *lua.LFunction, args ...lua.LValue) {
+		err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, args...)
+		if err != nil {
+			if onError == nil {
+				shed.GlPanic(err)
+			}
+			onError(err)
+		}
+	}
+
+	callback := func(opts *lua.LTable, name string) func(text string) {
+		switch fn := opts.RawGetString(name).(type) {
+		case *lua.LFunction:
+			return func(text string) { call(fn, lua.LString(text)) }
+		case *lua.LNilType:
+			return nil
+		default:
+			L.ArgError(1, fmt.Sprintf("'%s' must be a function", name))
+			return nil
+		}
+	}
+
+	L.SetGlobal("TextEdit", L.NewFunction(func(L *lua.LState) int {
+		opts := L.OptTable(1, L.NewTable())
+
+		E := CreateTextEdit("")
+		E.MaxLength = int(lua.LVAsNumber(opts.RawGetString("maxLength")))
+		E.SetText(lua.LVAsString(opts.RawGetString("text")))
+		E.OnChange = callback(opts, "onChange")
+		E.OnSubmit = callback(opts, "onSubmit")
+		E.Clipboard = K
+
+		L.Push(luar.New(L, &LuaTextEdit{edit: E, typing: S}))
+		return 1
+	}))
+
+	L.SetGlobal("OnText", L.NewFunction(func(L *lua.LState) int {
+		S.textHandler = nil
+		if fn := L.OptFunction(1, nil); fn != nil {
+			S.textHandler = func(char rune) { call(fn, lua.LString(string(char))) }
+		}
+		S.install()
+		return 0
+	}))
+
+	L.SetGlobal("Clipboard", luar.New(L, K.Clipboard))
+	L.SetGlobal("SetClipboard", luar.New(L, K.SetClipboard))
+
+	return func() {
+		S.active = true
+		S.install()
+	}
+}
+
+// What a lua state wants of the keyboard. It gets it once it is active
+type luaTyping struct {
+	keyboard    *Keyboard
+	active      bool
+	textHandler TextHandler
+	focus       *TextEdit
+}
+
+func (S *luaTyping) install() {
+	if S.active {
+		S.keyboard.HandleText(S.textHandler)
+		S.keyboard.Focus(S.focus)
+	}
+}
+
+// A text edit, as lua scripts see it
+type LuaTextEdit struct {
+	edit   *TextEdit
+	typing *luaTyping
+}
+
+func (T *LuaTextEdit) Text() string {
+	return T.edit.Text()
+}
+
+func (T *LuaTextEdit) SetText(text string) {
+	T.edit.SetText(text)
+}
+
+func (T *LuaTextEdit) Cursor() int {
+	return T.edit.Cursor()
+}
+
+func (T *LuaTextEdit) SetCursor(pos int) {
+	T.edit.SetCursor(pos, false)
+}
+
+func (T *LuaTextEdit) Selection() (int, int) {
+	return T.edit.Selection()
+}
+
+func (T *LuaTextEdit) SelectAll() {
+	T.edit.SelectAll()
+}
+
+func (T *LuaTextEdit) Focus() {
+	T.typing.focus = T.edit
+	T.typing.install()
+}
+
+// Give the typing back to the script, if the text edit has it
+func (T *LuaTextEdit) Blur() {
+	if T.typing.focus == T.edit {
+		T.typing.focus = nil
+		T.typing.install()
+	}
+}
+
+func (T *LuaTextEdit) IsFocused() bool {
+	return T.typing.focus == T.edit
+}
@@ -0,0 +1,55 @@
+package tractor
+
+import (
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// A script that fails to load must not take the mouse and keyboard from the one that runs
+func TestLuaInputWaitsForActivation(t *testing.T) {
+	M, K := CreateMouse(nil), CreateKeyboard(nil)
+
+	load := func(script string) (*lua.LState, func(), error) {
+		L := lua.NewState()
+		activateMouse := M.ExportToLua(L, nil, nil)
+		activateKeyboard := K.ExportToLua(L, nil)
+		err := L.DoString(script)
+		return L, func() { activateMouse(); activateKeyboard() }, err
+	}
+
+	running, activate, err := load(`
+		clicks, typed = 0, ""
+		OnMouse(function(ev) if ev.Pressed then clicks = clicks + 1 end end)
+		OnText(function(c) typed = typed .. c end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activate()
+	defer running.Close()
+
+	broken, _, err := load(`
+		OnMouse(function(ev) end)
+		OnText(function(c) end)
+		TextEdit{}:Focus()
+		error("oops")
+	`)
+	if err == nil {
+		t.Fatal("the broken script loaded")
+	}
+	broken.Close()
+
+	M.Feed(MouseEvent{Button: MouseLeft, Pressed: true})
+	K.FeedChar('x')
+
+	if clicks := lua.LVAsNumber(running.GetGlobal("clicks")); clicks != 1 {
+		t.Fatalf("the running script saw %v clicks", clicks)
+	}
+	if typed := lua.LVAsString(running.GetGlobal("typed")); typed != "x" {
+		t.Fatalf("the running script saw %q typed", typed)
+	}
+	if K.Focused() != nil {
+		t.Fatal("the broken script took the focus")
+	}
+}
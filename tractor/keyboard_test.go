@@ -0,0 +1,84 @@
+package tractor
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+func TestFocusedTextEditKeepsKeysFromTheGame(t *testing.T) {
+	K := CreateKeyboard(nil)
+	A := CreateActions(K, nil, nil)
+	A.Bind("jump", KeyBinding(KeyCode(KeySpace)))
+
+	handled := []KeyCode{}
+	K.Handle(func(kev *KeyEvent) { handled = append(handled, kev.Key) })
+
+	press := func(key glfw.Key) { K.Feed(CreateKeyEvent(KeyCode(key), glfw.Press, 0)) }
+	release := func(key glfw.Key) { K.Feed(CreateKeyEvent(KeyCode(key), glfw.Release, 0)) }
+
+	press(KeyW) // held before typing starts
+
+	E := CreateTextEdit("")
+	K.Focus(E)
+
+	press(KeySpace)
+	K.FeedChar(' ')
+	press(KeyA)
+	K.FeedChar('a')
+	A.Update()
+
+	if K.IsDown(KeyCode(KeySpace)) || K.WasPressed(KeyCode(KeyA)) || A.Pressed("jump") {
+		t.Fatal("keys typed into the text edit reached the game")
+	}
+	if E.Text() != " a" {
+		t.Fatalf("the text edit got %q", E.Text())
+	}
+
+	release(KeySpace)
+	release(KeyA)
+	release(KeyW) // held before the focus: it must not stick
+	press(KeyEscape)
+
+	if K.IsDown(KeyCode(KeyW)) {
+		t.Fatal("a key held before the focus is stuck down")
+	}
+	if !K.IsDown(KeyCode(KeyEscape)) {
+		t.Fatal("escape did not pass the text edit")
+	}
+
+	expected := []KeyCode{KeyCode(KeyW), KeyCode(KeyW), KeyCode(KeyEscape)}
+	if len(handled) != len(expected) {
+		t.Fatalf("the handler got %v, expected %v", handled, expected)
+	}
+	for i := range expected {
+		if handled[i] != expected[i] {
+			t.Fatalf("the handler got %v, expected %v", handled, expected)
+		}
+	}
+}
+
+// Games that ask Controls.KeyPressed() must not see the typing either
+func TestFocusedTextEditKeepsKeysFromKeyPressed(t *testing.T) {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 0)
+	K := W.Controls.Keyboard()
+
+	E := CreateTextEdit("")
+	K.Focus(E)
+	K.Feed(CreateKeyEvent(KeyCode(KeyA), glfw.Press, 0))
+	K.Feed(CreateKeyEvent(KeyCode(KeyEscape), glfw.Press, 0))
+
+	if W.Controls.KeyPressed(KeyCode(KeyA)) {
+		t.Fatal("a key typed into the text edit reached KeyPressed")
+	}
+	if !W.Controls.KeyPressed(KeyCode(KeyEscape)) {
+		t.Fatal("escape did not pass the text edit")
+	}
+
+	K.Focus(nil)
+	K.Feed(CreateKeyEvent(KeyCode(KeyA), glfw.Release, 0))
+	K.Feed(CreateKeyEvent(KeyCode(KeyA), glfw.Press, 0))
+	if !W.Controls.KeyPressed(KeyCode(KeyA)) {
+		t.Fatal("without the focus, KeyPressed should see the key")
+	}
+}
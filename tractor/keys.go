@@ -45,7 +45,8 @@ type KeyEvent struct {
 	Escape bool
 }
 
-// Get the "name" of the key as a string
+// Get the "name" of the key as a string. This is not what the key types:
+// for text, use Controls.HandleText
 func (kev *KeyEvent) Str() string {
     if kev.str != "" {
         return kev.str
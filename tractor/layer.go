@@ -0,0 +1,215 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+	"sort"
+)
+
+// Anything that can draw itself
+type Drawable interface {
+	Draw()
+}
+
+// Drawables that can be marked as deleted.
+// Deleted objects are removed from their layers the next time the layer is drawn.
+type Deletable interface {
+	IsDeleted() bool
+}
+
+// The default layers, in the order they are drawn (see goals.md)
+const (
+	LayerBackground = "background"
+	LayerBgSprites  = "bgSprites"
+	LayerFgSprites  = "fgSprites"
+	LayerGeometries = "geometries"
+	LayerText       = "text"
+)
+
+// =========================================================================
+// ||
+// || Layer.
+// ||
+// || A retained-mode list of things to draw.
+// || Engine.Loop() draws all layers, lowest Z first, after the loop function
+// || has run. Within a layer, things are drawn in the order they were added.
+// ||
+// =========================================================================
+type Layer struct {
+	Name   string
+	Z      int  // Layers with lower Z are drawn first
+	Hidden bool // Hidden layers are not drawn, but they keep their contents
+
+	items []Drawable
+
+	// Things drawn by Draw() may change the layer. The changes wait until all has been drawn
+	drawing bool
+	added   []Drawable // added during Draw(). Drawn from the next frame on
+	removed []Drawable // removed from items during Draw()
+	cleared bool       // cleared during Draw()
+}
+
+// Add something to the layer
+func (L *Layer) Add(items ...Drawable) {
+	if L.drawing {
+		L.added = append(L.added, items...)
+		return
+	}
+
+	L.items = append(L.items, items...)
+}
+
+// Remove something from the layer. Marking it as Deleted works too.
+func (L *Layer) Remove(item Drawable) {
+	if L.drawing {
+		if i := indexOf(L.added, item); i >= 0 {
+			L.added = append(L.added[:i], L.added[i+1:]...)
+		} else {
+			L.removed = append(L.removed, item)
+		}
+		return
+	}
+
+	if i := indexOf(L.items, item); i >= 0 {
+		L.items = append(L.items[:i], L.items[i+1:]...)
+	}
+}
+
+// Remove everything from the layer
+func (L *Layer) Clear() {
+	if L.drawing {
+		L.cleared = true
+		L.added = L.added[:0]
+		return
+	}
+
+	L.items = L.items[:0]
+}
+
+// Number of things in the layer
+func (L *Layer) Len() int {
+	if L.cleared {
+		return len(L.added)
+	}
+
+	return len(L.items) - len(L.removed) + len(L.added)
+}
+
+// Draw everything in the layer, and drop the things that have been deleted.
+// Things added while drawing are drawn from the next frame on. Things removed while drawing are not drawn.
+func (L *Layer) Draw() {
+	L.drawing = true
+	for _, d := range L.items {
+		if L.Hidden || L.cleared || isDeleted(d) || indexOf(L.removed, d) >= 0 {
+			continue
+		}
+		d.Draw()
+	}
+	L.drawing = false
+
+	kept := L.items[:0]
+	for _, d := range L.items {
+		if L.cleared || isDeleted(d) {
+			continue
+		}
+		if i := indexOf(L.removed, d); i >= 0 {
+			L.removed = append(L.removed[:i], L.removed[i+1:]...)
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	// let go of the deleted things, so they can be garbage collected
+	for i := len(kept); i < len(L.items); i++ {
+		L.items[i] = nil
+	}
+
+	L.items = append(kept, L.added...)
+	clear(L.added)
+	clear(L.removed)
+	L.added = L.added[:0]
+	L.removed = L.removed[:0]
+	L.cleared = false
+}
+
+func isDeleted(d Drawable) bool {
+	del, ok := d.(Deletable)
+	return ok && del.IsDeleted()
+}
+
+func indexOf(items []Drawable, item Drawable) int {
+	for i, d := range items {
+		if d == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// Create a layer with the given name and z-index.
+// If the layer already exists, its z-index is updated.
+func (W *EngineType) CreateLayer(name string, z int) *Layer {
+	if layer, found := W.GetLayer(name); found {
+		layer.Z = z
+		W.sortLayers()
+		return layer
+	}
+
+	layer := &Layer{Name: name, Z: z}
+	W.layers = append(W.layers, layer)
+	W.sortLayers()
+
+	return layer
+}
+
+// Get a layer by name
+func (W *EngineType) GetLayer(name string) (*Layer, bool) {
+	for _, layer := range W.layers {
+		if layer.Name == name {
+			return layer, true
+		}
+	}
+
+	return nil, false
+}
+
+// Get a layer by name. Panic if it does not exist
+func (W *EngineType) MustGetLayer(name string) *Layer {
+	layer, found := W.GetLayer(name)
+	if !found {
+		shed.GlPanic(fmt.Errorf("could not find layer '%s'", name))
+	}
+
+	return layer
+}
+
+// Add things to the layer with the given name. Panic if it does not exist
+func (W *EngineType) AddToLayer(name string, items ...Drawable) {
+	W.MustGetLayer(name).Add(items...)
+}
+
+// All layers, in the order they are drawn
+func (W *EngineType) Layers() []*Layer {
+	return W.layers
+}
+
+// Draw all layers, lowest Z first
+func (W *EngineType) DrawLayers() {
+	for _, layer := range W.layers {
+		layer.Draw()
+	}
+}
+
+func (W *EngineType) sortLayers() {
+	sort.SliceStable(W.layers, func(i, j int) bool {
+		return W.layers[i].Z < W.layers[j].Z
+	})
+}
+
+func (W *EngineType) createDefaultLayers() {
+	W.CreateLayer(LayerBackground, 0)
+	W.CreateLayer(LayerBgSprites, 100)
+	W.CreateLayer(LayerFgSprites, 200)
+	W.CreateLayer(LayerGeometries, 300)
+	W.CreateLayer(LayerText, 400)
+}
@@ -0,0 +1,110 @@
+package tractor
+
+import (
+	"testing"
+)
+
+// Calls onDraw when it is drawn, and remembers it was
+type drawSpy struct {
+	name    string
+	drawn   *[]string
+	onDraw  func()
+	deleted bool
+}
+
+func (S *drawSpy) Draw() {
+	*S.drawn = append(*S.drawn, S.name)
+	if S.onDraw != nil {
+		S.onDraw()
+	}
+}
+
+func (S *drawSpy) IsDeleted() bool {
+	return S.deleted
+}
+
+func layerNames(L *Layer) []string {
+	names := []string{}
+	for _, d := range L.items {
+		names = append(names, d.(*drawSpy).name)
+	}
+	return names
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLayerChangedWhileDrawing(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(L *Layer, spies map[string]*drawSpy) // called when b is drawn
+		firstDraw  []string
+		secondDraw []string
+	}{
+		{"nothing", func(L *Layer, spies map[string]*drawSpy) {},
+			[]string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d"}},
+		{"add", func(L *Layer, spies map[string]*drawSpy) { L.Add(spies["x"]) },
+			[]string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d", "x"}},
+		{"remove itself", func(L *Layer, spies map[string]*drawSpy) { L.Remove(spies["b"]) },
+			[]string{"a", "b", "c", "d"}, []string{"a", "c", "d"}},
+		{"remove the one drawn before", func(L *Layer, spies map[string]*drawSpy) { L.Remove(spies["a"]) },
+			[]string{"a", "b", "c", "d"}, []string{"b", "c", "d"}},
+		{"remove the next one", func(L *Layer, spies map[string]*drawSpy) { L.Remove(spies["c"]) },
+			[]string{"a", "b", "d"}, []string{"a", "b", "d"}},
+		{"add and remove", func(L *Layer, spies map[string]*drawSpy) {
+			L.Add(spies["x"], spies["y"])
+			L.Remove(spies["x"])
+			L.Remove(spies["d"])
+		}, []string{"a", "b", "c"}, []string{"a", "b", "c", "y"}},
+		{"delete the next one", func(L *Layer, spies map[string]*drawSpy) { spies["c"].deleted = true },
+			[]string{"a", "b", "d"}, []string{"a", "b", "d"}},
+		{"clear and add", func(L *Layer, spies map[string]*drawSpy) {
+			L.Add(spies["x"])
+			L.Clear()
+			L.Add(spies["y"])
+		}, []string{"a", "b"}, []string{"y"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drawn := []string{}
+			L := &Layer{Name: "test"}
+
+			spies := map[string]*drawSpy{}
+			for _, name := range []string{"a", "b", "c", "d", "x", "y"} {
+				spies[name] = &drawSpy{name: name, drawn: &drawn}
+			}
+			changed := false
+			spies["b"].onDraw = func() {
+				if !changed {
+					changed = true
+					test.change(L, spies)
+				}
+			}
+			L.Add(spies["a"], spies["b"], spies["c"], spies["d"])
+
+			L.Draw()
+			if !sameNames(drawn, test.firstDraw) {
+				t.Fatalf("drew %v, expected %v", drawn, test.firstDraw)
+			}
+			if L.Len() != len(test.secondDraw) {
+				t.Fatalf("the layer holds %d things (%v), expected %v", L.Len(), layerNames(L), test.secondDraw)
+			}
+
+			drawn = drawn[:0]
+			L.Draw()
+			if !sameNames(drawn, test.secondDraw) {
+				t.Fatalf("then drew %v, expected %v", drawn, test.secondDraw)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package tractor
+
+import (
+	"goat/shed"
+	"image"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+type MouseButton glfw.MouseButton
+
+const (
+	MouseLeft   = MouseButton(glfw.MouseButtonLeft)
+	MouseRight  = MouseButton(glfw.MouseButtonRight)
+	MouseMiddle = MouseButton(glfw.MouseButtonMiddle)
+
+	mouseButtonCount = int(glfw.MouseButtonLast) + 1
+)
+
+// What the cursor looks like, and whether it may leave the window
+type CursorMode int
+
+const (
+	CursorNormal   CursorMode = iota
+	CursorHidden              // invisible while it is over the window
+	CursorCaptured            // invisible, and locked to the window. It moves without limits, which is good for mouse-look
+)
+
+type MouseHandler func(mev *MouseEvent)
+
+// Mouse event type that abstracts away (some) of glfw. See KeyEvent
+type MouseEvent struct {
+	X, Y float32 // the cursor, in pixels from the top left of the window
+
+	Button   MouseButton
+	Pressed  bool // Button went down
+	Released bool // Button went up
+
+	Moved bool
+
+	Scrolled         bool
+	ScrollX, ScrollY float32 // Y is the normal scroll wheel
+
+	Entered bool // the cursor came into the window
+	Exited  bool // the cursor left the window
+
+	Ctrl  bool
+	Alt   bool
+	Shift bool
+	Gui   bool
+}
+
+// A button that is held down while the cursor moves
+type MouseDrag struct {
+	Button   MouseButton
+	Start    shed.V2 // where the button went down, in pixels
+	Current  shed.V2 // where the cursor is now, in pixels
+	Dragging bool    // the cursor has moved more than Mouse.DragThreshold since the button went down
+}
+
+// How far the cursor has moved since the button went down
+func (D MouseDrag) Delta() shed.V2 {
+	return D.Current.Minus(D.Start)
+}
+
+// =========================================================================
+// ||
+// || Mouse.
+// ||
+// || Keeps track of the buttons, the cursor and the scroll wheel, so they
+// || can be polled, and passes the events on to a handler.
+// ||
+// || "Pressed" and "released" and the scroll distance are collected over a
+// || frame: EndFrame() starts a new one. Engine loops call it themselves.
+// ||
+// =========================================================================
+type Mouse struct {
+	DragThreshold float32 // pixels. 3 by default
+
+	window    *glfw.Window // nil when headless
+	handler   MouseHandler
+	tap       func(mev MouseEvent) // sees every event. See InputRecorder
+	replaying bool                 // the window is ignored while a replay feeds the events
+
+	pos      shed.V2
+	inside   bool
+	down     [mouseButtonCount]bool
+	pressed  [mouseButtonCount]bool
+	released [mouseButtonCount]bool
+	scroll   shed.V2
+	drags    [mouseButtonCount]MouseDrag
+	mode     CursorMode
+	cursor   *glfw.Cursor
+}
+
+// Listen to the mouse of a window. Without a window (headless) the mouse only knows what it is fed (see Feed)
+func CreateMouse(window *glfw.Window) *Mouse {
+	M := &Mouse{DragThreshold: 3, window: window}
+
+	if window == nil {
+		return M
+	}
+
+	x, y := window.GetCursorPos()
+	M.pos = shed.Vec2(float32(x), float32(y))
+
+	window.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		mev := M.event(mods)
+		mev.Button = MouseButton(button)
+		mev.Pressed = action == glfw.Press
+		mev.Released = action == glfw.Release
+		M.fromWindow(mev)
+	})
+
+	window.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		mev := M.event(0)
+		mev.X, mev.Y = float32(x), float32(y)
+		mev.Moved = true
+		M.fromWindow(mev)
+	})
+
+	window.SetScrollCallback(func(_ *glfw.Window, dx, dy float64) {
+		mev := M.event(0)
+		mev.ScrollX, mev.ScrollY = float32(dx), float32(dy)
+		mev.Scrolled = true
+		M.fromWindow(mev)
+	})
+
+	window.SetCursorEnterCallback(func(_ *glfw.Window, entered bool) {
+		mev := M.event(0)
+		mev.Entered, mev.Exited = entered, !entered
+		M.fromWindow(mev)
+	})
+
+	return M
+}
+
+// An event at the current cursor position
+func (M *Mouse) event(mods glfw.ModifierKey) MouseEvent {
+	return MouseEvent{
+		X:     M.pos.X,
+		Y:     M.pos.Y,
+		Ctrl:  mods&glfw.ModControl != 0,
+		Alt:   mods&glfw.ModAlt != 0,
+		Shift: mods&glfw.ModShift != 0,
+		Gui:   mods&glfw.ModSuper != 0,
+	}
+}
+
+func (M *Mouse) fromWindow(mev MouseEvent) {
+	if !M.replaying {
+		M.Feed(mev)
+	}
+}
+
+// Call mh for every mouse event. Replaces the previous handler. nil stops the events
+func (M *Mouse) Handle(mh MouseHandler) {
+	M.handler = mh
+}
+
+// Process an event as if it came from the window. Useful for tests and replays
+func (M *Mouse) Feed(mev MouseEvent) {
+	M.pos = shed.Vec2(mev.X, mev.Y)
+
+	if mev.Entered {
+		M.inside = true
+	}
+	if mev.Exited {
+		M.inside = false
+	}
+	if mev.Scrolled {
+		M.scroll = M.scroll.Plus(shed.Vec2(mev.ScrollX, mev.ScrollY))
+	}
+
+	if b := int(mev.Button); b >= 0 && b < mouseButtonCount {
+		switch {
+		case mev.Pressed:
+			M.down[b], M.pressed[b] = true, true
+			M.drags[b] = MouseDrag{Button: mev.Button, Start: M.pos, Current: M.pos}
+		case mev.Released:
+			M.down[b], M.released[b] = false, true
+		}
+	}
+
+	if mev.Moved {
+		for b := range M.drags {
+			if !M.down[b] {
+				continue
+			}
+			D := &M.drags[b]
+			D.Current = M.pos
+			D.Dragging = D.Dragging || D.Delta().Len() > M.DragThreshold
+		}
+	}
+
+	if M.tap != nil {
+		M.tap(mev)
+	}
+	if M.handler != nil {
+		M.handler(&mev)
+	}
+}
+
+// Start a new frame: forget which buttons were pressed and released, and how far the wheel scrolled
+func (M *Mouse) EndFrame() {
+	M.pressed = [mouseButtonCount]bool{}
+	M.released = [mouseButtonCount]bool{}
+	M.scroll = shed.V2{}
+}
+
+// Let go of every button, without events
+func (M *Mouse) reset() {
+	M.down = [mouseButtonCount]bool{}
+	M.EndFrame()
+}
+
+// The cursor, in pixels from the top left of the window
+func (M *Mouse) XY() shed.V2 {
+	return M.pos
+}
+
+// The cursor, in the world as seen by the camera
+func (M *Mouse) WorldXY(cam *Camera) shed.V2 {
+	w, h := M.windowSize()
+	return cam.ScreenToWorld(M.pos, w, h)
+}
+
+func (M *Mouse) windowSize() (float32, float32) {
+	if M.window != nil {
+		w, h := M.window.GetSize()
+		return float32(w), float32(h)
+	}
+	if Engine != nil {
+		w, h := Engine.Backend.FramebufferSize()
+		return float32(w), float32(h)
+	}
+	return 1, 1
+}
+
+// Is the button held down?
+func (M *Mouse) IsDown(button MouseButton) bool {
+	return validButton(button) && M.down[button]
+}
+
+// Did the button go down this frame?
+func (M *Mouse) WasPressed(button MouseButton) bool {
+	return validButton(button) && M.pressed[button]
+}
+
+// Did the button go up this frame?
+func (M *Mouse) WasReleased(button MouseButton) bool {
+	return validButton(button) && M.released[button]
+}
+
+func validButton(button MouseButton) bool {
+	return int(button) >= 0 && int(button) < mouseButtonCount
+}
+
+// How far the wheel has scrolled this frame
+func (M *Mouse) Scroll() shed.V2 {
+	return M.scroll
+}
+
+// Is the cursor over the window?
+func (M *Mouse) IsInside() bool {
+	return M.inside
+}
+
+// The drag of a button, while it is held down
+func (M *Mouse) Drag(button MouseButton) (MouseDrag, bool) {
+	if !M.IsDown(button) {
+		return MouseDrag{}, false
+	}
+	return M.drags[button], true
+}
+
+func (M *Mouse) SetCursorMode(mode CursorMode) {
+	M.mode = mode
+	if M.window == nil {
+		return
+	}
+
+	switch mode {
+	case CursorHidden:
+		M.window.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
+	case CursorCaptured:
+		M.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+		if glfw.RawMouseMotionSupported() {
+			M.window.SetInputMode(glfw.RawMouseMotion, glfw.True)
+		}
+	default:
+		M.window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+}
+
+func (M *Mouse) GetCursorMode() CursorMode {
+	return M.mode
+}
+
+// Draw the cursor with an image. (hotX, hotY) is the pixel of the image that points. nil restores the normal cursor
+func (M *Mouse) SetCursorImage(img image.Image, hotX, hotY int) {
+	if M.window == nil {
+		return
+	}
+
+	if M.cursor != nil {
+		M.cursor.Destroy()
+		M.cursor = nil
+	}
+	if img != nil {
+		M.cursor = glfw.CreateCursor(img, hotX, hotY)
+	}
+
+	M.window.SetCursor(M.cursor)
+}
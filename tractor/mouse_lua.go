@@ -0,0 +1,134 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+
+	lua "github.com/yuin/gopher-lua"
+	luar "layeh.com/gopher-luar"
+)
+
+// Make the mouse available to a lua script:
+//
+//	local x, y = MouseXY()
+//	if MouseDown("left") then ... end          -- also MousePressed and MouseReleased, for this frame only
+//	local dx, dy = MouseScroll()
+//	local dragging, startX, startY = MouseDrag("right")
+//	CursorMode("hidden")                        -- "normal", "hidden" or "captured"
+//	OnMouse(function(ev) if ev.Pressed then Log("click at %f, %f", ev.X, ev.Y) end end)
+//
+// Buttons are "left", "right", "middle", or numbers from 1 to 8. toScript converts pixels to the coordinates
+// the script uses. If it is nil, scripts get pixels. Lua errors in OnMouse are passed to onError. If it is nil, they panic.
+//
+// The handler the script sets is kept aside until activate() is called, so a script that fails to load
+// does not take the mouse from the one that runs. Call it once the script has loaded.
+func (M *Mouse) ExportToLua(L *lua.LState, toScript func(pixel shed.V2) shed.V2, onError func(error)) (activate func()) {
+
+	if toScript == nil {
+		toScript = func(pixel shed.V2) shed.V2 { return pixel }
+	}
+
+	var handler MouseHandler // what the script asked for
+	active := false
+
+	push := func(L *lua.LState, p shed.V2) {
+		p = toScript(p)
+		L.Push(lua.LNumber(p.X))
+		L.Push(lua.LNumber(p.Y))
+	}
+
+	button := func(L *lua.LState) MouseButton {
+		switch arg := L.CheckAny(1).(type) {
+		case lua.LString:
+			switch arg {
+			case "left":
+				return MouseLeft
+			case "right":
+				return MouseRight
+			case "middle":
+				return MouseMiddle
+			}
+		case lua.LNumber:
+			if b := int(arg) - 1; b >= 0 && b < mouseButtonCount {
+				return MouseButton(b)
+			}
+		}
+		L.ArgError(1, fmt.Sprintf("unknown mouse button '%s'", L.Get(1)))
+		return 0
+	}
+
+	test := func(fn func(MouseButton) bool) *lua.LFunction {
+		return L.NewFunction(func(L *lua.LState) int {
+			L.Push(lua.LBool(fn(button(L))))
+			return 1
+		})
+	}
+
+	L.SetGlobal("MouseXY", L.NewFunction(func(L *lua.LState) int {
+		push(L, M.XY())
+		return 2
+	}))
+
+	L.SetGlobal("MouseDown", test(M.IsDown))
+	L.SetGlobal("MousePressed", test(M.WasPressed))
+	L.SetGlobal("MouseReleased", test(M.WasReleased))
+	L.SetGlobal("MouseInside", luar.New(L, M.IsInside))
+
+	L.SetGlobal("MouseScroll", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LNumber(M.scroll.X))
+		L.Push(lua.LNumber(M.scroll.Y))
+		return 2
+	}))
+
+	L.SetGlobal("MouseDrag", L.NewFunction(func(L *lua.LState) int {
+		drag, held := M.Drag(button(L))
+		L.Push(lua.LBool(held && drag.Dragging))
+		push(L, drag.Start)
+		return 3
+	}))
+
+	L.SetGlobal("CursorMode", L.NewFunction(func(L *lua.LState) int {
+		switch mode := L.CheckString(1); mode {
+		case "normal":
+			M.SetCursorMode(CursorNormal)
+		case "hidden":
+			M.SetCursorMode(CursorHidden)
+		case "captured":
+			M.SetCursorMode(CursorCaptured)
+		default:
+			L.ArgError(1, fmt.Sprintf("unknown cursor mode '%s'", mode))
+		}
+		return 0
+	}))
+
+	L.SetGlobal("OnMouse", L.NewFunction(func(L *lua.LState) int {
+		fn := L.OptFunction(1, nil)
+		if fn == nil {
+			handler = nil
+		} else {
+			handler = func(mev *MouseEvent) {
+				event := *mev
+				p := toScript(shed.Vec2(event.X, event.Y))
+				event.X, event.Y = p.X, p.Y
+
+				err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, luar.New(L, &event))
+				if err != nil {
+					if onError == nil {
+						shed.GlPanic(err)
+					}
+					onError(err)
+				}
+			}
+		}
+
+		if active {
+			M.handler = handler
+		}
+		return 0
+	}))
+
+	return func() {
+		active = true
+		M.handler = handler
+	}
+}
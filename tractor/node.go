@@ -0,0 +1,178 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Anything that can be drawn relative to a parent transformation.
+// The parent matrix is applied after the thing's own matrix.
+type RelativeDrawable interface {
+	DrawRelative(parent mgl32.Mat3)
+}
+
+// =========================================================================
+// ||
+// || Scene graph node.
+// ||
+// || A node has a position relative to its parent, and any number of
+// || children. Moving, rotating or scaling a node moves, rotates or scales
+// || all its children (and the things attached to them).
+// ||
+// || World matrices are cached. Each node remembers which version of its
+// || own matrix and its parent's world matrix it was composed from, so
+// || changes propagate lazily down the tree, the next time they are needed.
+// ||
+// =========================================================================
+type Node struct {
+	Position
+
+	Drawables []RelativeDrawable // Things drawn at this node's location. Drawn before the children.
+	Hidden    bool               // Don't draw this node or any of its children
+	Deleted   bool               // Removes the node from any layer it is in
+
+	parent   *Node
+	children []*Node
+
+	worldCache         mgl32.Mat3
+	worldVersion       uint64 // incremented every time worldCache is recalculated
+	localVersionUsed   uint64 // the Position.cacheVersion worldCache was calculated from
+	parentVersionUsed  uint64 // the parent's worldVersion worldCache was calculated from
+	worldCacheComputed bool
+}
+
+func CreateNode(x, y float32) *Node {
+	N := &Node{}
+	N.SetScale(1, 1)
+	N.SetXY(x, y)
+
+	return N
+}
+
+// Add a child node. If the child already has a parent, it is moved.
+// The child keeps its local position, so it may jump on screen.
+// A node cannot be added to itself, or to one of its descendants.
+func (N *Node) AddChild(child *Node) {
+	for ancestor := N; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			shed.GlPanic(fmt.Errorf("cannot add a node to itself or to one of its descendants"))
+		}
+	}
+
+	if child.parent != nil {
+		child.parent.RemoveChild(child)
+	}
+
+	child.parent = N
+	child.worldCacheComputed = false
+	N.children = append(N.children, child)
+}
+
+// Remove a child node. Does nothing if the node is not a child of N
+func (N *Node) RemoveChild(child *Node) {
+	for i, c := range N.children {
+		if c == child {
+			N.children = append(N.children[:i], N.children[i+1:]...)
+			child.parent = nil
+			child.worldCacheComputed = false
+			return
+		}
+	}
+}
+
+// Remove the node from its parent (if any)
+func (N *Node) Detach() {
+	if N.parent != nil {
+		N.parent.RemoveChild(N)
+	}
+}
+
+func (N *Node) Parent() *Node {
+	return N.parent
+}
+
+func (N *Node) Children() []*Node {
+	return N.children
+}
+
+// Attach something that should be drawn at this node's location
+func (N *Node) Attach(d RelativeDrawable) {
+	N.Drawables = append(N.Drawables, d)
+}
+
+// The matrix that transforms from this node's local space into world space
+func (N *Node) GetWorldMatrix() mgl32.Mat3 {
+	local := N.GetMatrix() // also updates N.cacheVersion if needed
+
+	if N.parent == nil {
+		if !N.worldCacheComputed || N.localVersionUsed != N.cacheVersion {
+			N.worldCache = local
+			N.localVersionUsed = N.cacheVersion
+			N.worldCacheComputed = true
+			N.worldVersion++
+		}
+		return N.worldCache
+	}
+
+	parentWorld := N.parent.GetWorldMatrix() // also updates the parent's worldVersion if needed
+
+	if !N.worldCacheComputed || N.localVersionUsed != N.cacheVersion || N.parentVersionUsed != N.parent.worldVersion {
+		N.worldCache = parentWorld.Mul3(local)
+		N.localVersionUsed = N.cacheVersion
+		N.parentVersionUsed = N.parent.worldVersion
+		N.worldCacheComputed = true
+		N.worldVersion++
+	}
+
+	return N.worldCache
+}
+
+// Convert a point from this node's local space into world space
+func (N *Node) LocalToWorld(p shed.V2) shed.V2 {
+	v := N.GetWorldMatrix().Mul3x1(mgl32.Vec3{p.X, p.Y, 1})
+
+	return shed.Vec2(v[0], v[1])
+}
+
+// Convert a point from world space into this node's local space
+func (N *Node) WorldToLocal(p shed.V2) shed.V2 {
+	v := N.GetWorldMatrix().Inv().Mul3x1(mgl32.Vec3{p.X, p.Y, 1})
+
+	return shed.Vec2(v[0], v[1])
+}
+
+// The location of the node's origin, in world space
+func (N *Node) WorldXY() shed.V2 {
+	return N.LocalToWorld(shed.Vec2(0, 0))
+}
+
+// Call fn for this node, and all its descendants. Parents before children.
+func (N *Node) Walk(fn func(n *Node)) {
+	fn(N)
+
+	for _, c := range N.children {
+		c.Walk(fn)
+	}
+}
+
+// Draw the attached drawables of this node, and all its descendants
+func (N *Node) Draw() {
+	if N.Hidden || N.Deleted {
+		return
+	}
+
+	world := N.GetWorldMatrix()
+	for _, d := range N.Drawables {
+		d.DrawRelative(world)
+	}
+
+	for _, c := range N.children {
+		c.Draw()
+	}
+}
+
+func (N *Node) IsDeleted() bool {
+	return N.Deleted
+}
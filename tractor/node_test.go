@@ -0,0 +1,135 @@
+package tractor
+
+import (
+	"goat/shed"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func closeTo(a, b shed.V2) bool {
+	return math.Abs(float64(a.X-b.X)) < 1e-3 && math.Abs(float64(a.Y-b.Y)) < 1e-3
+}
+
+func TestNodeParentMoveReachesGrandchild(t *testing.T) {
+	root, child, grandchild := CreateNode(0, 0), CreateNode(10, 0), CreateNode(0, 5)
+	root.AddChild(child)
+	child.AddChild(grandchild)
+
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(10, 5)) {
+		t.Fatalf("the grandchild is at %v", p)
+	}
+
+	// The cached world matrices of child and grandchild are now stale
+	root.SetXY(100, 0)
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(110, 5)) {
+		t.Fatalf("after moving the root, the grandchild is at %v", p)
+	}
+
+	root.SetAngle(mgl32.DegToRad(90))
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(95, 10)) {
+		t.Fatalf("after turning the root, the grandchild is at %v", p)
+	}
+
+	root.SetScale(2, 2)
+	child.SetXY(10, 1) // both the root and the child change before the grandchild is asked
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(88, 20)) {
+		t.Fatalf("after scaling the root and moving the child, the grandchild is at %v", p)
+	}
+}
+
+func TestNodeReparent(t *testing.T) {
+	a, b := CreateNode(10, 0), CreateNode(-10, 0)
+	child, grandchild := CreateNode(1, 0), CreateNode(0, 1)
+	a.AddChild(child)
+	child.AddChild(grandchild)
+
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(11, 1)) {
+		t.Fatalf("under a, the grandchild is at %v", p)
+	}
+
+	b.AddChild(child)
+	if child.Parent() != b || len(a.Children()) != 0 || len(b.Children()) != 1 {
+		t.Fatalf("the child was not moved from a to b")
+	}
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(-9, 1)) {
+		t.Fatalf("under b, the grandchild is at %v", p)
+	}
+
+	child.Detach()
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(1, 1)) {
+		t.Fatalf("detached, the grandchild is at %v", p)
+	}
+
+	// Moving the old parent must not move the detached child
+	a.SetXY(50, 50)
+	b.SetXY(50, 50)
+	if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(1, 1)) {
+		t.Fatalf("moving its old parents moved the detached grandchild to %v", p)
+	}
+}
+
+func TestNodeWorldLocalRoundTrip(t *testing.T) {
+	root, child := CreateNode(30, -20), CreateNode(5, 7)
+	root.SetAngle(mgl32.DegToRad(30))
+	root.SetScale(2, 0.5)
+	child.SetAngle(mgl32.DegToRad(-75))
+	child.SetScale(1.5, 3)
+	root.AddChild(child)
+
+	for _, p := range []shed.V2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: -13, Y: 42}, {X: 0.25, Y: -7}} {
+		if back := child.WorldToLocal(child.LocalToWorld(p)); !closeTo(back, p) {
+			t.Fatalf("%v went to world space and came back as %v", p, back)
+		}
+		if back := child.LocalToWorld(child.WorldToLocal(p)); !closeTo(back, p) {
+			t.Fatalf("%v went to local space and came back as %v", p, back)
+		}
+	}
+
+	// The child's origin is the root's local point (5, 7)
+	if p, q := child.WorldXY(), root.LocalToWorld(shed.Vec2(5, 7)); !closeTo(p, q) {
+		t.Fatalf("the child's origin is at %v, expected %v", p, q)
+	}
+}
+
+func TestNodeCycles(t *testing.T) {
+	out := shed.Logger.Writer() // GlPanic logs a stack trace
+	shed.Logger.SetOutput(io.Discard)
+	defer shed.Logger.SetOutput(out)
+
+	tests := []struct {
+		name string
+		add  func(root, child, grandchild *Node)
+	}{
+		{"itself", func(root, child, grandchild *Node) { root.AddChild(root) }},
+		{"its parent", func(root, child, grandchild *Node) { child.AddChild(root) }},
+		{"its grandparent", func(root, child, grandchild *Node) { grandchild.AddChild(root) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, child, grandchild := CreateNode(0, 0), CreateNode(1, 0), CreateNode(0, 1)
+			root.AddChild(child)
+			child.AddChild(grandchild)
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("making a cycle did not panic")
+					}
+				}()
+				test.add(root, child, grandchild)
+			}()
+
+			// The tree is as it was
+			if root.Parent() != nil || child.Parent() != root || grandchild.Parent() != child {
+				t.Fatalf("the failed AddChild changed the tree")
+			}
+			if p := grandchild.WorldXY(); !closeTo(p, shed.Vec2(1, 1)) {
+				t.Fatalf("the grandchild is at %v", p)
+			}
+		})
+	}
+}
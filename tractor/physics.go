@@ -0,0 +1,408 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+	"math"
+)
+
+// How a body moves
+type BodyType int
+
+const (
+	BodyDynamic   BodyType = iota // moved by gravity, forces, impulses and collisions
+	BodyKinematic                 // moved by its velocity only. Pushes dynamic bodies around, but nothing pushes it
+	BodyStatic                    // never moves
+)
+
+// =========================================================================
+// ||
+// || Body.
+// ||
+// || Moves a Position (of a sprite, a rect, ...) like a physical object.
+// || Bodies with a Collider bump into each other.
+// ||
+// =========================================================================
+type Body struct {
+	Type     BodyType
+	Position *Position
+	Collider *Collider // Optional. Bodies without one don't collide
+
+	Velocity        shed.V2 // units per second
+	AngularVelocity float32 // radians per second
+	Drag            float32 // how quickly the body slows down. Roughly the fraction of its speed it loses per second
+	AngularDrag     float32 // the same, for spinning
+	Restitution     float32 // bounciness. 0 stops dead, 1 bounces back as fast as it came. The bouncier of two bodies wins
+	Friction        float32 // 0 slides freely. Combined with the friction of the other body
+	GravityScale    float32 // 1 falls normally, 0 floats
+	FixedRotation   bool    // collisions don't make the body spin
+	Data            interface{}
+	Deleted         bool
+
+	mass, invMass       float32
+	inertia, invInertia float32
+	force               shed.V2 // applied until the next step
+	torque              float32
+
+	prevX, prevY, prevAngle float32 // before the last step. See Interpolated()
+}
+
+// A dynamic body. The collider may be nil, and should follow the same Position
+func CreateBody(position *Position, collider *Collider, mass float32) *Body {
+	B := &Body{
+		Type:         BodyDynamic,
+		Position:     position,
+		Collider:     collider,
+		GravityScale: 1,
+	}
+	B.SetMass(mass)
+	B.prevX, B.prevY, B.prevAngle = position.GetXYA()
+
+	return B
+}
+
+// A body that never moves, for instance the ground
+func CreateStaticBody(position *Position, collider *Collider) *Body {
+	B := CreateBody(position, collider, 0)
+	B.Type = BodyStatic
+
+	return B
+}
+
+// A body that moves at its own velocity, and is not pushed around. For instance a moving platform
+func CreateKinematicBody(position *Position, collider *Collider) *Body {
+	B := CreateBody(position, collider, 0)
+	B.Type = BodyKinematic
+
+	return B
+}
+
+// Set the mass. How hard the body is to spin follows from its mass and the shape of its collider
+func (B *Body) SetMass(mass float32) {
+	if mass < 0 {
+		shed.GlPanic(fmt.Errorf("the mass of a body must be >= 0, got %f", mass))
+	}
+
+	B.mass = mass
+	B.inertia = mass * B.shapeInertia()
+}
+
+func (B *Body) GetMass() float32 {
+	return B.mass
+}
+
+// The moment of inertia of the shape, per unit of mass
+func (B *Body) shapeInertia() float32 {
+	sx, sy := B.Position.GetScale()
+
+	if B.Collider != nil {
+		switch B.Collider.Shape {
+		case ColliderCircle:
+			r := shed.Max(sx, sy) / 2
+			return r * r / 2
+		case ColliderPolygon:
+			box := shed.AABBAround(B.Collider.Points)
+			sx, sy = sx*(box.Max.X-box.Min.X), sy*(box.Max.Y-box.Min.Y)
+		}
+	}
+
+	return (sx*sx + sy*sy) / 12 // a box
+}
+
+// The inverse mass is what the physics uses. Zero means the body cannot be pushed
+func (B *Body) inverseMass() float32 {
+	if B.Type != BodyDynamic || B.mass == 0 {
+		return 0
+	}
+	return 1 / B.mass
+}
+
+func (B *Body) inverseInertia() float32 {
+	if B.Type != BodyDynamic || B.FixedRotation || B.inertia == 0 {
+		return 0
+	}
+	return 1 / B.inertia
+}
+
+func (B *Body) IsDeleted() bool {
+	return B.Deleted
+}
+
+// Push the body. The force is applied during the next step, and then forgotten
+func (B *Body) ApplyForce(force shed.V2) {
+	B.force = B.force.Plus(force)
+}
+
+// Twist the body during the next step
+func (B *Body) ApplyTorque(torque float32) {
+	B.torque += torque
+}
+
+// Change the velocity at once, for instance to jump or to shoot
+func (B *Body) ApplyImpulse(impulse shed.V2) {
+	B.Velocity = B.Velocity.Plus(impulse.Scaled(B.inverseMass()))
+}
+
+// Apply an impulse at a point (in world space). Off-center impulses make the body spin
+func (B *Body) ApplyImpulseAt(impulse, point shed.V2) {
+	B.ApplyImpulse(impulse)
+	B.AngularVelocity += point.Minus(B.center()).Cross(impulse) * B.inverseInertia()
+}
+
+func (B *Body) center() shed.V2 {
+	return shed.Vec2(B.Position.x, B.Position.y)
+}
+
+// The velocity of a point of the body (in world space), including spin
+func (B *Body) velocityAt(point shed.V2) shed.V2 {
+	r := point.Minus(B.center())
+	return B.Velocity.Plus(shed.Vec2(-r.Y, r.X).Scaled(B.AngularVelocity))
+}
+
+// Where to draw the body, between the last two steps. See PhysicsWorld.Alpha()
+func (B *Body) Interpolated(alpha float32) (x, y, angle float32) {
+	x, y, angle = B.Position.GetXYA()
+
+	return shed.LerpU(B.prevX, x, alpha), shed.LerpU(B.prevY, y, alpha), shed.LerpU(B.prevAngle, angle, alpha)
+}
+
+// =========================================================================
+// ||
+// || Physics World.
+// ||
+// || Moves bodies in fixed steps, so the simulation behaves the same at any
+// || frame rate. Time that is left over is carried on to the next frame.
+// ||
+// =========================================================================
+type PhysicsWorld struct {
+	Gravity    shed.V2
+	Step       float32 // seconds per step. 1/60 by default
+	MaxSteps   int     // per Advance(). A slow frame is not caught up with completely, or it would slow down the next one even more
+	Iterations int     // times the collisions are resolved per step. More keeps stacks of bodies steadier
+
+	// Two bodies bumped into each other. The normal of the contact points from A towards B
+	OnCollision func(A, B *Body, contact shed.Contact)
+
+	Collisions *CollisionWorld // the colliders of the bodies. Other colliders may be added, but are not pushed around
+
+	bodies      []*Body
+	byCollider  map[*Collider]*Body
+	accumulator float32
+}
+
+const (
+	physicsSlop       = 0.01 // overlap that is allowed, so resting bodies don't jitter
+	physicsCorrection = 0.8  // how much of the overlap is pushed out per step
+)
+
+// cellSize is for the broadphase (see CreateCollisionWorld)
+func CreatePhysicsWorld(cellSize float32) *PhysicsWorld {
+	return &PhysicsWorld{
+		Step:       1.0 / 60,
+		MaxSteps:   5,
+		Iterations: 4,
+		Collisions: CreateCollisionWorld(cellSize),
+		byCollider: make(map[*Collider]*Body),
+	}
+}
+
+func (W *PhysicsWorld) Add(bodies ...*Body) {
+	for _, B := range bodies {
+		W.bodies = append(W.bodies, B)
+		if B.Collider != nil {
+			W.Collisions.Add(B.Collider)
+			W.byCollider[B.Collider] = B
+		}
+	}
+}
+
+func (W *PhysicsWorld) Remove(B *Body) {
+	for i, other := range W.bodies {
+		if other == B {
+			W.bodies = append(W.bodies[:i], W.bodies[i+1:]...)
+			break
+		}
+	}
+
+	if B.Collider != nil {
+		W.Collisions.Remove(B.Collider)
+		delete(W.byCollider, B.Collider)
+	}
+}
+
+// Remove every body
+func (W *PhysicsWorld) Clear() {
+	for len(W.bodies) > 0 {
+		W.Remove(W.bodies[len(W.bodies)-1])
+	}
+	W.accumulator = 0
+}
+
+func (W *PhysicsWorld) Bodies() []*Body {
+	return W.bodies
+}
+
+// The body of a collider, or nil
+func (W *PhysicsWorld) BodyOf(C *Collider) *Body {
+	return W.byCollider[C]
+}
+
+// Advance by the time since the last frame
+func (W *PhysicsWorld) Update() int {
+	return W.Advance(Engine.Delta)
+}
+
+// Run as many steps as fit into the time, plus what was left over last time. Returns the number of steps
+func (W *PhysicsWorld) Advance(dt float32) int {
+	if W.Step <= 0 {
+		shed.GlPanic(fmt.Errorf("the physics step must be > 0, got %f", W.Step))
+	}
+
+	W.accumulator += dt
+
+	steps := 0
+	for W.accumulator >= W.Step {
+		if W.MaxSteps > 0 && steps == W.MaxSteps {
+			W.accumulator = 0 // give up on catching up
+			break
+		}
+		W.StepOnce()
+		W.accumulator -= W.Step
+		steps++
+	}
+
+	return steps
+}
+
+// How far we are between the last step and the next one, from 0 to 1. See Body.Interpolated()
+func (W *PhysicsWorld) Alpha() float32 {
+	return shed.Min(W.accumulator/W.Step, 1)
+}
+
+// Move the bodies by one Step, and resolve their collisions
+func (W *PhysicsWorld) StepOnce() {
+	dt := W.Step
+
+	for _, B := range append([]*Body{}, W.bodies...) {
+		if B.Deleted {
+			W.Remove(B)
+		}
+	}
+
+	for _, B := range W.bodies {
+		B.prevX, B.prevY, B.prevAngle = B.Position.GetXYA()
+
+		switch B.Type {
+		case BodyStatic:
+			continue
+
+		case BodyDynamic:
+			accel := W.Gravity.Scaled(B.GravityScale).Plus(B.force.Scaled(B.inverseMass()))
+			B.Velocity = B.Velocity.Plus(accel.Scaled(dt)).Scaled(1 / (1 + B.Drag*dt))
+
+			B.AngularVelocity += B.torque * B.inverseInertia() * dt
+			B.AngularVelocity /= 1 + B.AngularDrag*dt
+			if B.FixedRotation {
+				B.AngularVelocity = 0
+			}
+		}
+
+		B.force, B.torque = shed.V2{}, 0
+
+		B.Position.Move(B.Velocity.Scaled(dt))
+		if B.AngularVelocity != 0 {
+			B.Position.Rotate(B.AngularVelocity * dt)
+		}
+	}
+
+	W.Collisions.Update()
+	W.resolveCollisions()
+}
+
+type physicsContact struct {
+	A, B    *Body
+	contact shed.Contact
+}
+
+func (W *PhysicsWorld) resolveCollisions() {
+	contacts := []physicsContact{}
+
+	for _, pair := range W.Collisions.Pairs() {
+		a, b := W.byCollider[pair.A], W.byCollider[pair.B]
+		if a == nil || b == nil {
+			continue
+		}
+		contacts = append(contacts, physicsContact{a, b, pair.Contact})
+	}
+
+	for i := 0; i < max(W.Iterations, 1); i++ {
+		for _, c := range contacts {
+			resolveVelocities(c.A, c.B, c.contact)
+		}
+	}
+
+	for _, c := range contacts {
+		separate(c.A, c.B, c.contact)
+
+		if W.OnCollision != nil {
+			W.OnCollision(c.A, c.B, c.contact)
+		}
+	}
+}
+
+// Bounce the bodies off each other, with an impulse along the normal (and one across it, for friction)
+func resolveVelocities(a, b *Body, c shed.Contact) {
+	ra, rb := c.Point.Minus(a.center()), c.Point.Minus(b.center())
+	relative := b.velocityAt(c.Point).Minus(a.velocityAt(c.Point))
+
+	along := relative.Dot(c.Normal)
+	if along > 0 {
+		return // already moving apart
+	}
+
+	// How much the bodies resist an impulse along a direction, at the contact point
+	resistance := func(dir shed.V2) float32 {
+		raDir, rbDir := ra.Cross(dir), rb.Cross(dir)
+		return a.inverseMass() + b.inverseMass() + raDir*raDir*a.inverseInertia() + rbDir*rbDir*b.inverseInertia()
+	}
+
+	k := resistance(c.Normal)
+	if k == 0 {
+		return // neither body can be pushed
+	}
+
+	restitution := shed.Max(a.Restitution, b.Restitution)
+	j := -(1 + restitution) * along / k
+	applyPairImpulse(a, b, c.Normal.Scaled(j), c.Point)
+
+	// Friction works against the sliding, and is at most friction * the push along the normal
+	relative = b.velocityAt(c.Point).Minus(a.velocityAt(c.Point))
+	tangent := relative.Minus(c.Normal.Scaled(relative.Dot(c.Normal)))
+	if tangent.Len() < 1e-6 {
+		return
+	}
+	tangent = tangent.Normalized()
+
+	friction := float32(math.Sqrt(float64(a.Friction * b.Friction)))
+	jt := -relative.Dot(tangent) / resistance(tangent)
+	jt = shed.Max(-j*friction, shed.Min(jt, j*friction))
+	applyPairImpulse(a, b, tangent.Scaled(jt), c.Point)
+}
+
+// Apply the impulse to b, and the opposite one to a
+func applyPairImpulse(a, b *Body, impulse, point shed.V2) {
+	a.ApplyImpulseAt(impulse.Scaled(-1), point)
+	b.ApplyImpulseAt(impulse, point)
+}
+
+// Push overlapping bodies apart, the lighter one more
+func separate(a, b *Body, c shed.Contact) {
+	invA, invB := a.inverseMass(), b.inverseMass()
+	if invA+invB == 0 {
+		return
+	}
+
+	push := c.Normal.Scaled(shed.Max(c.Depth-physicsSlop, 0) / (invA + invB) * physicsCorrection)
+	a.Position.Move(push.Scaled(-invA))
+	b.Position.Move(push.Scaled(invB))
+}
@@ -0,0 +1,178 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+
+	lua "github.com/yuin/gopher-lua"
+	luar "layeh.com/gopher-luar"
+)
+
+// Make the physics world available to a lua script. The script still has to draw the bodies:
+//
+//	local ball = Body{x = 100, y = 100, radius = 20, restitution = 0.8, data = {name = "ball"}}
+//	local floor = Body{x = 400, y = 580, w = 800, h = 40, type = "static"}
+//	Gravity(0, 500)
+//	OnCollision(function(a, b, nx, ny, depth) Log("%s bumped into something", a:Data().name) end)
+//	ball:ApplyImpulse(200, 0)
+//	local x, y = ball:XY()
+//	RemoveAllBodies()
+//
+// Bodies are boxes (w by h) unless they have a radius. Lua errors in OnCollision are passed to onError. If it is nil, they panic.
+func (W *PhysicsWorld) ExportToLua(L *lua.LState, onError func(error)) {
+
+	L.SetGlobal("Body", L.NewFunction(func(L *lua.LState) int {
+		opts := L.CheckTable(1)
+		number := func(name string, fallback float32) float32 {
+			switch value := opts.RawGetString(name).(type) {
+			case lua.LNumber:
+				return float32(value)
+			case *lua.LNilType:
+				return fallback
+			default:
+				L.ArgError(1, fmt.Sprintf("'%s' must be a number", name))
+				return 0
+			}
+		}
+
+		position := CreatePosition()
+		position.SetXY(number("x", 0), number("y", 0))
+		position.SetAngle(number("angle", 0))
+
+		var collider *Collider
+		if radius := number("radius", 0); radius > 0 {
+			position.SetScale(2*radius, 2*radius)
+			collider = CreateCircleCollider(&position)
+		} else {
+			position.SetScale(number("w", 1), number("h", 1))
+			collider = CreateBoxCollider(&position)
+		}
+
+		B := CreateBody(&position, collider, number("mass", 1))
+		switch kind := lua.LVAsString(opts.RawGetString("type")); kind {
+		case "", "dynamic":
+		case "static":
+			B.Type = BodyStatic
+		case "kinematic":
+			B.Type = BodyKinematic
+		default:
+			L.ArgError(1, fmt.Sprintf("unknown body type '%s'", kind))
+		}
+
+		B.Velocity = shed.Vec2(number("vx", 0), number("vy", 0))
+		B.AngularVelocity = number("spin", 0)
+		B.Drag = number("drag", 0)
+		B.AngularDrag = number("angularDrag", 0)
+		B.Restitution = number("restitution", 0)
+		B.Friction = number("friction", 0)
+		B.GravityScale = number("gravityScale", 1)
+		B.FixedRotation = lua.LVAsBool(opts.RawGetString("fixedRotation"))
+
+		body := &LuaBody{body: B, world: W, data: opts.RawGetString("data")}
+		B.Data = body
+		W.Add(B)
+
+		L.Push(luar.New(L, body))
+		return 1
+	}))
+
+	L.SetGlobal("Gravity", luar.New(L, func(x, y float32) {
+		W.Gravity = shed.Vec2(x, y)
+	}))
+
+	L.SetGlobal("OnCollision", L.NewFunction(func(L *lua.LState) int {
+		fn := L.OptFunction(1, nil)
+		if fn == nil {
+			W.OnCollision = nil
+			return 0
+		}
+
+		W.OnCollision = func(A, B *Body, contact shed.Contact) {
+			a, okA := A.Data.(*LuaBody)
+			b, okB := B.Data.(*LuaBody)
+			if !okA || !okB {
+				return // not made by the script
+			}
+
+			err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true},
+				luar.New(L, a), luar.New(L, b),
+				lua.LNumber(contact.Normal.X), lua.LNumber(contact.Normal.Y), lua.LNumber(contact.Depth))
+			if err != nil {
+				if onError == nil {
+					shed.GlPanic(err)
+				}
+				onError(err)
+			}
+		}
+		return 0
+	}))
+
+	L.SetGlobal("RemoveAllBodies", luar.New(L, W.Clear))
+}
+
+// A body, as lua scripts see it. Vectors are passed as two numbers
+type LuaBody struct {
+	body  *Body
+	world *PhysicsWorld
+	data  lua.LValue
+}
+
+func (B *LuaBody) XY() (float32, float32) {
+	x, y, _ := B.body.Position.GetXYA()
+	return x, y
+}
+
+func (B *LuaBody) SetXY(x, y float32) {
+	B.body.Position.SetXY(x, y)
+}
+
+func (B *LuaBody) Angle() float32 {
+	_, _, angle := B.body.Position.GetXYA()
+	return angle
+}
+
+func (B *LuaBody) SetAngle(angle float32) {
+	B.body.Position.SetAngle(angle)
+}
+
+func (B *LuaBody) Velocity() (float32, float32) {
+	return B.body.Velocity.X, B.body.Velocity.Y
+}
+
+func (B *LuaBody) SetVelocity(x, y float32) {
+	B.body.Velocity = shed.Vec2(x, y)
+}
+
+func (B *LuaBody) Spin() float32 {
+	return B.body.AngularVelocity
+}
+
+func (B *LuaBody) SetSpin(radiansPerSecond float32) {
+	B.body.AngularVelocity = radiansPerSecond
+}
+
+func (B *LuaBody) ApplyForce(x, y float32) {
+	B.body.ApplyForce(shed.Vec2(x, y))
+}
+
+func (B *LuaBody) ApplyImpulse(x, y float32) {
+	B.body.ApplyImpulse(shed.Vec2(x, y))
+}
+
+func (B *LuaBody) ApplyTorque(torque float32) {
+	B.body.ApplyTorque(torque)
+}
+
+// The width and height of boxes. Circles return their diameter twice
+func (B *LuaBody) Size() (float32, float32) {
+	return B.body.Position.GetScale()
+}
+
+// The data option the body was made with
+func (B *LuaBody) Data() lua.LValue {
+	return B.data
+}
+
+func (B *LuaBody) Remove() {
+	B.world.Remove(B.body)
+}
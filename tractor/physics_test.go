@@ -0,0 +1,181 @@
+package tractor
+
+import (
+	"goat/shed"
+	"math"
+	"testing"
+)
+
+// A box body of the given size, with its center at x, y
+func boxBody(x, y, w, h float32, create func(P *Position, C *Collider) *Body) *Body {
+	P := CreatePosition()
+	P.SetXY(x, y)
+	P.SetScale(w, h)
+	return create(&P, CreateBoxCollider(&P))
+}
+
+func dynamicBody(mass float32) func(P *Position, C *Collider) *Body {
+	return func(P *Position, C *Collider) *Body { return CreateBody(P, C, mass) }
+}
+
+func TestPhysicsAdvance(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxSteps int
+		dts      []float32
+		steps    []int   // returned by each Advance
+		alpha    float32 // after the last one
+	}{
+		{"less than a step", 5, []float32{0.05}, []int{0}, 0.4},
+		{"left over time is kept", 5, []float32{0.3, 0.2}, []int{2, 2}, 0},
+		{"steps add up", 5, []float32{0.1, 0.1, 0.1}, []int{0, 1, 1}, 0.4},
+		{"too slow to catch up", 3, []float32{1, 0.05}, []int{3, 0}, 0.4},
+		{"no limit", 0, []float32{1}, []int{8}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			W := CreatePhysicsWorld(10)
+			W.Step = 0.125
+			W.MaxSteps = test.maxSteps
+
+			B := boxBody(0, 0, 1, 1, dynamicBody(1))
+			B.Velocity = shed.Vec2(8, 0) // one unit per step
+			W.Add(B)
+
+			total := 0
+			for i, dt := range test.dts {
+				if steps := W.Advance(dt); steps != test.steps[i] {
+					t.Fatalf("Advance(%v) ran %d steps, expected %d", dt, steps, test.steps[i])
+				}
+				total += test.steps[i]
+			}
+
+			if alpha := W.Alpha(); math.Abs(float64(alpha-test.alpha)) > 1e-5 {
+				t.Fatalf("alpha is %v, expected %v", alpha, test.alpha)
+			}
+			if x, _, _ := B.Position.GetXYA(); math.Abs(float64(x-float32(total))) > 1e-5 {
+				t.Fatalf("the body moved to %v in %d steps", x, total)
+			}
+
+			// Between the last two steps
+			x, _, _ := B.Interpolated(0.5)
+			if total > 0 && math.Abs(float64(x-(float32(total)-0.5))) > 1e-5 {
+				t.Fatalf("halfway through the last step, the body is at %v", x)
+			}
+		})
+	}
+}
+
+func TestPhysicsBodyTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   func(P *Position, C *Collider) *Body
+		velocity shed.V2 // before the impulse
+		after    shed.V2 // velocity after the impulse
+		moved    shed.V2 // position after one step
+	}{
+		{"dynamic", dynamicBody(2), shed.V2{}, shed.Vec2(2, 0), shed.Vec2(0.25, -0.125)}, // pushed, and falls
+		{"kinematic", CreateKinematicBody, shed.Vec2(1, 0), shed.Vec2(1, 0), shed.Vec2(0.125, 0)},
+		{"static", CreateStaticBody, shed.Vec2(1, 0), shed.Vec2(1, 0), shed.V2{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			W := CreatePhysicsWorld(10)
+			W.Step = 0.125
+			W.Gravity = shed.Vec2(0, -8)
+
+			B := boxBody(0, 0, 1, 1, test.create)
+			B.Velocity = test.velocity
+			W.Add(B)
+
+			B.ApplyImpulse(shed.Vec2(4, 0))
+			if !closeTo(B.Velocity, test.after) {
+				t.Fatalf("after the impulse, the velocity is %v, expected %v", B.Velocity, test.after)
+			}
+
+			W.StepOnce()
+			if x, y, _ := B.Position.GetXYA(); !closeTo(shed.Vec2(x, y), test.moved) {
+				t.Fatalf("after a step, the body is at %v, expected %v", shed.Vec2(x, y), test.moved)
+			}
+		})
+	}
+}
+
+func TestPhysicsComesToRest(t *testing.T) {
+	W := CreatePhysicsWorld(4)
+	W.Gravity = shed.Vec2(0, -10)
+	W.MaxSteps = 0
+
+	ground := boxBody(0, -0.5, 10, 1, CreateStaticBody) // the top is at y = 0
+	box := boxBody(0, 2, 1, 1, dynamicBody(1))
+	W.Add(ground, box)
+
+	W.Advance(3)
+	_, restingY, _ := box.Position.GetXYA()
+	W.Advance(1)
+	x, y, angle := box.Position.GetXYA()
+
+	// The overlap that is left is the slop, plus what gravity pulls it in during a step
+	depth := 0.5 - y
+	allowed := physicsSlop + 10*W.Step*W.Step
+	if depth < 0 || depth > allowed {
+		t.Fatalf("the box rests %v deep in the ground, expected at most %v", depth, allowed)
+	}
+	if math.Abs(float64(y-restingY)) > 1e-4 || x != 0 || angle != 0 {
+		t.Fatalf("the box does not rest: it went from y = %v to %v, x = %v, angle = %v", restingY, y, x, angle)
+	}
+	if speed := box.Velocity.Len(); speed > 1e-3 {
+		t.Fatalf("the resting box moves at %v", speed)
+	}
+	if gx, gy, _ := ground.Position.GetXYA(); gx != 0 || gy != -0.5 {
+		t.Fatalf("the static ground moved to %v, %v", gx, gy)
+	}
+}
+
+func TestPhysicsRestitution(t *testing.T) {
+	tests := []struct {
+		name        string
+		restitution float32
+		other       func(P *Position, C *Collider) *Body
+		after       shed.V2 // velocity of the moving box after the collision
+		otherAfter  shed.V2
+	}{
+		{"bounces off a wall", 1, CreateStaticBody, shed.Vec2(-5, 0), shed.V2{}},
+		{"stops at a wall", 0, CreateStaticBody, shed.V2{}, shed.V2{}},
+		{"hands its speed to an equal box", 1, dynamicBody(1), shed.V2{}, shed.Vec2(5, 0)},
+		{"pushes an equal box along", 0, dynamicBody(1), shed.Vec2(2.5, 0), shed.Vec2(2.5, 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			W := CreatePhysicsWorld(4)
+			W.MaxSteps = 0
+
+			moving := boxBody(0, 0, 1, 1, dynamicBody(1))
+			moving.Velocity = shed.Vec2(5, 0)
+			moving.Restitution = test.restitution
+			other := boxBody(2, 0, 1, 1, test.other)
+			W.Add(moving, other)
+
+			collisions := 0
+			W.OnCollision = func(A, B *Body, contact shed.Contact) { collisions++ }
+
+			// They meet after a little over 0.2 seconds. Stop before they could meet again
+			for i := 0; i < 20 && collisions == 0; i++ {
+				W.StepOnce()
+			}
+
+			if collisions != 1 {
+				t.Fatalf("%d collisions, expected one", collisions)
+			}
+			if !closeTo(moving.Velocity, test.after) || !closeTo(other.Velocity, test.otherAfter) {
+				t.Fatalf("the velocities are %v and %v, expected %v and %v", moving.Velocity, other.Velocity, test.after, test.otherAfter)
+			}
+			if moving.AngularVelocity != 0 || other.AngularVelocity != 0 {
+				t.Fatalf("a head-on collision made the boxes spin")
+			}
+		})
+	}
+}
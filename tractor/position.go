@@ -9,8 +9,9 @@ import (
 
 // Cache transformation
 type Position struct {
-	cacheValid  bool
-	matrixCache mgl32.Mat3
+	cacheValid   bool
+	matrixCache  mgl32.Mat3
+	cacheVersion uint64 // incremented every time matrixCache is recalculated. Lets a Node know its world matrix is stale.
 	// Location
 	x             float32 // position on x-axis
 	y             float32 // position on y-axis
@@ -180,6 +181,16 @@ func (P *Position) SetScale(sx, sy float32) {
 	P.scaleY = sy
 }
 
+func (P *Position) GetScale() (sx, sy float32) {
+	return P.scaleX, P.scaleY
+}
+
+// Allow negative scales, which flip (mirror) the object
+func (P *Position) AllowNegativeScale(allow bool) {
+	P.allowNegScale = allow
+	P.cacheValid = false
+}
+
 func (P *Position) LimitScale(minX, minY, maxX, maxY float32) {
 	P.minScaleX = minX
 	P.minScaleY = minY
@@ -207,7 +218,36 @@ func (P *Position) GetMatrix() mgl32.Mat3 {
 	if !P.cacheValid {
 		P.matrixCache = P.createMatrix()
 		P.cacheValid = true
+		P.cacheVersion++
 	}
 
 	return P.matrixCache
 }
+
+// The location, angle and scale of a Position, without the limits and the cache
+type PositionState struct {
+	X, Y           float32
+	Angle          float32
+	ScaleX, ScaleY float32
+}
+
+func (P *Position) GetState() PositionState {
+	return PositionState{X: P.x, Y: P.y, Angle: P.angle, ScaleX: P.scaleX, ScaleY: P.scaleY}
+}
+
+func (P *Position) SetState(S PositionState) {
+	P.SetXY(S.X, S.Y)
+	P.SetAngle(S.Angle)
+	P.SetScale(S.ScaleX, S.ScaleY)
+}
+
+// The state amt of the way from S to other
+func (S PositionState) Lerp(other PositionState, amt float32) PositionState {
+	return PositionState{
+		X:      h.LerpU(S.X, other.X, amt),
+		Y:      h.LerpU(S.Y, other.Y, amt),
+		Angle:  h.LerpU(S.Angle, other.Angle, amt),
+		ScaleX: h.LerpU(S.ScaleX, other.ScaleX, amt),
+		ScaleY: h.LerpU(S.ScaleY, other.ScaleY, amt),
+	}
+}
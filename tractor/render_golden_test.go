@@ -0,0 +1,176 @@
+package tractor
+
+import (
+	"flag"
+	"goat/shed"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "render testdata/golden/*.png again")
+
+const (
+	goldenSize      = 96
+	goldenTolerance = 2 // per channel. The rasterizer is deterministic, but float rounding may differ between platforms
+)
+
+// A checkerboard with a red and a blue corner, so flips and sub-textures show
+func checkerTexture() *shed.TextureWrapper {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.RGBA{40, 40, 40, 255}
+			switch {
+			case x < 2 && y < 2:
+				c = color.RGBA{255, 0, 0, 255}
+			case x >= 6 && y >= 6:
+				c = color.RGBA{0, 0, 255, 255}
+			case (x+y)%2 == 0:
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	tex, err := shed.CreateTexture(img, 0, 0)
+	shed.GlPanicIfErrNotNil(err)
+	return tex
+}
+
+// Every scene is drawn with the screen camera: one unit per pixel, (0, 0) in the middle
+var goldenScenes = []struct {
+	name string
+	draw func(cam *Camera)
+}{
+	{"rects", func(cam *Camera) {
+		R := CreateBasicRectRenderer("shaders/rect")
+		R.Finalize()
+
+		a := CreateBasicRect(-10, 10, 50, 30, 0, cam, R)
+		a.Color = shed.V4{C1: 1, C2: 0, C3: 0, C4: 1}
+		b := CreateBasicRect(10, -10, 40, 40, mgl32.DegToRad(30), cam, R)
+		b.Color = shed.V4{C1: 0, C2: 1, C3: 0, C4: 0.5}
+		a.Draw()
+		b.Draw()
+
+		line := CreateBasicLine(-40, -40, 40, -20, 3, cam, R)
+		line.SetColor(shed.V4{C1: 1, C2: 1, C3: 0, C4: 1})
+		line.Draw()
+	}},
+	{"fancy_rect", func(cam *Camera) {
+		R := CreateFancyRectRenderer("shaders/fancy_rect")
+		R.Finalize()
+
+		rounded := CreateFancyRect(0, 12, 70, 40, mgl32.DegToRad(-10), cam, R)
+		rounded.Fill = shed.V4{C1: 0.2, C2: 0.4, C3: 1, C4: 1}
+		rounded.Stroke = shed.OPAQ_WHITE()
+		rounded.StrokeWidth = 4
+		rounded.Radius = shed.V4{C1: 16, C2: 4, C3: 16, C4: 0}
+		rounded.Draw()
+
+		// No radius: the anti-aliased edges are the only thing that is not square
+		square := CreateFancyRect(-20, -28, 30, 20, mgl32.DegToRad(20), cam, R)
+		square.Fill = shed.V4{C1: 1, C2: 0.6, C3: 0, C4: 1}
+		square.Draw()
+	}},
+	{"line_strip", func(cam *Camera) {
+		R := CreateLineStripRenderer("shaders/line_strip")
+		R.Finalize()
+
+		zigzag := CreateLineStrip([]shed.V2{{X: -40, Y: -30}, {X: -15, Y: 30}, {X: 10, Y: -30}, {X: 35, Y: 30}}, 8, cam, R)
+		zigzag.Colors = []shed.V4{{C1: 1, C4: 1}, {C2: 1, C4: 1}, {C3: 1, C4: 1}, {C1: 1, C2: 1, C3: 1, C4: 1}}
+		zigzag.Join = shed.JoinRound
+		zigzag.Cap = shed.CapRound
+		zigzag.Draw()
+
+		dashed := CreateLineStrip([]shed.V2{{X: -40, Y: 40}, {X: 40, Y: 40}}, 3, cam, R)
+		dashed.Dashes = []float32{8, 4}
+		dashed.Draw()
+	}},
+	{"ellipse", func(cam *Camera) {
+		R := CreatePrimitiveRenderer("shaders/rect")
+		R.Finalize()
+
+		E := CreateEllipse(-15, 10, 50, 30, cam, R)
+		E.Fill = shed.V4{C1: 0, C2: 0.8, C3: 0.8, C4: 1}
+		E.Stroke = shed.OPAQ_WHITE()
+		E.StrokeWidth = 3
+		E.Draw()
+
+		pie := CreateArc(20, -20, 40, 40, 0, mgl32.DegToRad(270), cam, R)
+		pie.Fill = shed.V4{C1: 1, C2: 0.3, C3: 0.3, C4: 0.8}
+		pie.SetPie(true)
+		pie.Draw()
+	}},
+	{"path", func(cam *Camera) {
+		R := CreatePrimitiveRenderer("shaders/rect")
+		R.Finalize()
+
+		// An O, with a hole
+		S, err := CreateSVGPathShape(-30, 30, "M0 0 H60 V60 H0 Z M15 15 V45 H45 V15 Z", cam, R)
+		shed.GlPanicIfErrNotNil(err)
+		S.Fill = shed.V4{C1: 1, C2: 0.8, C3: 0, C4: 1}
+		S.Stroke = shed.V4{C1: 1, C4: 1}
+		S.Style.Width = 2
+		S.Draw()
+	}},
+	{"sprite_batch", func(cam *Camera) {
+		batch := CreateSpriteBatch("shaders/sprite_batch")
+		batch.Finalize()
+		tex := checkerTexture()
+
+		whole := shed.V4{C1: 0, C2: 0, C3: 1, C4: 1}
+		batch.AddQuad(tex, cam, mgl32.Translate2D(-20, 20).Mul3(mgl32.Scale2D(40, 40)), whole, shed.OPAQ_WHITE(), 0)
+		batch.AddQuad(tex, cam, mgl32.Translate2D(20, -20).Mul3(mgl32.HomogRotate2D(mgl32.DegToRad(45))).Mul3(mgl32.Scale2D(40, 40)),
+			shed.V4{C1: 0.5, C2: 0.5, C3: 1, C4: 1}, shed.V4{C1: 0, C2: 1, C3: 0, C4: 1}, 0.5)
+		batch.Flush()
+	}},
+	{"text", func(cam *Camera) {
+		ttf, err := shed.ParseTrueType(goregular.TTF)
+		shed.GlPanicIfErrNotNil(err)
+
+		F := CreateFont(TextShader, "goregular", ttf)
+		F.Finalize()
+		F.Draw(cam, 24, -44, 10, "Goat\nAVy", shed.V4{C1: 1, C2: 1, C3: 0.5, C4: 1})
+		F.Batch.Flush()
+	}},
+}
+
+// Draw each scene with the software backend and compare it to testdata/golden/<scene>.png.
+// go test -run Golden -update-golden renders the golden images again. Look at them before committing
+func TestRenderGolden(t *testing.T) {
+	for _, scene := range goldenScenes {
+		t.Run(scene.name, func(t *testing.T) {
+			StartMainHeadless(&WindowOptions{Width: goldenSize, Height: goldenSize}, 1) // the renderers draw with the global Engine
+			W := Engine
+			W.Backend.(*SoftBackend).ClearColor = shed.V4{C1: 0.1, C2: 0.1, C3: 0.15, C4: 1}
+
+			W.Backend.BeginFrame()
+			scene.draw(W.GetScreenCamera())
+			W.flushActiveBatch()
+
+			frame, err := W.CaptureFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "golden", scene.name+".png")
+			if *updateGolden {
+				if err := shed.SavePNG(golden, frame); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			diff := filepath.Join(os.TempDir(), "goat_golden_"+scene.name+".diff.png")
+			if err := shed.CompareWithGoldenFile(golden, frame, goldenTolerance, diff); err != nil {
+				t.Fatalf("%v (diff written to '%s')", err, diff)
+			}
+		})
+	}
+}
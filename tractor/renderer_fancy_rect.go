@@ -0,0 +1,90 @@
+package tractor
+
+import (
+	u "goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || Fancy Rect Renderer
+// ||
+// || Render a rect with rounded
+// || corners and a stroke, using
+// || a signed distance field.
+// ||=============================
+type FancyRectRenderer struct {
+	Shader     *u.ShaderProgram // Only used by the opengl backend. Loaded during Finalize()
+	shaderName string
+
+	// Uniform variables to send to the shader
+	UniSize        u.V2    // width and height, in world units
+	UniFeather     u.V2    // how far the quad is grown on each side for the anti-aliased fringe, in world units. One pixel. Set by Draw()
+	UniRadius      u.V4    // corner radii: top left, top right, bottom right, bottom left
+	UniStrokeWidth float32 // the stroke is inside the edge
+	UniFillColor   u.V4
+	UniStrokeColor u.V4
+
+	// Buffer initialization stuff
+	buffersReady bool
+	vaoHandle    uint32
+	bufferHandle uint32 // we only have the vertex buffer.
+}
+
+// The shader must have the same attributes and uniforms as shaders/fancy_rect
+func CreateFancyRectRenderer(shaderFileBaseName string) *FancyRectRenderer {
+	return &FancyRectRenderer{
+		shaderName:     shaderFileBaseName,
+		UniFillColor:   u.OPAQ_WHITE(),
+		UniStrokeColor: u.OPAQ_WHITE(),
+	}
+}
+
+// Prepare the renderer for drawing (upload buffers, compile shaders, etc.)
+func (R *FancyRectRenderer) Finalize() {
+	Engine.Backend.FinalizeFancyRect(R)
+}
+
+// Draw the unit quad transformed by objTranslationMatrix.
+// The uniforms must be set first. UniSize must match the size of the transformed quad.
+func (R *FancyRectRenderer) Draw(camMatrix, objTranslationMatrix mgl32.Mat3) {
+
+	trMatrix := camMatrix.Mul3(objTranslationMatrix)
+
+	// The size of the quad in pixels
+	w, h := Engine.Backend.FramebufferSize()
+	quadW := u.V2{X: trMatrix[0] * float32(w) / 2, Y: trMatrix[1] * float32(h) / 2}.Len()
+	quadH := u.V2{X: trMatrix[3] * float32(w) / 2, Y: trMatrix[4] * float32(h) / 2}.Len()
+	if quadW == 0 || quadH == 0 {
+		return
+	}
+	R.UniFeather = u.V2{X: R.UniSize.X / quadW, Y: R.UniSize.Y / quadH}
+
+	Engine.flushActiveBatch()
+	Engine.DrawCalls++
+
+	Engine.Backend.DrawFancyRect(R, trMatrix)
+}
+
+// The signed distance from p to the edge of the rect. Negative inside.
+// Mimics roundedBox() in shaders/fancy_rect.frag
+func (R *FancyRectRenderer) distance(p u.V2) float32 {
+	var r float32
+	switch {
+	case p.X < 0 && p.Y > 0:
+		r = R.UniRadius.C1
+	case p.X >= 0 && p.Y > 0:
+		r = R.UniRadius.C2
+	case p.X >= 0:
+		r = R.UniRadius.C3
+	default:
+		r = R.UniRadius.C4
+	}
+
+	qx := mgl32.Abs(p.X) - R.UniSize.X/2 + r
+	qy := mgl32.Abs(p.Y) - R.UniSize.Y/2 + r
+	outside := u.V2{X: u.Max(qx, 0), Y: u.Max(qy, 0)}
+
+	return u.Min(u.Max(qx, qy), 0) + outside.Len() - r
+}
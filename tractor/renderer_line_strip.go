@@ -0,0 +1,56 @@
+package tractor
+
+import (
+	u "goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || Line Strip Renderer
+// ||
+// || Render triangles with a color
+// || per vertex. The vertices are
+// || streamed to the GPU on every
+// || draw.
+// ||=============================
+type LineStripRenderer struct {
+	Shader     *u.ShaderProgram // Only used by the opengl backend. Loaded during Finalize()
+	shaderName string
+
+	// Buffer initialization stuff
+	buffersReady bool
+	vaoHandle    uint32
+	bufferHandle uint32
+}
+
+// A vertex with a color, in the exact layout it is uploaded to the GPU.
+type LineVertex = u.StrokeVertex
+
+// The shader must have the same attributes and uniforms as shaders/line_strip
+func CreateLineStripRenderer(shaderFileBaseName string) *LineStripRenderer {
+	return &LineStripRenderer{
+		shaderName: shaderFileBaseName,
+	}
+}
+
+// Prepare the renderer for drawing (upload buffers, compile shaders, etc.)
+func (R *LineStripRenderer) Finalize() {
+	Engine.Backend.FinalizeLineStrip(R)
+}
+
+// Draw a list of triangles. Every three vertices make a triangle.
+// The vertices are transformed by objMatrix, and then by camMatrix.
+func (R *LineStripRenderer) Draw(camMatrix, objMatrix mgl32.Mat3, vertices []LineVertex) {
+	if len(vertices) < 3 {
+		return
+	}
+
+	Engine.flushActiveBatch()
+	Engine.DrawCalls++
+
+	trMatrix := camMatrix.Mul3(objMatrix)
+
+	Engine.Backend.DrawLineStrip(R, trMatrix, vertices[:len(vertices)/3*3])
+}
@@ -0,0 +1,69 @@
+package tractor
+
+import (
+	u "goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || Primitive Renderer
+// ||
+// || Render arbitrary triangles
+// || in a single color.
+// || The vertices are streamed to
+// || the GPU on every draw.
+// ||=============================
+type PrimitiveRenderer struct {
+	Shader     *u.ShaderProgram // Only used by the opengl backend. Loaded during Finalize()
+	shaderName string
+
+	vertices []float32 // x, y, z per vertex. Reused by the opengl backend between draws
+
+	// Buffer initialization stuff
+	buffersReady bool
+	vaoHandle    uint32
+	bufferHandle uint32
+}
+
+// The shader must have the same attributes and uniforms as shaders/rect
+func CreatePrimitiveRenderer(shaderFileBaseName string) *PrimitiveRenderer {
+	return &PrimitiveRenderer{
+		shaderName: shaderFileBaseName,
+	}
+}
+
+// Prepare the renderer for drawing (upload buffers, compile shaders, etc.)
+func (R *PrimitiveRenderer) Finalize() {
+	Engine.Backend.FinalizePrimitives(R)
+}
+
+// Draw a list of triangles. Every three vertices make a triangle.
+// The vertices are transformed by objMatrix, and then by camMatrix.
+func (R *PrimitiveRenderer) DrawTriangles(camMatrix, objMatrix mgl32.Mat3, vertices []u.V2, color u.V4) {
+	if len(vertices) < 3 {
+		return
+	}
+
+	Engine.flushActiveBatch()
+	Engine.DrawCalls++
+
+	trMatrix := camMatrix.Mul3(objMatrix)
+
+	Engine.Backend.DrawPrimitives(R, trMatrix, vertices[:len(vertices)/3*3], color)
+}
+
+// Draw a convex polygon as a fan of triangles around its first vertex.
+func (R *PrimitiveRenderer) DrawConvexPolygon(camMatrix, objMatrix mgl32.Mat3, vertices []u.V2, color u.V4) {
+	if len(vertices) < 3 {
+		return
+	}
+
+	triangles := make([]u.V2, 0, 3*(len(vertices)-2))
+	for i := 1; i+1 < len(vertices); i++ {
+		triangles = append(triangles, vertices[0], vertices[i], vertices[i+1])
+	}
+
+	R.DrawTriangles(camMatrix, objMatrix, triangles, color)
+}
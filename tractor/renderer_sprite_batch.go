@@ -0,0 +1,116 @@
+package tractor
+
+import (
+	u "goat/shed"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ||=============================
+// ||
+// || Sprite Batch
+// ||
+// || Collect many sprites that share
+// || a texture (typically an atlas)
+// || and draw them with a single
+// || instanced draw call.
+// ||=============================
+type SpriteBatch struct {
+	Shader     *u.ShaderProgram // Only used by the opengl backend. Loaded during Finalize()
+	shaderName string
+	Tint       bool // The shader multiplies texture and color instead of mixing them (like shaders/text). The software backend needs to know.
+
+	texture   *u.TextureWrapper // texture of the sprites currently in the batch. Owned by the caller, not the batch
+	camera    *Camera           // camera of the sprites currently in the batch
+	instances []batchInstance   // the sprites currently in the batch
+
+	// Buffer initialization stuff
+	buffersReady   bool
+	vaoHandle      uint32
+	quadHandle     uint32 // the vertices and tex coords of the quad. Static.
+	instanceHandle uint32 // the per-sprite data. Re-uploaded on every flush.
+}
+
+// Per-sprite data, in the exact layout it is uploaded to the GPU.
+// Only float32 fields, so there is no padding.
+type batchInstance struct {
+	transform mgl32.Mat3
+	subTexPos u.V4
+	color     u.V4
+	colorMix  float32
+}
+
+// The shader must have the same attributes and uniforms as shaders/sprite_batch
+func CreateSpriteBatch(shaderFileBasename string) *SpriteBatch {
+	return &SpriteBatch{
+		shaderName: shaderFileBasename,
+		instances:  make([]batchInstance, 0, 1024),
+	}
+}
+
+// Prepare the batch for drawing (upload buffers, compile shaders, etc.)
+// Textures are not part of the batch: they are uploaded when they are first drawn,
+// and belong to the caller, who must keep them alive while sprites using them are in the batch.
+func (B *SpriteBatch) Finalize() {
+	Engine.Backend.FinalizeSpriteBatch(B)
+}
+
+// Add a sprite to the batch.
+//
+// If the sprite uses a different texture or camera than the sprites already
+// in the batch, the batch is flushed first.
+// Drawing anything with another renderer (i.e. another shader) also flushes the batch,
+// so the drawing order is preserved. So does the end of the frame.
+func (B *SpriteBatch) Add(S *Sprite) {
+	if S.Deleted {
+		return
+	}
+
+	B.AddQuad(S.Renderer.Texture, S.Camera, S.GetMatrix(), S.UniSubTexPos, S.UniColor, S.UniColorMix)
+}
+
+// Add a textured quad to the batch, without having a Sprite for it.
+// Same flushing rules as Add()
+func (B *SpriteBatch) AddQuad(tex *u.TextureWrapper, camera *Camera, transform mgl32.Mat3, subTexPos, color u.V4, colorMix float32) {
+
+	if Engine.activeBatch != B {
+		Engine.flushActiveBatch()
+	}
+
+	if len(B.instances) > 0 && (tex != B.texture || camera != B.camera) {
+		B.Flush()
+	}
+
+	Engine.activeBatch = B
+
+	B.texture = tex
+	B.camera = camera
+	B.instances = append(B.instances, batchInstance{
+		transform: transform,
+		subTexPos: subTexPos,
+		color:     color,
+		colorMix:  colorMix,
+	})
+}
+
+// Number of sprites waiting to be drawn
+func (B *SpriteBatch) Len() int {
+	return len(B.instances)
+}
+
+// Draw all the sprites in the batch, and empty it.
+// Engine.Loop() does this automatically at the end of each frame.
+func (B *SpriteBatch) Flush() {
+	if Engine.activeBatch == B {
+		Engine.activeBatch = nil
+	}
+
+	if len(B.instances) == 0 {
+		return
+	}
+
+	Engine.DrawCalls++
+	Engine.Backend.DrawSpriteBatch(B, B.camera.GetMatrix())
+
+	B.instances = B.instances[:0]
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	u "goat/shed"
 
-	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -15,9 +14,10 @@ import (
 // || and map into subtextures.
 // ||=============================
 type TexQuadRenderer struct {
-	Shader  *u.ShaderProgram
-	Texture *u.TextureWrapper
-	Atlas   *u.AtlasDescriptor // may be nil
+	Shader     *u.ShaderProgram // Only used by the opengl backend. Loaded during Finalize()
+	shaderName string
+	Texture    *u.TextureWrapper
+	Atlas      *u.AtlasDescriptor // may be nil
 
 	// Uniform variables to send to the shader
 	UniColor     u.V4
@@ -49,9 +49,6 @@ type TexQuadRenderer struct {
 // ||  indexes: indeces used in the element array
 // || ========================================================================================================================================================================
 func CreateTexAtlasRenderer(shaderFileBasename, atlas, subTexName string) *TexQuadRenderer {
-	shader, err := Engine.GetShader(shaderFileBasename)
-	u.GlPanicIfErrNotNil(err)
-
 	atlasDescriptor := Engine.LoadTextureAtlas(atlas)
 
 	subTexInfo := atlasDescriptor.GetSubTexture(subTexName)
@@ -59,7 +56,7 @@ func CreateTexAtlasRenderer(shaderFileBasename, atlas, subTexName string) *TexQu
 	w, h := atlasDescriptor.Texture.GetSize()
 
 	s := TexQuadRenderer{
-		Shader:       shader,
+		shaderName:   shaderFileBasename,
 		Texture:      atlasDescriptor.Texture,
 		Atlas:        atlasDescriptor,
 		UniColor:     u.V4{},
@@ -82,11 +79,8 @@ func CreateTexQuadRenderer(shaderAlias, textureAlias string) *TexQuadRenderer {
 	tex, err := Engine.GetTexture(textureAlias)
 	u.GlPanicIfErrNotNil(err)
 
-	shader, err := Engine.GetShader(shaderAlias)
-	u.GlPanicIfErrNotNil(err)
-
 	T := TexQuadRenderer{
-		Shader:       shader,
+		shaderName:   shaderAlias,
 		Texture:      tex,
 		UniColor:     u.OPAQ_WHITE(),
 		UniColorMix:  0.5, // mix tex and unicolor equally. good for debugging
@@ -97,62 +91,9 @@ func CreateTexQuadRenderer(shaderAlias, textureAlias string) *TexQuadRenderer {
 	return &T
 }
 
+// Prepare the renderer for drawing (upload textures and buffers, compile shaders, etc.)
 func (R *TexQuadRenderer) Finalize() {
-
-	if R.finalized {
-		return
-	}
-
-	R.Shader.Use()
-
-	R.Texture.Finalize()
-
-	if R.buffersReady {
-		return
-	}
-
-	// Create interleaved array of verts and
-	// texture coordinates.
-	const vt_bytes_pr_stride = u.F32_SIZE * 5   // 4 bytes per float and 5 floats per stride/vert
-	const vt_floats_total = 4 * 5               // 4 strides and 5 floats per stride
-	const vt_len = u.F32_SIZE * vt_floats_total // total length (in bytes) of the VT buffer
-	const Z, HI, LO = 1.0, 0.5, -0.5            // convenience
-	vt_buffer := [vt_floats_total]float32{
-		HI, HI, Z /* <== Vert | Tex ==> */, 1, 1,
-		LO, HI, Z /* <== Vert | Tex ==> */, 0, 1,
-		LO, LO, Z /* <== Vert | Tex ==> */, 0, 0,
-		HI, LO, Z /* <== Vert | Tex ==> */, 1, 0,
-	}
-	vt_ptr := u.GlPtr32f(&vt_buffer[0])
-
-	// Create buffer
-	gl.GenBuffers(1, &R.bufferHandle)
-
-	//
-	// Vertex Array Object
-	gl.GenVertexArrays(1, &R.vaoHandle)
-	gl.BindVertexArray(R.vaoHandle)
-	defer gl.BindVertexArray(0)
-
-	//
-	// Vertex Buffer Object
-	gl.BindBuffer(gl.ARRAY_BUFFER, R.bufferHandle)
-	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BufferData(gl.ARRAY_BUFFER, vt_len, vt_ptr, gl.STATIC_DRAW)
-
-	R.Shader.EnableVertexAttribArray("iVert")
-	R.Shader.VertexAttribPointer("iVert", 3, gl.FLOAT, false, vt_bytes_pr_stride, 0)
-	defer R.Shader.DisableVertexAttribArray("iVert")
-
-	//
-	// Texture Buffers
-	R.Texture.Bind()
-	defer R.Texture.Unbind()
-	R.Shader.EnableVertexAttribArray("iTexCoord")
-	R.Shader.VertexAttribPointer("iTexCoord", 2, gl.FLOAT, false, vt_bytes_pr_stride, 3*u.F32_SIZE)
-	defer R.Shader.DisableVertexAttribArray("iTexCoord")
-
-	R.finalized = true
+	Engine.Backend.FinalizeTexQuad(R)
 }
 
 func (R *TexQuadRenderer) MustUseSubTextureByName(name string) {
@@ -163,26 +104,19 @@ func (R *TexQuadRenderer) MustUseSubTextureByName(name string) {
 
 func (R *TexQuadRenderer) Draw(camMatrix, objTranslationMatrix mgl32.Mat3) {
 
-	R.Shader.Use()
-	gl.BindVertexArray(R.vaoHandle)
-	R.Texture.Bind()
+	Engine.flushActiveBatch()
+	Engine.DrawCalls++
 
 	trMatrix := camMatrix.Mul3(objTranslationMatrix)
 
-	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColor", R.UniColor))
-	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniColorMix", R.UniColorMix))
-	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniSubTexPos", R.UniSubTexPos))
-	u.GlPanicIfErrNotNil(R.Shader.SetUniformAttr("uniTransformation", trMatrix))
-
-	gl.DrawArrays(gl.TRIANGLE_FAN, 0, 4)
-
-	u.AssertGLOK("SpriteRenderable.Draw", R.Shader, 22)
+	Engine.Backend.DrawTexQuad(R, trMatrix)
 }
 
 func (R *TexQuadRenderer) Clone() *TexQuadRenderer {
 
 	return &TexQuadRenderer{
 		Shader:       R.Shader,
+		shaderName:   R.shaderName,
 		Texture:      R.Texture,
 		UniColor:     R.UniColor,
 		UniSubTexPos: R.UniSubTexPos,
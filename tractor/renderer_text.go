@@ -0,0 +1,328 @@
+package tractor
+
+import (
+	"fmt"
+	u "goat/shed"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type TextAlign int
+
+const (
+	AlignLeft   TextAlign = iota // x is the left edge of the text
+	AlignCenter                  // x is the center of the text
+	AlignRight                   // x is the right edge of the text
+)
+
+// ||=============================
+// ||
+// || Text Renderer
+// ||
+// || Draw text using glyphs from
+// || a texture atlas. Each glyph
+// || is a sprite in a SpriteBatch,
+// || so a string is (usually) a
+// || single draw call.
+// ||=============================
+type TextRenderer struct {
+	Atlas  *u.AtlasDescriptor
+	Glyphs *GlyphMap
+	Batch  *SpriteBatch
+
+	Size          float32   // Height of a line of text, in world units (or pixels when drawing in screen space)
+	LineSpacing   float32   // Distance between the baselines of two lines, relative to Size
+	LetterSpacing float32   // Extra space between characters, relative to Size
+	SpaceWidth    float32   // Width of space characters (and other unmapped characters), relative to Size
+	WrapWidth     float32   // Break lines that are wider than this. Zero means "don't wrap"
+	Align         TextAlign // How lines are aligned with the x coordinate given to Draw()
+	Color         u.V4      // Tints the glyphs. White leaves them unchanged
+
+	kerning    map[[2]rune]float32 // extra space between pairs of characters, relative to Size
+	glyphs     map[string]glyphInfo
+	lineHeight float32 // height of the tallest glyph, in pixels
+}
+
+// A glyph, as it is found in the atlas
+type glyphInfo struct {
+	subTexPos u.V4    // flipped vertically, see CreateTextRenderer
+	w, h      float32 // in pixels
+}
+
+// A line of text, laid out
+type textLine struct {
+	runes   []rune
+	width   float32
+	skipped int // runes after the line that are not drawn (newlines, and spaces swallowed by wrapping)
+}
+
+// Create a renderer for text in the given atlas.
+// The shader must have the same attributes and uniforms as TextShader
+//
+// The height of the tallest glyph in the glyph map decides the height of a line.
+// All glyphs are aligned with the bottom of the line.
+func CreateTextRenderer(shaderFileBasename, atlas string, glyphs *GlyphMap) *TextRenderer {
+	descriptor := Engine.LoadTextureAtlas(atlas)
+
+	T := &TextRenderer{
+		Atlas:         descriptor,
+		Glyphs:        glyphs,
+		Batch:         CreateSpriteBatch(shaderFileBasename),
+		Size:          32,
+		LineSpacing:   1.2,
+		LetterSpacing: 0.05,
+		SpaceWidth:    0.5,
+		Align:         AlignLeft,
+		Color:         u.OPAQ_WHITE(),
+		kerning:       make(map[[2]rune]float32),
+		glyphs:        make(map[string]glyphInfo),
+	}
+	T.Batch.Tint = true
+
+	w, h := descriptor.Texture.GetSize()
+	for _, name := range glyphs.glyphs {
+		if _, found := T.glyphs[name]; found {
+			continue
+		}
+
+		sub := descriptor.GetSubTexture(name)
+		if sub == nil {
+			u.GlPanic(fmt.Errorf("glyph '%s' is not in the texture atlas '%s'", name, atlas))
+		}
+
+		// Sprites are drawn upside down, so glyphs are flipped to come out upright.
+		dims := sub.GetDims(float32(w), float32(h))
+		dims.C2, dims.C4 = dims.C4, dims.C2
+
+		T.glyphs[name] = glyphInfo{
+			subTexPos: dims,
+			w:         float32(sub.Width),
+			h:         float32(sub.Height),
+		}
+
+		if float32(sub.Height) > T.lineHeight {
+			T.lineHeight = float32(sub.Height)
+		}
+	}
+
+	return T
+}
+
+// Upload the glyph atlas and prepare the batch for drawing.
+func (T *TextRenderer) Finalize() {
+	Engine.Backend.FinalizeTexture(T.Atlas.Texture)
+	T.Batch.Finalize()
+}
+
+// Add (or remove) space between two characters, relative to Size.
+// Negative amounts move the characters closer together.
+func (T *TextRenderer) SetKerning(left, right rune, amount float32) {
+	if amount == 0 {
+		delete(T.kerning, [2]rune{left, right})
+		return
+	}
+
+	T.kerning[[2]rune{left, right}] = amount
+}
+
+// The width and height the text would have if it was drawn
+func (T *TextRenderer) Measure(str string) (w, h float32) {
+	lines := T.layout(str)
+
+	for _, line := range lines {
+		if line.width > w {
+			w = line.width
+		}
+	}
+
+	return w, T.height(len(lines))
+}
+
+// Draw text in world space. (x, y) is the top of the first line.
+// The horizontal meaning of x depends on Align.
+func (T *TextRenderer) Draw(camera *Camera, x, y float32, str string) {
+	T.DrawColored(camera, x, y, str, nil)
+}
+
+// Draw text in screen space. (x, y) is in pixels from the top-left corner of the window.
+func (T *TextRenderer) DrawScreen(x, y float32, str string) {
+	cam := Engine.GetScreenCamera()
+	w, h := cam.GetFrameSize()
+
+	T.DrawColored(cam, x-w/2, h/2-y, str, nil)
+}
+
+// Draw text with a color per character.
+// color is called with the index of the rune in str (counted in runes, not bytes), and the rune itself.
+// If color is nil, T.Color is used for all characters.
+func (T *TextRenderer) DrawColored(camera *Camera, x, y float32, str string, color func(i int, r rune) u.V4) {
+	scale := T.scale()
+	index := 0
+
+	for n, line := range T.layout(str) {
+		left := x
+		switch T.Align {
+		case AlignCenter:
+			left -= line.width / 2
+		case AlignRight:
+			left -= line.width
+		}
+
+		bottom := y - T.Size - float32(n)*T.Size*T.LineSpacing
+
+		var prev rune
+		for _, r := range line.runes {
+			left += T.kern(prev, r)
+			prev = r
+
+			glyph, found := T.lookup(r)
+			if !found {
+				left += T.SpaceWidth*T.Size + T.LetterSpacing*T.Size
+				index++
+				continue
+			}
+
+			w, h := glyph.w*scale, glyph.h*scale
+
+			c := T.Color
+			if color != nil {
+				c = color(index, r)
+			}
+
+			transform := mgl32.Translate2D(left+w/2, bottom+h/2).Mul3(mgl32.Scale2D(w, h))
+			T.Batch.AddQuad(T.Atlas.Texture, camera, transform, glyph.subTexPos, c, 1)
+
+			left += w + T.LetterSpacing*T.Size
+			index++
+		}
+
+		index += line.skipped
+	}
+}
+
+// pixels => world units
+func (T *TextRenderer) scale() float32 {
+	if T.lineHeight == 0 {
+		return 0
+	}
+
+	return T.Size / T.lineHeight
+}
+
+func (T *TextRenderer) height(lines int) float32 {
+	if lines == 0 {
+		return 0
+	}
+
+	return T.Size + float32(lines-1)*T.Size*T.LineSpacing
+}
+
+func (T *TextRenderer) lookup(r rune) (glyphInfo, bool) {
+	name, found := T.Glyphs.Lookup(r)
+	if !found {
+		return glyphInfo{}, false
+	}
+
+	return T.glyphs[name], true
+}
+
+func (T *TextRenderer) kern(left, right rune) float32 {
+	if left == 0 {
+		return 0
+	}
+
+	return T.kerning[[2]rune{left, right}] * T.Size
+}
+
+// Width of a rune, including letter spacing and kerning
+func (T *TextRenderer) advance(prev, r rune) float32 {
+	adv := T.kern(prev, r) + T.LetterSpacing*T.Size
+
+	if glyph, found := T.lookup(r); found {
+		return adv + glyph.w*T.scale()
+	}
+
+	return adv + T.SpaceWidth*T.Size
+}
+
+func (T *TextRenderer) measureRunes(runes []rune) float32 {
+	var w float32
+	var prev rune
+
+	for _, r := range runes {
+		w += T.advance(prev, r)
+		prev = r
+	}
+
+	if len(runes) > 0 {
+		w -= T.LetterSpacing * T.Size // no spacing after the last character
+	}
+
+	return w
+}
+
+// Split the text into lines, at newlines and (if WrapWidth is set) between words.
+// Words that are too long for a line on their own are broken between characters.
+func (T *TextRenderer) layout(str string) []textLine {
+	lines := []textLine{}
+
+	for _, paragraph := range strings.Split(str, "\n") {
+		runes := []rune(paragraph)
+
+		if T.WrapWidth <= 0 {
+			lines = append(lines, textLine{runes: runes, width: T.measureRunes(runes), skipped: 1})
+			continue
+		}
+
+		for {
+			end, next := T.wrap(runes)
+			lines = append(lines, textLine{runes: runes[:end], width: T.measureRunes(runes[:end]), skipped: next - end})
+			runes = runes[next:]
+			if len(runes) == 0 {
+				break
+			}
+		}
+
+		lines[len(lines)-1].skipped++ // the newline
+	}
+
+	return lines
+}
+
+// Find where to break a line.
+// end is the number of runes on the line, next is where the following line starts.
+// The runes between end and next are spaces swallowed by the line break.
+func (T *TextRenderer) wrap(runes []rune) (end, next int) {
+	var width float32
+	var prev rune
+	lastSpace := -1
+
+	for i, r := range runes {
+		width += T.advance(prev, r)
+		prev = r
+
+		if r == ' ' {
+			lastSpace = i
+			continue
+		}
+
+		if width-T.LetterSpacing*T.Size <= T.WrapWidth || i == 0 {
+			continue
+		}
+
+		if lastSpace < 0 {
+			return i, i
+		}
+
+		end = lastSpace
+		for end > 0 && runes[end-1] == ' ' {
+			end--
+		}
+		next = lastSpace + 1
+
+		return end, next
+	}
+
+	return len(runes), len(runes)
+}
@@ -0,0 +1,271 @@
+package tractor
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// The input of one tick
+type InputFrame struct {
+	Tick  uint64  // Engine.TickCount when it was recorded
+	Delta float64 // seconds since the previous tick
+	Keys  []RecordedKey
+	Mouse []MouseEvent
+	Pads  []RecordedGamepad // gamepads that were plugged in, unplugged, or changed
+}
+
+// A key event, or a typed character. They share a list, so they are replayed in the order they came
+type RecordedKey struct {
+	Event KeyEvent
+	Char  rune // 0 for key events
+}
+
+type RecordedGamepad struct {
+	Slot      int
+	Connected bool
+	Name      string
+	GUID      string
+	State     GamepadState
+}
+
+// =========================================================================
+// ||
+// || Input Recording.
+// ||
+// || Every key and mouse event, every typed character, every change of the gamepads, and the time
+// || of every tick. Played back, it drives the game like the player did:
+// || to reproduce a bug report, or to test a scene without a human.
+// ||
+// || Replays are only exact if the game is: start the recording where the
+// || replay will start (a fresh level, say), and don't use random numbers
+// || without a fixed seed.
+// ||
+// =========================================================================
+type InputRecording struct {
+	StartTime float64 // Engine.Now64 before the first tick
+	Frames    []InputFrame
+}
+
+func LoadInputRecording(filename string) (*InputRecording, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	R := &InputRecording{}
+	if err := gob.NewDecoder(file).Decode(R); err != nil {
+		return nil, fmt.Errorf("%s is not an input recording: %w", filename, err)
+	}
+
+	return R, nil
+}
+
+func (R *InputRecording) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(file).Encode(R); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// How long the recording plays, in seconds
+func (R *InputRecording) Duration() float64 {
+	total := 0.0
+	for _, F := range R.Frames {
+		total += F.Delta
+	}
+	return total
+}
+
+// Collects the input of the engine, one frame per tick
+type inputRecorder struct {
+	recording *InputRecording
+	pending   InputFrame                     // the events since the last tick
+	pads      [gamepadSlots]*RecordedGamepad // what was last recorded of each slot
+}
+
+// Add a frame with the events since the last tick. Called by Tick(), after the gamepads are read
+func (I *inputRecorder) record(W *EngineType) {
+	F := I.pending
+	I.pending = InputFrame{}
+
+	F.Tick, F.Delta = W.TickCount, W.Delta64
+
+	for slot := range I.pads {
+		now := RecordedGamepad{Slot: slot, State: releasedGamepadState()}
+		if G := W.gamepads.Get(slot); G != nil {
+			now = RecordedGamepad{Slot: slot, Connected: true, Name: G.Name(), GUID: G.GUID(), State: G.RawState()}
+		}
+
+		last := I.pads[slot]
+		if last == nil && !now.Connected || last != nil && *last == now {
+			continue
+		}
+
+		F.Pads = append(F.Pads, now)
+		I.pads[slot] = &now
+	}
+
+	I.recording.Frames = append(I.recording.Frames, F)
+}
+
+// Plays a recording back, one frame per tick
+type inputPlayer struct {
+	recording *InputRecording
+	onFinish  func()
+	next      int
+	lastDelta float64
+	fakes     [gamepadSlots]*FakeGamepad
+}
+
+// Feed the events of the next frame, and turn the clock to when it was recorded. Called by Tick()
+func (I *inputPlayer) play(W *EngineType) {
+	if I.next >= len(I.recording.Frames) {
+		W.Prev64 = W.Now64 - I.lastDelta // carry on from here, instead of from when the recording was made
+		onFinish := I.onFinish
+		W.StopReplay()
+		if onFinish != nil {
+			onFinish()
+		}
+		return
+	}
+
+	if I.next == 0 {
+		W.Prev64 = I.recording.StartTime
+	}
+
+	F := &I.recording.Frames[I.next]
+	I.next++
+
+	W.Now64 = W.Prev64 + F.Delta
+	I.lastDelta = F.Delta
+
+	for _, key := range F.Keys {
+		if key.Char != 0 {
+			W.keyboard.FeedChar(key.Char)
+		} else {
+			W.keyboard.Feed(key.Event)
+		}
+	}
+	for _, mev := range F.Mouse {
+		W.mouse.Feed(mev)
+	}
+
+	for _, pad := range F.Pads {
+		if pad.Slot < 0 || pad.Slot >= gamepadSlots {
+			continue
+		}
+
+		if !pad.Connected {
+			if G := W.gamepads.Get(pad.Slot); G != nil {
+				W.gamepads.Disconnect(G)
+			}
+			I.fakes[pad.Slot] = nil
+			continue
+		}
+
+		state := pad.State
+		if fake := I.fakes[pad.Slot]; fake != nil && fake.DeviceGUID == pad.GUID {
+			*fake.Pad = state
+			continue
+		}
+
+		I.fakes[pad.Slot] = &FakeGamepad{DeviceName: pad.Name, DeviceGUID: pad.GUID, Pad: &state}
+		W.gamepads.connectSlot(pad.Slot, I.fakes[pad.Slot])
+	}
+}
+
+// =========================================================================
+// || Record the input, from the next tick on.
+// ||
+// || The recording grows every tick until StopRecording(). Save() it to
+// || replay it later.
+// =========================================================================
+func (W *EngineType) RecordInput() *InputRecording {
+	W.StopRecording()
+
+	keyboard, mouse := W.Controls.Keyboard(), W.Controls.Mouse()
+	W.Controls.Gamepads()
+
+	I := &inputRecorder{recording: &InputRecording{StartTime: W.Now64}}
+
+	// Replays start with the cursor where it is now
+	I.pending.Mouse = append(I.pending.Mouse, MouseEvent{X: mouse.pos.X, Y: mouse.pos.Y, Moved: true})
+
+	keyboard.tap = func(kev KeyEvent) { I.pending.Keys = append(I.pending.Keys, RecordedKey{Event: kev}) }
+	keyboard.tapChar = func(char rune) { I.pending.Keys = append(I.pending.Keys, RecordedKey{Char: char}) }
+	mouse.tap = func(mev MouseEvent) { I.pending.Mouse = append(I.pending.Mouse, mev) }
+	W.recorder = I
+
+	return I.recording
+}
+
+// Stop recording. Returns the recording, or nil if there was none
+func (W *EngineType) StopRecording() *InputRecording {
+	if W.recorder == nil {
+		return nil
+	}
+
+	R := W.recorder.recording
+	W.keyboard.tap, W.keyboard.tapChar, W.mouse.tap = nil, nil, nil
+	W.recorder = nil
+
+	return R
+}
+
+func (W *EngineType) IsRecording() bool {
+	return W.recorder != nil
+}
+
+// =========================================================================
+// || Play a recording back, from the next tick on.
+// ||
+// || While it plays, the real keyboard, mouse and gamepads are ignored,
+// || and Now, Delta and so on are as they were recorded. onFinish (which
+// || may be nil) is called after the last frame.
+// =========================================================================
+func (W *EngineType) ReplayInput(R *InputRecording, onFinish func()) {
+	W.StopReplay()
+
+	keyboard, mouse, gamepads := W.Controls.Keyboard(), W.Controls.Mouse(), W.Controls.Gamepads()
+
+	keyboard.reset()
+	mouse.reset()
+	keyboard.replaying, mouse.replaying, gamepads.replaying = true, true, true
+
+	for _, G := range gamepads.Connected() {
+		gamepads.Disconnect(G)
+	}
+
+	W.player = &inputPlayer{recording: R, onFinish: onFinish}
+}
+
+// Stop playing back, and listen to the real devices again. onFinish is not called
+func (W *EngineType) StopReplay() {
+	if W.player == nil {
+		return
+	}
+	W.player = nil
+
+	W.keyboard.reset()
+	W.mouse.reset()
+	W.keyboard.replaying, W.mouse.replaying, W.gamepads.replaying = false, false, false
+
+	for _, G := range W.gamepads.Connected() {
+		W.gamepads.Disconnect(G)
+	}
+	if W.gamepads.live {
+		W.gamepads.connectPresent()
+	}
+}
+
+func (W *EngineType) IsReplaying() bool {
+	return W.player != nil
+}
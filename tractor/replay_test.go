@@ -0,0 +1,236 @@
+package tractor
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+var updateReplay = flag.Bool("update-replay", false, "record testdata/input.replay again")
+
+const replayTicks = 16
+
+// What the game can see of the input in one tick
+type inputSnapshot struct {
+	Now, Delta float64
+
+	KeysDown, KeysPressed, KeysReleased [keyCount]bool
+	Typed                               string
+	HoldingA                            bool // Controls.KeyPressed(KeyA), the way games usually ask
+
+	MouseX, MouseY                         float32
+	MouseDown, MousePressed, MouseReleased [mouseButtonCount]bool
+	ScrollX, ScrollY                       float32
+
+	Pads [gamepadSlots]padSnapshot
+}
+
+type padSnapshot struct {
+	Connected         bool
+	Name, GUID        string
+	State             GamepadState
+	Pressed, Released [gamepadButtonCount]bool
+}
+
+// Only what is down, pressed, connected and so on, so a failing test says what differs
+func (S inputSnapshot) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "time %.4f+%.4f, keys down %v pressed %v released %v, typed %q, holding A %v, ", S.Now, S.Delta,
+		trueIndices(S.KeysDown[:]), trueIndices(S.KeysPressed[:]), trueIndices(S.KeysReleased[:]), S.Typed, S.HoldingA)
+	fmt.Fprintf(b, "mouse at %v,%v down %v pressed %v released %v scrolled %v,%v", S.MouseX, S.MouseY,
+		trueIndices(S.MouseDown[:]), trueIndices(S.MousePressed[:]), trueIndices(S.MouseReleased[:]), S.ScrollX, S.ScrollY)
+
+	for slot, P := range S.Pads {
+		if P.Connected {
+			fmt.Fprintf(b, ", pad %d %q (%s) down %v axes %v pressed %v released %v", slot, P.Name, P.GUID,
+				trueIndices(P.State.Buttons[:]), P.State.Axes, trueIndices(P.Pressed[:]), trueIndices(P.Released[:]))
+		}
+	}
+	return b.String()
+}
+
+func trueIndices(flags []bool) []int {
+	indices := []int{}
+	for i, flag := range flags {
+		if flag {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Run the engine tick by tick, like Loop() does. feed(i) comes after frame i, where a window would poll its events
+func runInputTicks(W *EngineType, feed func(i int)) []inputSnapshot {
+	K, M, P := W.Controls.Keyboard(), W.Controls.Mouse(), W.Controls.Gamepads()
+
+	typed := ""
+	K.HandleText(func(char rune) { typed += string(char) })
+
+	snapshots := []inputSnapshot{}
+	for i := 0; i < replayTicks; i++ {
+		W.Backend.BeginFrame()
+		W.Tick()
+
+		S := inputSnapshot{
+			Now: W.Now64, Delta: W.Delta64,
+			KeysDown: K.down, KeysPressed: K.pressed, KeysReleased: K.released,
+			Typed: typed, HoldingA: W.Controls.KeyPressed(KeyCode(KeyA)),
+			MouseX: M.pos.X, MouseY: M.pos.Y,
+			MouseDown: M.down, MousePressed: M.pressed, MouseReleased: M.released,
+			ScrollX: M.scroll.X, ScrollY: M.scroll.Y,
+		}
+		for slot := range S.Pads {
+			if G := P.Get(slot); G != nil {
+				S.Pads[slot] = padSnapshot{true, G.Name(), G.GUID(), G.RawState(), G.pressed, G.released}
+			}
+		}
+		snapshots = append(snapshots, S)
+		typed = ""
+
+		W.endFrame()
+		if feed != nil {
+			feed(i)
+		}
+	}
+
+	return snapshots
+}
+
+// Keys, typing, the mouse and a gamepad that comes and goes
+func playScriptedInput(W *EngineType) []inputSnapshot {
+	K, M, P := W.Controls.Keyboard(), W.Controls.Mouse(), W.Controls.Gamepads()
+
+	pad := releasedGamepadState()
+	fake := &FakeGamepad{DeviceName: "Test Pad", DeviceGUID: "0300", Pad: &pad}
+
+	key := func(key glfw.Key, action glfw.Action) { K.Feed(CreateKeyEvent(KeyCode(key), action, 0)) }
+
+	return runInputTicks(W, func(i int) {
+		switch i {
+		case 1:
+			P.Connect(fake)
+		case 2:
+			key(KeyA, glfw.Press)
+			M.Feed(MouseEvent{X: 10, Y: 20, Moved: true})
+		case 3:
+			K.FeedChar('a')
+			M.Feed(MouseEvent{X: 10, Y: 20, Button: MouseLeft, Pressed: true})
+			pad.Buttons[GamepadA] = true
+		case 4:
+			key(KeyA, glfw.Release)
+			key(KeySpace, glfw.Press)
+			key(KeySpace, glfw.Release)
+			M.Feed(MouseEvent{X: 10, Y: 20, Scrolled: true, ScrollY: -2})
+			pad.Axes[GamepadLeftX] = 0.5
+		case 6:
+			M.Feed(MouseEvent{X: 30, Y: 40, Moved: true})
+			M.Feed(MouseEvent{X: 30, Y: 40, Button: MouseLeft, Released: true})
+			pad.Buttons[GamepadA] = false
+		case 8:
+			K.FeedChar('é')
+			key(KeyLeftShift, glfw.Press)
+		case 10:
+			P.Disconnect(P.Get(0))
+			key(KeyLeftShift, glfw.Release)
+		}
+	})
+}
+
+func replay(t *testing.T, R *InputRecording) []inputSnapshot {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 0)
+
+	finished := false
+	W.ReplayInput(R, func() { finished = true })
+	snapshots := runInputTicks(W, nil)
+
+	W.Tick() // one past the end
+	if !finished || W.IsReplaying() {
+		t.Fatalf("the replay did not finish after %d ticks", len(R.Frames))
+	}
+
+	return snapshots
+}
+
+func compareInput(t *testing.T, played, replayed []inputSnapshot) {
+	t.Helper()
+
+	if len(played) != len(replayed) {
+		t.Fatalf("played %d ticks, replayed %d", len(played), len(replayed))
+	}
+	for i := range played {
+		if played[i] != replayed[i] {
+			t.Fatalf("tick %d differs:\nplayed   %v\nreplayed %v", i+1, played[i], replayed[i])
+		}
+	}
+}
+
+func TestReplayMatchesRecording(t *testing.T) {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 0)
+	W.RecordInput()
+	played := playScriptedInput(W)
+	R := W.StopRecording()
+
+	if len(R.Frames) != replayTicks {
+		t.Fatalf("recorded %d frames in %d ticks", len(R.Frames), replayTicks)
+	}
+
+	filename := filepath.Join(t.TempDir(), "input.replay")
+	if err := R.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadInputRecording(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareInput(t, played, replay(t, loaded))
+}
+
+// Games that ask Controls.KeyPressed() must see the replayed keys, not the real ones
+func TestReplayKeyPressed(t *testing.T) {
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 0)
+	W.RecordInput()
+	played := playScriptedInput(W)
+	R := W.StopRecording()
+
+	replayed := replay(t, R)
+
+	held := 0
+	for i := range played {
+		if played[i].HoldingA {
+			held++
+		}
+		if replayed[i].HoldingA != played[i].HoldingA {
+			t.Fatalf("tick %d: KeyPressed(KeyA) is %v in the replay, %v when it was played", i+1, replayed[i].HoldingA, played[i].HoldingA)
+		}
+	}
+	if held != 2 {
+		t.Fatalf("A was held for %d ticks, expected 2", held)
+	}
+}
+
+// Recordings made by earlier versions must still play. go test -run ReplayFixture -update-replay records it again
+func TestReplayFixture(t *testing.T) {
+	filename := filepath.Join("testdata", "input.replay")
+
+	W := StartHeadless(&WindowOptions{Width: 8, Height: 8}, 0)
+	W.RecordInput()
+	played := playScriptedInput(W)
+
+	if *updateReplay {
+		if err := W.StopRecording().Save(filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	R, err := LoadInputRecording(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareInput(t, played, replay(t, R))
+}
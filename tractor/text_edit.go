@@ -0,0 +1,320 @@
+package tractor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Somewhere to copy text to and paste it from. Keyboard is one
+type Clipboard interface {
+	Clipboard() string
+	SetClipboard(text string)
+}
+
+// =========================================================================
+// ||
+// || Text Edit.
+// ||
+// || One line of text being typed, for name entry and chat boxes. Knows
+// || the cursor, the selection and the clipboard; drawing it is up to the
+// || game. Positions count characters (runes), and sit between them: 0 is
+// || before the first one.
+// ||
+// || Give it the focus (Keyboard.Focus) and it understands:
+// ||
+// ||   typing                  replaces the selection
+// ||   backspace, delete       ctrl (or alt) for whole words
+// ||   left, right, home, end  shift selects, ctrl (or alt) jumps words
+// ||   ctrl+a, c, x, v         select all, copy, cut, paste. cmd on a mac
+// ||   enter                   calls OnSubmit
+// ||
+// || The game gets none of the other keys either, except PassKeys.
+// ||
+// =========================================================================
+type TextEdit struct {
+	MaxLength int               // in characters. 0 means no limit
+	Filter    func(r rune) bool // which characters may be typed or pasted. nil allows everything printable
+	OnChange  func(text string) // the user changed the text
+	OnSubmit  func(text string) // the user pressed enter
+	Clipboard Clipboard         // nil keeps copies to the text edit itself
+	PassKeys  []KeyCode         // keys the game still gets while the text edit has the focus. Escape and tab by default
+
+	text   []rune
+	cursor int
+	anchor int    // the other end of the selection. The same as cursor when nothing is selected
+	clip   string // when there is no Clipboard
+}
+
+// A text edit with the cursor at the end of the text
+func CreateTextEdit(text string) *TextEdit {
+	E := &TextEdit{PassKeys: []KeyCode{KeyCode(KeyEscape), KeyCode(KeyTab)}}
+	E.SetText(text)
+
+	return E
+}
+
+func (E *TextEdit) Text() string {
+	return string(E.text)
+}
+
+// Replace the text, and put the cursor at the end. MaxLength applies, the filter and OnChange do not
+func (E *TextEdit) SetText(text string) {
+	E.text = []rune(text)
+	if E.MaxLength > 0 && len(E.text) > E.MaxLength {
+		E.text = E.text[:E.MaxLength]
+	}
+	E.cursor, E.anchor = len(E.text), len(E.text)
+}
+
+func (E *TextEdit) Len() int {
+	return len(E.text)
+}
+
+func (E *TextEdit) Cursor() int {
+	return E.cursor
+}
+
+// Move the cursor. If selecting, the selection stretches along
+func (E *TextEdit) SetCursor(pos int, selecting bool) {
+	E.cursor = max(0, min(pos, len(E.text)))
+	if !selecting {
+		E.anchor = E.cursor
+	}
+}
+
+// The selected characters, from and to. The same when nothing is selected
+func (E *TextEdit) Selection() (int, int) {
+	return min(E.cursor, E.anchor), max(E.cursor, E.anchor)
+}
+
+func (E *TextEdit) HasSelection() bool {
+	return E.cursor != E.anchor
+}
+
+func (E *TextEdit) SelectedText() string {
+	from, to := E.Selection()
+	return string(E.text[from:to])
+}
+
+func (E *TextEdit) SelectAll() {
+	E.anchor, E.cursor = 0, len(E.text)
+}
+
+// Type text at the cursor, over the selection. Characters the filter refuses are left out,
+// and line breaks become spaces. Stops at MaxLength
+func (E *TextEdit) Insert(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	typed := []rune{}
+	for _, r := range text {
+		if r == '\n' {
+			r = ' '
+		}
+		if E.allows(r) {
+			typed = append(typed, r)
+		}
+	}
+
+	from, to := E.Selection()
+	if E.MaxLength > 0 {
+		room := E.MaxLength - (len(E.text) - (to - from))
+		typed = typed[:max(0, min(len(typed), room))]
+	}
+	if len(typed) == 0 && from == to {
+		return
+	}
+
+	E.replace(from, to, typed)
+}
+
+func (E *TextEdit) allows(r rune) bool {
+	if E.Filter != nil {
+		return E.Filter(r)
+	}
+	return unicode.IsPrint(r)
+}
+
+// Replace characters from..to, put the cursor after the new ones, and tell OnChange
+func (E *TextEdit) replace(from, to int, typed []rune) {
+	E.text = append(E.text[:from], append(typed, E.text[to:]...)...)
+	E.cursor = from + len(typed)
+	E.anchor = E.cursor
+
+	if E.OnChange != nil {
+		E.OnChange(string(E.text))
+	}
+}
+
+// Delete the selection, or the character (or word) before the cursor
+func (E *TextEdit) Backspace(word bool) {
+	from, to := E.Selection()
+	if from == to {
+		from = E.step(E.cursor, -1, word)
+	}
+	if from != to {
+		E.replace(from, to, nil)
+	}
+}
+
+// Delete the selection, or the character (or word) after the cursor
+func (E *TextEdit) Delete(word bool) {
+	from, to := E.Selection()
+	if from == to {
+		to = E.step(E.cursor, 1, word)
+	}
+	if from != to {
+		E.replace(from, to, nil)
+	}
+}
+
+// Move the cursor one character (or word) to the left. Without selecting, a selection collapses to its start
+func (E *TextEdit) Left(selecting, word bool) {
+	if from, to := E.Selection(); from != to && !selecting {
+		E.SetCursor(from, false)
+		return
+	}
+	E.SetCursor(E.step(E.cursor, -1, word), selecting)
+}
+
+// Move the cursor one character (or word) to the right. Without selecting, a selection collapses to its end
+func (E *TextEdit) Right(selecting, word bool) {
+	if from, to := E.Selection(); from != to && !selecting {
+		E.SetCursor(to, false)
+		return
+	}
+	E.SetCursor(E.step(E.cursor, 1, word), selecting)
+}
+
+func (E *TextEdit) Home(selecting bool) {
+	E.SetCursor(0, selecting)
+}
+
+func (E *TextEdit) End(selecting bool) {
+	E.SetCursor(len(E.text), selecting)
+}
+
+// Where one step from pos leads: the next character, or the start (end) of the word
+func (E *TextEdit) step(pos, direction int, word bool) int {
+	if !word {
+		return max(0, min(pos+direction, len(E.text)))
+	}
+
+	inWord := func(i int) bool { return !unicode.IsSpace(E.text[i]) }
+
+	if direction < 0 {
+		for pos > 0 && !inWord(pos-1) {
+			pos--
+		}
+		for pos > 0 && inWord(pos-1) {
+			pos--
+		}
+	} else {
+		for pos < len(E.text) && !inWord(pos) {
+			pos++
+		}
+		for pos < len(E.text) && inWord(pos) {
+			pos++
+		}
+	}
+	return pos
+}
+
+// Copy the selection to the clipboard
+func (E *TextEdit) Copy() {
+	if E.HasSelection() {
+		E.setClipboard(E.SelectedText())
+	}
+}
+
+// Copy the selection to the clipboard, and delete it
+func (E *TextEdit) Cut() {
+	if E.HasSelection() {
+		E.Copy()
+		E.Backspace(false)
+	}
+}
+
+// Type what is on the clipboard
+func (E *TextEdit) Paste() {
+	if E.Clipboard != nil {
+		E.Insert(E.Clipboard.Clipboard())
+		return
+	}
+	E.Insert(E.clip)
+}
+
+func (E *TextEdit) setClipboard(text string) {
+	if E.Clipboard != nil {
+		E.Clipboard.SetClipboard(text)
+		return
+	}
+	E.clip = text
+}
+
+// Does the key go to the game, rather than the text edit?
+func (E *TextEdit) Passes(key KeyCode) bool {
+	for _, pass := range E.PassKeys {
+		if key == pass {
+			return true
+		}
+	}
+	return false
+}
+
+// Type a character
+func (E *TextEdit) HandleChar(char rune) {
+	E.Insert(string(char))
+}
+
+// Act on a key, if it is one the text edit understands. Returns whether it was
+func (E *TextEdit) HandleKey(kev *KeyEvent) bool {
+	if !kev.Pressed && !kev.Repeated {
+		return false
+	}
+
+	command := kev.Ctrl || kev.Gui // cmd on a mac
+	word := kev.Ctrl || kev.Alt    // alt on a mac
+
+	switch kev.Key {
+	case KeyCode(KeyBackspace):
+		E.Backspace(word)
+	case KeyCode(KeyDelete):
+		E.Delete(word)
+	case KeyCode(KeyLeft):
+		E.Left(kev.Shift, word)
+	case KeyCode(KeyRight):
+		E.Right(kev.Shift, word)
+	case KeyCode(KeyHome), KeyCode(KeyUp):
+		E.Home(kev.Shift)
+	case KeyCode(KeyEnd), KeyCode(KeyDown):
+		E.End(kev.Shift)
+	case KeyCode(KeyEnter), KeyCode(KeyKPEnter):
+		if E.OnSubmit != nil {
+			E.OnSubmit(string(E.text))
+		}
+	case KeyCode(KeyA):
+		if !command {
+			return false
+		}
+		E.SelectAll()
+	case KeyCode(KeyC):
+		if !command {
+			return false
+		}
+		E.Copy()
+	case KeyCode(KeyX):
+		if !command {
+			return false
+		}
+		E.Cut()
+	case KeyCode(KeyV):
+		if !command {
+			return false
+		}
+		E.Paste()
+	default:
+		return false
+	}
+
+	return true
+}
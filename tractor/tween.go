@@ -0,0 +1,510 @@
+package tractor
+
+import (
+	"goat/shed"
+)
+
+// =========================================================================
+// ||
+// || Tweens.
+// ||
+// || Animate a value from wherever it is to where it should be, over time.
+// || Tweens can be put in sequences and parallel groups, which are tweens
+// || themselves, so they can be nested.
+// ||
+// || Started tweens are advanced by Engine.Tick(), until they are done:
+// ||
+// ||	tractor.TweenV4(&rect.Color, shed.RGBA(1, 0, 0, 1), 0.5).Ease(shed.EaseOutQuad).Start()
+// ||
+// =========================================================================
+
+// Anything that can be started: a Tween or a TweenGroup
+type Tweener interface {
+	Duration() float32 // total, including delays and repeats. Negative means forever
+
+	seek(t float32)   // show the state at t seconds after the start
+	rewind()          // forget the captured start values, so they are captured again on the next play
+	hasStarted() bool // the start values have been captured
+}
+
+// The timing shared by tweens and groups: delays, repeats and callbacks
+type tweenTiming struct {
+	delay      float32
+	repeat     int // extra plays. Negative means forever
+	yoyo       bool
+	onComplete func()
+	completed  bool
+	manager    *TweenManager // plays the tween when it is started. nil means Engine.Tweens
+}
+
+func (T *tweenTiming) player() *TweenManager {
+	if T.manager != nil {
+		return T.manager
+	}
+
+	return Engine.Tweens
+}
+
+// The total duration, given the duration of one play
+func (T *tweenTiming) total(cycle float32) float32 {
+	if T.repeat < 0 {
+		return -1
+	}
+
+	return T.delay + cycle*float32(T.repeat+1)
+}
+
+// Map t to the time within a single play. Odd plays of yoyo tweens go backwards.
+// Returns false before the delay is over.
+func (T *tweenTiming) cycleTime(t, cycle float32) (float32, bool) {
+	t -= T.delay
+	if t < 0 {
+		return 0, false
+	}
+
+	if cycle <= 0 {
+		return 0, true
+	}
+
+	plays := int(t / cycle)
+	within := t - float32(plays)*cycle
+
+	if total := T.total(cycle); total >= 0 && t >= total-T.delay {
+		// Done. Stay at the end of the last play
+		plays = T.repeat
+		within = cycle
+	}
+
+	if T.yoyo && plays%2 == 1 {
+		return cycle - within, true
+	}
+
+	return within, true
+}
+
+// Fire the completion callback when t passes the end. It fires again if t goes back
+// before the end and past it again, as it does when a repeating group starts over
+func (T *tweenTiming) checkComplete(t, cycle float32) {
+	total := T.total(cycle)
+	done := total >= 0 && t >= total
+
+	if done && !T.completed && T.onComplete != nil {
+		T.onComplete()
+	}
+	T.completed = done
+}
+
+// ||=============================
+// || Tween
+// ||=============================
+
+// Animates a single value
+type Tween struct {
+	tweenTiming
+	duration float32
+	ease     shed.EaseFunc
+
+	capture  func()          // remember the start value
+	apply    func(p float32) // set the value p of the way from the start to the end value
+	captured bool
+}
+
+// A tween that calls capture when it starts, and apply(p) as it plays,
+// where p is the eased progress: 0 at the start, 1 at the end.
+func CreateTween(duration float32, capture func(), apply func(p float32)) *Tween {
+	return &Tween{
+		duration: duration,
+		ease:     shed.EaseLinear,
+		capture:  capture,
+		apply:    apply,
+	}
+}
+
+// Animate *target to the value to
+func TweenFloat(target *float32, to, duration float32) *Tween {
+	var from float32
+
+	return CreateTween(duration,
+		func() { from = *target },
+		func(p float32) { *target = shed.LerpU(from, to, p) },
+	)
+}
+
+func TweenV2(target *shed.V2, to shed.V2, duration float32) *Tween {
+	var from shed.V2
+
+	return CreateTween(duration,
+		func() { from = *target },
+		func(p float32) { *target = lerpV2(from, to, p) },
+	)
+}
+
+func TweenV4(target *shed.V4, to shed.V4, duration float32) *Tween {
+	var from shed.V4
+
+	return CreateTween(duration,
+		func() { from = *target },
+		func(p float32) { *target = from.Mix(to, p) },
+	)
+}
+
+// Animate a value that is behind a getter and a setter
+func TweenFloatFunc(get func() float32, set func(float32), to, duration float32) *Tween {
+	var from float32
+
+	return CreateTween(duration,
+		func() { from = get() },
+		func(p float32) { set(shed.LerpU(from, to, p)) },
+	)
+}
+
+func TweenV2Func(get func() shed.V2, set func(shed.V2), to shed.V2, duration float32) *Tween {
+	var from shed.V2
+
+	return CreateTween(duration,
+		func() { from = get() },
+		func(p float32) { set(lerpV2(from, to, p)) },
+	)
+}
+
+func lerpV2(from, to shed.V2, p float32) shed.V2 {
+	return shed.V2{X: shed.LerpU(from.X, to.X, p), Y: shed.LerpU(from.Y, to.Y, p)}
+}
+
+// Set the easing curve. The default is linear
+func (T *Tween) Ease(fn shed.EaseFunc) *Tween {
+	T.ease = fn
+	return T
+}
+
+// Wait before starting. The start value is captured when the delay is over
+func (T *Tween) Delay(seconds float32) *Tween {
+	T.delay = seconds
+	return T
+}
+
+// Play times more times after the first. Negative means forever
+func (T *Tween) Repeat(times int) *Tween {
+	T.repeat = times
+	return T
+}
+
+// Every other play goes backwards. Use with Repeat
+func (T *Tween) Yoyo(yoyo bool) *Tween {
+	T.yoyo = yoyo
+	return T
+}
+
+// Called when the tween is done, including all its repeats
+func (T *Tween) OnComplete(fn func()) *Tween {
+	T.onComplete = fn
+	return T
+}
+
+// Let Engine.Tick() play the tween
+func (T *Tween) Start() *Tween {
+	T.player().Start(T)
+	return T
+}
+
+func (T *Tween) Stop() {
+	T.player().Stop(T)
+}
+
+func (T *Tween) Duration() float32 {
+	return T.total(T.duration)
+}
+
+func (T *Tween) seek(t float32) {
+	within, started := T.cycleTime(t, T.duration)
+	if !started {
+		if T.captured {
+			T.apply(T.ease(0)) // a yoyo group went back to before the delay
+		}
+		return
+	}
+
+	if !T.captured {
+		T.captured = true
+		T.capture()
+	}
+
+	p := float32(1)
+	if T.duration > 0 {
+		p = within / T.duration
+	}
+	T.apply(T.ease(p))
+
+	T.checkComplete(t, T.duration)
+}
+
+func (T *Tween) rewind() {
+	T.captured = false
+	T.completed = false
+}
+
+func (T *Tween) hasStarted() bool {
+	return T.captured
+}
+
+// ||=============================
+// || Positions and cameras
+// ||=============================
+
+// Move to (x, y)
+func (P *Position) TweenXY(x, y, duration float32) *Tween {
+	return TweenV2Func(
+		func() shed.V2 { x, y, _ := P.GetXYA(); return shed.Vec2(x, y) },
+		func(v shed.V2) { P.SetXY(v.X, v.Y) },
+		shed.Vec2(x, y), duration,
+	)
+}
+
+// Rotate to angle (in radians)
+func (P *Position) TweenAngle(angle, duration float32) *Tween {
+	return TweenFloatFunc(
+		func() float32 { _, _, a := P.GetXYA(); return a },
+		P.SetAngle,
+		angle, duration,
+	)
+}
+
+func (P *Position) TweenScale(sx, sy, duration float32) *Tween {
+	return TweenV2Func(
+		func() shed.V2 { return shed.Vec2(P.GetScale()) },
+		func(v shed.V2) { P.SetScale(v.X, v.Y) },
+		shed.Vec2(sx, sy), duration,
+	)
+}
+
+// Look at (x, y)
+func (C *Camera) TweenXY(x, y, duration float32) *Tween {
+	return TweenV2Func(
+		func() shed.V2 { return shed.Vec2(C.GetXY()) },
+		func(v shed.V2) { C.SetXY(v.X, v.Y) },
+		shed.Vec2(x, y), duration,
+	)
+}
+
+func (C *Camera) TweenAngle(angle, duration float32) *Tween {
+	return TweenFloatFunc(C.GetAngle, C.SetAngle, angle, duration)
+}
+
+// Zoom by changing how much of the world the camera sees
+func (C *Camera) TweenFrameSize(w, h, duration float32) *Tween {
+	return TweenV2Func(
+		C.GetFrameSizeV,
+		func(v shed.V2) { C.SetFrameSize(v.X, v.Y) },
+		shed.Vec2(w, h), duration,
+	)
+}
+
+// ||=============================
+// || Groups
+// ||=============================
+
+// Tweens that play one after the other, or all at once
+type TweenGroup struct {
+	tweenTiming
+	items    []Tweener
+	parallel bool
+}
+
+// The tweens play one after the other. None of them may repeat forever
+func TweenSequence(items ...Tweener) *TweenGroup {
+	return &TweenGroup{items: items}
+}
+
+// The tweens play at the same time. The group is done when the last of them is
+func TweenParallel(items ...Tweener) *TweenGroup {
+	return &TweenGroup{items: items, parallel: true}
+}
+
+func (G *TweenGroup) Delay(seconds float32) *TweenGroup {
+	G.delay = seconds
+	return G
+}
+
+// Play times more times after the first. Negative means forever
+func (G *TweenGroup) Repeat(times int) *TweenGroup {
+	G.repeat = times
+	return G
+}
+
+// Every other play goes backwards. Use with Repeat
+func (G *TweenGroup) Yoyo(yoyo bool) *TweenGroup {
+	G.yoyo = yoyo
+	return G
+}
+
+func (G *TweenGroup) OnComplete(fn func()) *TweenGroup {
+	G.onComplete = fn
+	return G
+}
+
+func (G *TweenGroup) Start() *TweenGroup {
+	G.player().Start(G)
+	return G
+}
+
+func (G *TweenGroup) Stop() {
+	G.player().Stop(G)
+}
+
+func (G *TweenGroup) Duration() float32 {
+	return G.total(G.cycle())
+}
+
+// The duration of one play. Items that go on forever count as zero
+func (G *TweenGroup) cycle() float32 {
+	var cycle float32
+
+	for _, item := range G.items {
+		d := shed.Max(item.Duration(), 0)
+		if G.parallel {
+			cycle = shed.Max(cycle, d)
+		} else {
+			cycle += d
+		}
+	}
+
+	return cycle
+}
+
+func (G *TweenGroup) seek(t float32) {
+	cycle := G.cycle()
+	within, started := G.cycleTime(t, cycle)
+	if !started {
+		return
+	}
+
+	if G.parallel {
+		for _, item := range G.items {
+			item.seek(within)
+		}
+	} else {
+		starts := make([]float32, len(G.items))
+		var start float32
+		for i, item := range G.items {
+			starts[i] = start
+			start += shed.Max(item.Duration(), 0)
+		}
+
+		// Items that have not started yet are left alone, so they capture their start values when they do.
+		// Items that have, but are ahead of a yoyo going backwards, are put back at their start first,
+		// last to first, so tweens of the same value end up where the earlier ones put it.
+		for i := len(G.items) - 1; i >= 0; i-- {
+			if within < starts[i] && G.items[i].hasStarted() {
+				G.items[i].seek(0)
+			}
+		}
+		for i, item := range G.items {
+			if within >= starts[i] {
+				item.seek(within - starts[i])
+			}
+		}
+	}
+
+	G.checkComplete(t, cycle)
+}
+
+func (G *TweenGroup) hasStarted() bool {
+	for _, item := range G.items {
+		if item.hasStarted() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (G *TweenGroup) rewind() {
+	G.completed = false
+	for _, item := range G.items {
+		item.rewind()
+	}
+}
+
+// ||=============================
+// || Manager
+// ||=============================
+
+// Plays tweens. Engine.Tweens is advanced by Engine.Tick()
+type TweenManager struct {
+	playing []*playingTween
+}
+
+type playingTween struct {
+	tween   Tweener
+	elapsed float32
+}
+
+func CreateTweenManager() *TweenManager {
+	return &TweenManager{}
+}
+
+// Play a tween from the start. Restarts it if it is already playing
+func (M *TweenManager) Start(T Tweener) {
+	M.Stop(T)
+	T.rewind()
+	M.playing = append(M.playing, &playingTween{tween: T})
+}
+
+// Stop a tween where it is
+func (M *TweenManager) Stop(T Tweener) {
+	for i, p := range M.playing {
+		if p.tween == T {
+			M.playing = append(M.playing[:i], M.playing[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stop all tweens
+func (M *TweenManager) Clear() {
+	M.playing = M.playing[:0]
+}
+
+func (M *TweenManager) IsPlaying(T Tweener) bool {
+	for _, p := range M.playing {
+		if p.tween == T {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The number of tweens that are playing
+func (M *TweenManager) Len() int {
+	return len(M.playing)
+}
+
+// Advance all tweens by dt seconds. Finished tweens are removed
+func (M *TweenManager) Advance(dt float32) {
+	// Callbacks may start and stop tweens, so go through a copy
+	playing := append([]*playingTween{}, M.playing...)
+
+	for _, p := range playing {
+		if !M.isPlaying(p) {
+			continue // stopped by a callback
+		}
+
+		p.elapsed += dt
+		p.tween.seek(p.elapsed)
+
+		if total := p.tween.Duration(); total >= 0 && p.elapsed >= total && M.isPlaying(p) {
+			M.Stop(p.tween)
+		}
+	}
+}
+
+func (M *TweenManager) isPlaying(p *playingTween) bool {
+	for _, q := range M.playing {
+		if q == p {
+			return true
+		}
+	}
+
+	return false
+}
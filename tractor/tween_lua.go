@@ -0,0 +1,164 @@
+package tractor
+
+import (
+	"fmt"
+	"goat/shed"
+
+	lua "github.com/yuin/gopher-lua"
+	luar "layeh.com/gopher-luar"
+)
+
+// Make tweens available to a lua script. The tweens it starts are played by M:
+//
+//	local ship = {x = 0, y = 0}
+//	Tween(ship, {x = 100, y = 50}, 1.5, {ease = "outBounce", delay = 0.5}):Start()
+//	local fade = Tween(1, 0, 2, {onUpdate = function(v) alpha = v end, repeats = 1, yoyo = true})
+//	TweenSequence(fade, Tween(ship, {x = 0}, 1), {onComplete = function() Log("done") end}):Start()
+//	TweenParallel(...)
+//	StopAllTweens()
+//
+// Tables are tweened field by field: every number in the second table is a target value.
+// Lua errors in the callbacks are passed to onError. If it is nil, they panic.
+func (M *TweenManager) ExportToLua(L *lua.LState, onError func(error)) {
+
+	report := func(err error) {
+		if onError == nil {
+			shed.GlPanic(err)
+		}
+		onError(err)
+	}
+
+	call := func(fn *lua.LFunction, args ...lua.LValue) {
+		if err := L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, args...); err != nil {
+			report(err)
+		}
+	}
+
+	L.SetGlobal("Tween", L.NewFunction(func(L *lua.LState) int {
+		duration := float32(L.CheckNumber(3))
+		opts := L.OptTable(4, nil)
+
+		var T *Tween
+		var value func() lua.LValue // passed to onUpdate
+
+		switch target := L.CheckAny(1).(type) {
+		case lua.LNumber:
+			from := float32(target)
+			to := float32(L.CheckNumber(2))
+			current := from
+			T = CreateTween(duration, func() {}, func(p float32) { current = shed.LerpU(from, to, p) })
+			value = func() lua.LValue { return lua.LNumber(current) }
+
+		case *lua.LTable:
+			T = luaTableTween(target, L.CheckTable(2), duration, report)
+			value = func() lua.LValue { return target }
+
+		default:
+			L.ArgError(1, "a number or a table expected")
+		}
+
+		T.manager = M
+		if opts != nil {
+			if name, ok := opts.RawGetString("ease").(lua.LString); ok {
+				ease, found := shed.EaseByName(string(name))
+				if !found {
+					L.ArgError(4, fmt.Sprintf("unknown ease '%s'", name))
+				}
+				T.Ease(ease)
+			}
+			if fn, ok := opts.RawGetString("onUpdate").(*lua.LFunction); ok {
+				apply := T.apply
+				T.apply = func(p float32) {
+					apply(p)
+					call(fn, value())
+				}
+			}
+			luaTweenTiming(opts, &T.tweenTiming, call)
+		}
+
+		L.Push(luar.New(L, T))
+		return 1
+	}))
+
+	group := func(parallel bool) *lua.LFunction {
+		return L.NewFunction(func(L *lua.LState) int {
+			G := &TweenGroup{parallel: parallel}
+			G.manager = M
+
+			for i := 1; i <= L.GetTop(); i++ {
+				switch arg := L.Get(i).(type) {
+				case *lua.LUserData:
+					item, ok := arg.Value.(Tweener)
+					if !ok {
+						L.ArgError(i, "a tween expected")
+					}
+					G.items = append(G.items, item)
+				case *lua.LTable:
+					if i != L.GetTop() {
+						L.ArgError(i, "the options must come last")
+					}
+					luaTweenTiming(arg, &G.tweenTiming, call)
+				default:
+					L.ArgError(i, "a tween expected")
+				}
+			}
+
+			L.Push(luar.New(L, G))
+			return 1
+		})
+	}
+
+	L.SetGlobal("TweenSequence", group(false))
+	L.SetGlobal("TweenParallel", group(true))
+	L.SetGlobal("StopAllTweens", luar.New(L, M.Clear))
+}
+
+// Tween the number fields of target towards the ones in to.
+// Fields of target that are not numbers when the tween starts are reported, and left alone.
+func luaTableTween(target, to *lua.LTable, duration float32, report func(error)) *Tween {
+	type field struct {
+		key      lua.LValue
+		from, to float32
+	}
+	fields := []*field{}
+
+	to.ForEach(func(key, value lua.LValue) {
+		if n, ok := value.(lua.LNumber); ok {
+			fields = append(fields, &field{key: key, to: float32(n)})
+		}
+	})
+
+	return CreateTween(duration,
+		func() {
+			for _, f := range fields {
+				n, ok := target.RawGet(f.key).(lua.LNumber)
+				if !ok {
+					report(fmt.Errorf("cannot tween field '%s': it is not a number", f.key))
+					f.key = lua.LNil
+				}
+				f.from = float32(n)
+			}
+		},
+		func(p float32) {
+			for _, f := range fields {
+				if f.key != lua.LNil {
+					target.RawSet(f.key, lua.LNumber(shed.LerpU(f.from, f.to, p)))
+				}
+			}
+		},
+	)
+}
+
+// Read delay, repeats, yoyo and onComplete
+func luaTweenTiming(opts *lua.LTable, timing *tweenTiming, call func(fn *lua.LFunction, args ...lua.LValue)) {
+	if n, ok := opts.RawGetString("delay").(lua.LNumber); ok {
+		timing.delay = float32(n)
+	}
+	if n, ok := opts.RawGetString("repeats").(lua.LNumber); ok {
+		timing.repeat = int(n)
+	}
+	timing.yoyo = lua.LVAsBool(opts.RawGetString("yoyo"))
+	if fn, ok := opts.RawGetString("onComplete").(*lua.LFunction); ok {
+		timing.onComplete = func() { call(fn) }
+	}
+}
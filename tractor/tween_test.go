@@ -0,0 +1,157 @@
+package tractor
+
+import (
+	"goat/shed"
+	"testing"
+)
+
+func TestTweenTiming(t *testing.T) {
+	const dt = 0.25
+
+	tests := []struct {
+		name     string
+		build    func(x, y *float32, done func()) Tweener
+		duration float32
+		values   []shed.V2 // x and y after every step of dt
+		forever  bool      // still playing at the end, and never completes
+	}{
+		{"plain", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).OnComplete(done)
+		}, 1, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4}}, false},
+
+		{"zero duration", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 0).OnComplete(done)
+		}, 0, []shed.V2{{X: 4}}, false},
+
+		{"delay", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).Delay(0.5).OnComplete(done)
+		}, 1.5, []shed.V2{{X: 0}, {X: 0}, {X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4}}, false},
+
+		// Every play starts from the value captured at the start of the first
+		{"repeat", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).Repeat(1).OnComplete(done)
+		}, 2, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 0}, {X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4}}, false},
+
+		// An even number of plays ends where it started
+		{"yoyo", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).Repeat(1).Yoyo(true).OnComplete(done)
+		}, 2, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 3}, {X: 2}, {X: 1}, {X: 0}, {X: 0}}, false},
+
+		{"yoyo, odd number of plays", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).Repeat(2).Yoyo(true).OnComplete(done)
+		}, 3, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 3}, {X: 2}, {X: 1}, {X: 0}, {X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4}}, false},
+
+		{"yoyo forever", func(x, y *float32, done func()) Tweener {
+			return TweenFloat(x, 4, 1).Repeat(-1).Yoyo(true).OnComplete(done)
+		}, -1, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 3}, {X: 2}, {X: 1}, {X: 0}, {X: 1}}, true},
+
+		// The second tween captures its start value where the first one ended
+		{"sequence", func(x, y *float32, done func()) Tweener {
+			return TweenSequence(TweenFloat(x, 4, 1), TweenFloat(x, 0, 0.5)).OnComplete(done)
+		}, 1.5, []shed.V2{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 2}, {X: 0}, {X: 0}}, false},
+
+		{"sequence with a delayed item", func(x, y *float32, done func()) Tweener {
+			return TweenSequence(TweenFloat(x, 4, 1).Delay(0.5), TweenFloat(y, 2, 0.5)).OnComplete(done)
+		}, 2, []shed.V2{{}, {}, {X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4, Y: 1}, {X: 4, Y: 2}}, false},
+
+		{"parallel", func(x, y *float32, done func()) Tweener {
+			return TweenParallel(TweenFloat(x, 4, 1), TweenFloat(y, 2, 0.5)).OnComplete(done)
+		}, 1, []shed.V2{{X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 2}, {X: 4, Y: 2}, {X: 4, Y: 2}}, false},
+
+		{"delayed group", func(x, y *float32, done func()) Tweener {
+			return TweenParallel(TweenFloat(x, 4, 1)).Delay(0.5).OnComplete(done)
+		}, 1.5, []shed.V2{{X: 0}, {X: 0}, {X: 1}, {X: 2}, {X: 3}, {X: 4}}, false},
+
+		// Going backwards, the second tween is undone before the first one
+		{"sequence yoyo", func(x, y *float32, done func()) Tweener {
+			return TweenSequence(TweenFloat(x, 4, 1), TweenFloat(y, 2, 0.5)).Repeat(1).Yoyo(true).OnComplete(done)
+		}, 3, []shed.V2{
+			{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 4, Y: 1}, {X: 4, Y: 2},
+			{X: 4, Y: 1}, {X: 4}, {X: 3}, {X: 2}, {X: 1}, {X: 0}, {X: 0},
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var x, y float32
+			completed := 0
+			T := test.build(&x, &y, func() { completed++ })
+
+			if d := T.Duration(); d != test.duration {
+				t.Fatalf("the duration is %v, expected %v", d, test.duration)
+			}
+
+			M := CreateTweenManager()
+			M.Start(T)
+
+			for i, want := range test.values {
+				M.Advance(dt)
+				if x != want.X || y != want.Y {
+					t.Fatalf("after %v seconds, x and y are %v, %v, expected %v, %v", float32(i+1)*dt, x, y, want.X, want.Y)
+				}
+			}
+
+			// Long after the end
+			for i := 0; i < 20; i++ {
+				M.Advance(dt)
+			}
+
+			if test.forever {
+				if !M.IsPlaying(T) || completed != 0 {
+					t.Fatalf("a tween that goes on forever stopped, or completed %d times", completed)
+				}
+				return
+			}
+			if M.IsPlaying(T) || M.Len() != 0 {
+				t.Fatalf("the tween is still playing")
+			}
+			if completed != 1 {
+				t.Fatalf("onComplete was called %d times, expected once", completed)
+			}
+			if last := test.values[len(test.values)-1]; x != last.X || y != last.Y {
+				t.Fatalf("the values moved after the end, to %v, %v", x, y)
+			}
+		})
+	}
+}
+
+// The start value is whatever the value is when the delay is over, not when the tween was started
+func TestTweenCapturesAfterDelay(t *testing.T) {
+	x := float32(0)
+	M := CreateTweenManager()
+	M.Start(TweenFloat(&x, 4, 1).Delay(0.5))
+
+	M.Advance(0.25)
+	x = 2 // moved by something else during the delay
+	M.Advance(0.25)
+	M.Advance(0.5)
+
+	if x != 3 {
+		t.Fatalf("halfway from 2 to 4, x is %v", x)
+	}
+}
+
+// Starting a tween again plays it from the start, with a newly captured start value
+func TestTweenRestart(t *testing.T) {
+	x := float32(0)
+	completed := 0
+	M := CreateTweenManager()
+	T := TweenFloat(&x, 4, 1).OnComplete(func() { completed++ })
+
+	M.Start(T)
+	M.Advance(0.5)
+	M.Start(T)
+	if M.Len() != 1 {
+		t.Fatalf("%d tweens are playing after a restart", M.Len())
+	}
+
+	M.Advance(0.5)
+	if x != 3 {
+		t.Fatalf("halfway from 2 to 4, x is %v", x)
+	}
+
+	M.Advance(0.5)
+	if x != 4 || completed != 1 {
+		t.Fatalf("x ended at %v, and completed %d times", x, completed)
+	}
+}
@@ -0,0 +1,65 @@
+package vroom
+
+import (
+	"path"
+
+	lua "github.com/yuin/gopher-lua"
+	luar "layeh.com/gopher-luar"
+)
+
+// Make the mixer available to a lua script:
+//
+//	local laser = LoadSound("Bonus/sfx_laser1.ogg")   -- optional. PlaySound loads on demand
+//	local voice = PlaySound("Bonus/sfx_laser1.ogg", {volume = 0.5, pitch = 1.2, pan = -1, loop = false})
+//	voice:SetVolume(0.2)
+//	voice:Stop()
+//	StopAllSounds()
+//	MasterVolume(0.8)
+//
+// Sound files are loaded relative to assetPath, and cached.
+func (M *Mixer) ExportToLua(L *lua.LState, assetPath string) {
+	sounds := make(map[string]*Sound)
+
+	load := func(L *lua.LState, name string) *Sound {
+		if sound, found := sounds[name]; found {
+			return sound
+		}
+
+		sound, err := LoadSound(path.Join(assetPath, name))
+		if err != nil {
+			L.RaiseError("%v", err)
+		}
+		sounds[name] = sound
+
+		return sound
+	}
+
+	L.SetGlobal("LoadSound", L.NewFunction(func(L *lua.LState) int {
+		L.Push(luar.New(L, load(L, L.CheckString(1))))
+		return 1
+	}))
+
+	L.SetGlobal("PlaySound", L.NewFunction(func(L *lua.LState) int {
+		sound := load(L, L.CheckString(1))
+		V := M.Play(sound)
+
+		if opts := L.OptTable(2, nil); opts != nil {
+			if v, ok := opts.RawGetString("volume").(lua.LNumber); ok {
+				V.SetVolume(float32(v))
+			}
+			if v, ok := opts.RawGetString("pitch").(lua.LNumber); ok {
+				V.SetPitch(float32(v))
+			}
+			if v, ok := opts.RawGetString("pan").(lua.LNumber); ok {
+				V.SetPan(float32(v))
+			}
+			V.SetLoop(lua.LVAsBool(opts.RawGetString("loop")))
+		}
+
+		L.Push(luar.New(L, V))
+		return 1
+	}))
+
+	L.SetGlobal("StopAllSounds", luar.New(L, M.StopAll))
+	L.SetGlobal("MasterVolume", luar.New(L, M.SetVolume))
+}
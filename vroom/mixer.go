@@ -0,0 +1,320 @@
+package vroom
+
+import (
+	"sync"
+)
+
+// =========================================================================
+// ||
+// || Mixer.
+// ||
+// || Plays any number of sounds at once, and writes the result to a Sink
+// || as interleaved stereo.
+// ||
+// || Push style: call Advance() once per frame with the frame's delta time,
+// || and the mixer writes exactly that much audio to the sink.
+// || Pull style: call Mix() from an audio callback, and leave the sink nil.
+// ||
+// || The mixer is safe to use from several goroutines.
+// ||
+// =========================================================================
+type Mixer struct {
+	SampleRate int // Output sample rate, for instance 44100
+
+	mu      sync.Mutex
+	sink    Sink
+	voices  []*Voice
+	volume  float32   // master volume
+	pending float64   // fraction of a frame that Advance() has not mixed yet
+	buf     []float32 // reused by Advance()
+}
+
+func CreateMixer(sampleRate int, sink Sink) *Mixer {
+	return &Mixer{
+		SampleRate: sampleRate,
+		sink:       sink,
+		volume:     1,
+	}
+}
+
+// Replace the sink. The old sink is not closed.
+func (M *Mixer) SetSink(sink Sink) {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	M.sink = sink
+}
+
+// Master volume. 1 is unchanged.
+func (M *Mixer) SetVolume(volume float32) {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	M.volume = volume
+}
+
+func (M *Mixer) Volume() float32 {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	return M.volume
+}
+
+// Start playing a sound, at full volume, normal pitch, centered, not looping.
+// Use the returned voice to change that.
+func (M *Mixer) Play(sound *Sound) *Voice {
+	V := &Voice{
+		mixer:  M,
+		sound:  sound,
+		volume: 1,
+		pitch:  1,
+	}
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	M.voices = append(M.voices, V)
+
+	return V
+}
+
+// Stop all voices
+func (M *Mixer) StopAll() {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	for _, V := range M.voices {
+		V.stopped = true
+	}
+	M.voices = M.voices[:0]
+}
+
+// Number of voices currently playing
+func (M *Mixer) Playing() int {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	return len(M.voices)
+}
+
+// Mix the given number of seconds of audio, and write it to the sink.
+// Fractions of a sample frame are carried over to the next call,
+// so calling it with Engine.Delta64 every frame does not drift.
+func (M *Mixer) Advance(seconds float64) error {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	frames := seconds*float64(M.SampleRate) + M.pending
+	n := int(frames)
+	M.pending = frames - float64(n)
+
+	if n <= 0 {
+		return nil
+	}
+
+	if cap(M.buf) < 2*n {
+		M.buf = make([]float32, 2*n)
+	}
+	buf := M.buf[:2*n]
+
+	M.mix(buf)
+
+	if M.sink == nil {
+		return nil
+	}
+
+	return M.sink.Write(buf)
+}
+
+// Fill out with interleaved stereo samples.
+func (M *Mixer) Mix(out []float32) {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	M.mix(out)
+}
+
+// Close the sink
+func (M *Mixer) Close() error {
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	if M.sink == nil {
+		return nil
+	}
+
+	return M.sink.Close()
+}
+
+func (M *Mixer) mix(out []float32) {
+	for i := range out {
+		out[i] = 0
+	}
+
+	playing := M.voices[:0]
+	for _, V := range M.voices {
+		if V.mix(out, M.SampleRate) {
+			playing = append(playing, V)
+		}
+	}
+
+	// let go of the finished voices
+	for i := len(playing); i < len(M.voices); i++ {
+		M.voices[i] = nil
+	}
+	M.voices = playing
+
+	for i, s := range out {
+		out[i] = clampSample(s * M.volume)
+	}
+}
+
+// =========================================================================
+// ||
+// || Voice.
+// ||
+// || A sound being played by a mixer.
+// ||
+// =========================================================================
+type Voice struct {
+	mixer   *Mixer
+	sound   *Sound
+	pos     float64 // in frames of the sound
+	volume  float32 // 1 is unchanged
+	pitch   float32 // 1 is unchanged. 2 is an octave up, and twice as fast
+	pan     float32 // -1 is left, 0 is center, 1 is right
+	loop    bool
+	stopped bool
+}
+
+func (V *Voice) SetVolume(volume float32) {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	V.volume = volume
+}
+
+func (V *Voice) SetPitch(pitch float32) {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	V.pitch = pitch
+}
+
+func (V *Voice) SetPan(pan float32) {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	V.pan = clampSample(pan)
+}
+
+func (V *Voice) SetLoop(loop bool) {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	V.loop = loop
+}
+
+// Stop the voice, right away. It cannot be restarted; play the sound again instead.
+func (V *Voice) Stop() {
+	M := V.mixer
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	V.stopped = true
+
+	for i, other := range M.voices {
+		if other == V {
+			last := len(M.voices) - 1
+			copy(M.voices[i:], M.voices[i+1:])
+			M.voices[last] = nil
+			M.voices = M.voices[:last]
+			break
+		}
+	}
+}
+
+// Is the voice still playing?
+func (V *Voice) IsPlaying() bool {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	return !V.stopped
+}
+
+// How far the voice has come, in seconds of the sound
+func (V *Voice) Position() float64 {
+	V.mixer.mu.Lock()
+	defer V.mixer.mu.Unlock()
+
+	return V.pos / float64(V.sound.SampleRate)
+}
+
+// Add the voice to out. Returns false when the voice is done.
+func (V *Voice) mix(out []float32, sampleRate int) bool {
+	if V.stopped {
+		return false
+	}
+
+	S := V.sound
+	frames := S.Frames()
+	if frames == 0 {
+		V.stopped = true // nothing to play: done right away
+		return false
+	}
+	if V.pitch <= 0 {
+		return true // a pitch of zero pauses the voice
+	}
+
+	step := float64(S.SampleRate) / float64(sampleRate) * float64(V.pitch)
+
+	left := V.volume * min32(1, 1-V.pan)
+	right := V.volume * min32(1, 1+V.pan)
+
+	for i := 0; i+1 < len(out); i += 2 {
+		i0 := int(V.pos)
+		i1 := i0 + 1
+		if i1 >= frames {
+			if V.loop {
+				i1 = 0
+			} else {
+				i1 = i0
+			}
+		}
+		frac := float32(V.pos - float64(i0))
+
+		if S.Channels == 1 {
+			s := lerp(S.Samples[i0], S.Samples[i1], frac)
+			out[i] += s * left
+			out[i+1] += s * right
+		} else {
+			out[i] += lerp(S.Samples[2*i0], S.Samples[2*i1], frac) * left
+			out[i+1] += lerp(S.Samples[2*i0+1], S.Samples[2*i1+1], frac) * right
+		}
+
+		V.pos += step
+		if V.pos >= float64(frames) {
+			if !V.loop {
+				V.stopped = true
+				return false
+			}
+			for V.pos >= float64(frames) {
+				V.pos -= float64(frames)
+			}
+		}
+	}
+
+	return true
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,141 @@
+package vroom
+
+import (
+	"testing"
+)
+
+// A mono sound of n frames, all at the same level
+func constantSound(sampleRate, n int, level float32) *Sound {
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = level
+	}
+	return &Sound{SampleRate: sampleRate, Channels: 1, Samples: samples}
+}
+
+func TestMixerAdvanceDoesNotDrift(t *testing.T) {
+	sink := &NullSink{}
+	M := CreateMixer(44100, sink)
+
+	for i := 0; i < 60; i++ {
+		if err := M.Advance(1.0 / 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if sink.Frames < 44099 || sink.Frames > 44100 {
+		t.Fatalf("a second of audio is %d frames", sink.Frames)
+	}
+}
+
+func TestMixerVoiceFinishes(t *testing.T) {
+	M := CreateMixer(1000, &NullSink{})
+	V := M.Play(constantSound(1000, 100, 0.5)) // a tenth of a second
+
+	M.Advance(0.05)
+	if !V.IsPlaying() || M.Playing() != 1 {
+		t.Fatalf("the voice stopped half way")
+	}
+
+	M.Advance(0.1)
+	if V.IsPlaying() || M.Playing() != 0 {
+		t.Fatalf("the voice is still playing after the sound ended")
+	}
+}
+
+func TestMixerEmptySoundFinishes(t *testing.T) {
+	M := CreateMixer(1000, &NullSink{})
+	V := M.Play(constantSound(1000, 0, 0.5))
+	V.SetLoop(true) // not even a loop of nothing plays forever
+
+	M.Advance(0.01)
+	if V.IsPlaying() || M.Playing() != 0 {
+		t.Fatalf("a sound without frames is still playing")
+	}
+}
+
+func TestMixerLoopKeepsPlaying(t *testing.T) {
+	M := CreateMixer(1000, &NullSink{})
+	V := M.Play(constantSound(1000, 100, 0.5))
+	V.SetLoop(true)
+
+	M.Advance(1)
+	if !V.IsPlaying() {
+		t.Fatalf("the looping voice stopped")
+	}
+	if pos := V.Position(); pos < 0 || pos >= 0.1 {
+		t.Fatalf("the looping voice is at %v seconds of a 0.1 second sound", pos)
+	}
+}
+
+func TestVoiceStopIsImmediate(t *testing.T) {
+	M := CreateMixer(1000, nil)
+	A := M.Play(constantSound(1000, 100, 0.25))
+	B := M.Play(constantSound(1000, 100, 0.5))
+
+	B.Stop()
+	if B.IsPlaying() || M.Playing() != 1 {
+		t.Fatalf("a stopped voice still counts: playing %v, %d voices", B.IsPlaying(), M.Playing())
+	}
+	B.Stop() // again does nothing
+
+	out := make([]float32, 2)
+	M.Mix(out)
+	if out[0] != 0.25 || out[1] != 0.25 {
+		t.Fatalf("expected only the voice that plays, got %v", out)
+	}
+
+	A.Stop()
+	if M.Playing() != 0 {
+		t.Fatalf("%d voices after stopping both", M.Playing())
+	}
+}
+
+func TestMixerPanAndVolume(t *testing.T) {
+	tests := []struct {
+		name        string
+		volume, pan float32
+		master      float32
+		left, right float32
+	}{
+		{"centered", 1, 0, 1, 0.5, 0.5},
+		{"left", 1, -1, 1, 0.5, 0},
+		{"right", 1, 1, 1, 0, 0.5},
+		{"half volume", 0.5, 0, 1, 0.25, 0.25},
+		{"master volume", 1, 0, 0.5, 0.25, 0.25},
+		{"clipped", 4, 0, 1, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			M := CreateMixer(1000, nil)
+			M.SetVolume(test.master)
+			V := M.Play(constantSound(1000, 100, 0.5))
+			V.SetVolume(test.volume)
+			V.SetPan(test.pan)
+
+			out := make([]float32, 2)
+			M.Mix(out)
+			if out[0] != test.left || out[1] != test.right {
+				t.Fatalf("got %v, expected [%v %v]", out, test.left, test.right)
+			}
+		})
+	}
+}
+
+func TestMixerStopAll(t *testing.T) {
+	M := CreateMixer(1000, nil)
+	A := M.Play(constantSound(1000, 100, 0.5))
+	M.Play(constantSound(1000, 100, 0.5))
+
+	M.StopAll()
+	if A.IsPlaying() || M.Playing() != 0 {
+		t.Fatalf("voices still playing after StopAll")
+	}
+
+	out := []float32{1, 1}
+	M.Mix(out)
+	if out[0] != 0 || out[1] != 0 {
+		t.Fatalf("silence expected, got %v", out)
+	}
+}
@@ -0,0 +1,120 @@
+package vroom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Where the mixed audio goes.
+// Samples are interleaved stereo (L R L R ...) float32 in the range [-1, 1].
+//
+// Sound cards are driven by implementing this interface on top of an audio library.
+type Sink interface {
+	Write(samples []float32) error
+	Close() error
+}
+
+// =========================================================================
+// ||
+// || Null Sink.
+// ||
+// || Throws the audio away, but keeps statistics.
+// || Useful for headless runs and tests.
+// ||
+// =========================================================================
+type NullSink struct {
+	Frames uint64  // Number of stereo frames written so far
+	Peak   float32 // The largest absolute sample value written so far
+}
+
+func (S *NullSink) Write(samples []float32) error {
+	S.Frames += uint64(len(samples) / 2)
+
+	for _, s := range samples {
+		if s < 0 {
+			s = -s
+		}
+		if s > S.Peak {
+			S.Peak = s
+		}
+	}
+
+	return nil
+}
+
+func (S *NullSink) Close() error {
+	return nil
+}
+
+// =========================================================================
+// ||
+// || Wav File Sink.
+// ||
+// || Records the audio to a 16 bit stereo wav file.
+// || The file is not valid until the sink is closed.
+// ||
+// =========================================================================
+type WavFileSink struct {
+	file       *os.File
+	sampleRate int
+	dataSize   uint32
+	buf        []byte
+}
+
+func CreateWavFileSink(filePath string, sampleRate int) (*WavFileSink, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not create file '%s' - %v", filePath, err)
+	}
+
+	// placeholder header. The sizes are filled in by Close()
+	if err := writeWavHeader(f, sampleRate, 2, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &WavFileSink{file: f, sampleRate: sampleRate}, nil
+}
+
+func (S *WavFileSink) Write(samples []float32) error {
+	if cap(S.buf) < len(samples)*2 {
+		S.buf = make([]byte, len(samples)*2)
+	}
+	buf := S.buf[:len(samples)*2]
+
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(buf[2*i:], uint16(int16(clampSample(s)*32767)))
+	}
+
+	n, err := S.file.Write(buf)
+	S.dataSize += uint32(n)
+
+	return err
+}
+
+func (S *WavFileSink) Close() error {
+	if _, err := S.file.Seek(0, io.SeekStart); err != nil {
+		S.file.Close()
+		return err
+	}
+
+	if err := writeWavHeader(S.file, S.sampleRate, 2, S.dataSize); err != nil {
+		S.file.Close()
+		return err
+	}
+
+	return S.file.Close()
+}
+
+func clampSample(s float32) float32 {
+	if s > 1 {
+		return 1
+	}
+	if s < -1 {
+		return -1
+	}
+
+	return s
+}
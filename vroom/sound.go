@@ -0,0 +1,101 @@
+package vroom
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// =========================================================================
+// ||
+// || Sound.
+// ||
+// || A fully decoded sound, kept in memory.
+// || Samples are float32 in the range [-1, 1], interleaved by channel,
+// || at the sound's own sample rate. The mixer resamples on the fly.
+// ||
+// =========================================================================
+type Sound struct {
+	Name       string
+	SampleRate int
+	Channels   int       // 1 (mono) or 2 (stereo)
+	Samples    []float32 // interleaved: L R L R ... for stereo
+}
+
+// Number of sample frames (one sample per channel)
+func (S *Sound) Frames() int {
+	return len(S.Samples) / S.Channels
+}
+
+// Length of the sound, in seconds
+func (S *Sound) Duration() float64 {
+	return float64(S.Frames()) / float64(S.SampleRate)
+}
+
+// Load a .ogg or .wav file, depending on its extension
+func LoadSound(filePath string) (*Sound, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open sound file '%s' - %v", filePath, err)
+	}
+	defer f.Close()
+
+	var sound *Sound
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ogg":
+		sound, err = DecodeOgg(f)
+	case ".wav":
+		sound, err = DecodeWav(f)
+	default:
+		return nil, fmt.Errorf("unsupported sound file '%s'. Only .ogg and .wav are supported", filePath)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not decode sound file '%s' - %v", filePath, err)
+	}
+
+	sound.Name = filePath
+
+	return sound, nil
+}
+
+// Decode an entire Ogg Vorbis stream
+func DecodeOgg(r io.Reader) (*Sound, error) {
+	samples, format, err := oggvorbis.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSound(format.SampleRate, format.Channels, samples)
+}
+
+func createSound(sampleRate, channels int, samples []float32) (*Sound, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", sampleRate)
+	}
+
+	// Only mono and stereo are mixed. Keep the first two channels of anything else.
+	if channels > 2 {
+		frames := len(samples) / channels
+		stereo := make([]float32, frames*2)
+		for i := 0; i < frames; i++ {
+			stereo[2*i] = samples[i*channels]
+			stereo[2*i+1] = samples[i*channels+1]
+		}
+		samples, channels = stereo, 2
+	}
+
+	if channels < 1 {
+		return nil, fmt.Errorf("invalid channel count %d", channels)
+	}
+
+	return &Sound{
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Samples:    samples[:len(samples)/channels*channels],
+	}, nil
+}
@@ -0,0 +1,144 @@
+package vroom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+
+	wavMaxFmtSize = 1024 // fmt chunks are 16 to 40 bytes. Anything much bigger is not a wav file
+)
+
+// Decode a RIFF WAVE stream.
+// Supports 8, 16, 24 and 32 bit integer PCM, and 32 bit float.
+func DecodeWav(r io.Reader) (*Sound, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a wav file")
+	}
+
+	var format, channels, bits uint16
+	var sampleRate uint32
+	haveFormat := false
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("no data chunk")
+		}
+
+		id := string(header[0:4])
+		size := binary.LittleEndian.Uint32(header[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > wavMaxFmtSize {
+				return nil, fmt.Errorf("invalid fmt chunk")
+			}
+			chunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil, fmt.Errorf("invalid fmt chunk")
+			}
+			format = binary.LittleEndian.Uint16(chunk[0:])
+			channels = binary.LittleEndian.Uint16(chunk[2:])
+			sampleRate = binary.LittleEndian.Uint32(chunk[4:])
+			bits = binary.LittleEndian.Uint16(chunk[14:])
+			if format == wavFormatExtensible && size >= 26 {
+				format = binary.LittleEndian.Uint16(chunk[24:]) // first two bytes of the sub format GUID
+			}
+			haveFormat = true
+
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			// The size is only a promise: read what is there, up to it. Truncated files still play
+			data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+			if err != nil {
+				return nil, err
+			}
+
+			samples, err := decodeWavSamples(data, format, bits)
+			if err != nil {
+				return nil, err
+			}
+
+			return createSound(int(sampleRate), int(channels), samples)
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return nil, fmt.Errorf("no data chunk")
+			}
+		}
+	}
+}
+
+func decodeWavSamples(data []byte, format, bits uint16) ([]float32, error) {
+	bytesPerSample := int(bits) / 8
+	if bytesPerSample == 0 {
+		return nil, fmt.Errorf("invalid bit depth %d", bits)
+	}
+
+	samples := make([]float32, len(data)/bytesPerSample)
+
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		for i := range samples {
+			samples[i] = (float32(data[i]) - 128) / 128
+		}
+	case format == wavFormatPCM && bits == 16:
+		for i := range samples {
+			samples[i] = float32(int16(binary.LittleEndian.Uint16(data[2*i:]))) / 32768
+		}
+	case format == wavFormatPCM && bits == 24:
+		for i := range samples {
+			b := data[3*i:]
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			samples[i] = float32(v) / 8388608
+		}
+	case format == wavFormatPCM && bits == 32:
+		for i := range samples {
+			samples[i] = float32(int32(binary.LittleEndian.Uint32(data[4*i:]))) / 2147483648
+		}
+	case format == wavFormatFloat && bits == 32:
+		for i := range samples {
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported wav format %d with %d bits per sample", format, bits)
+	}
+
+	return samples, nil
+}
+
+// Write the header of a 16 bit PCM wav file.
+// dataSize is the number of bytes of sample data that follow.
+func writeWavHeader(w io.Writer, sampleRate, channels int, dataSize uint32) error {
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataSize)
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*channels*2)) // bytes per second
+	binary.LittleEndian.PutUint16(header[32:], uint16(channels*2))            // bytes per frame
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataSize)
+
+	_, err := w.Write(header)
+
+	return err
+}
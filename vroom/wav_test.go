@@ -0,0 +1,116 @@
+package vroom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// A chunk: id, size, and the bytes that follow. The size need not match them
+func wavChunk(id string, size uint32, data []byte) []byte {
+	chunk := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], size)
+	return append(chunk, data...)
+}
+
+func wavFmt(format, channels uint16, sampleRate uint32, bits uint16) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data[0:], format)
+	binary.LittleEndian.PutUint16(data[2:], channels)
+	binary.LittleEndian.PutUint32(data[4:], sampleRate)
+	binary.LittleEndian.PutUint16(data[14:], bits)
+	return wavChunk("fmt ", 16, data)
+}
+
+func wavFile(chunks ...[]byte) []byte {
+	file := []byte("RIFF\x00\x00\x00\x00WAVE")
+	for _, chunk := range chunks {
+		file = append(file, chunk...)
+	}
+	return file
+}
+
+func TestDecodeWav(t *testing.T) {
+	samples := []byte{0x00, 0x40, 0x00, 0xc0} // 0.5, -0.5 as 16 bits
+
+	tests := []struct {
+		name    string
+		file    []byte
+		samples []float32
+		wantErr string // empty: no error
+	}{
+		{"16 bit", wavFile(wavFmt(wavFormatPCM, 1, 8000, 16), wavChunk("data", 4, samples)), []float32{0.5, -0.5}, ""},
+		{"8 bit", wavFile(wavFmt(wavFormatPCM, 1, 8000, 8), wavChunk("data", 2, []byte{192, 64})), []float32{0.5, -0.5}, ""},
+		{"other chunks are skipped", wavFile(wavChunk("LIST", 3, []byte{1, 2, 3, 0}), wavFmt(wavFormatPCM, 1, 8000, 16),
+			wavChunk("data", 4, samples)), []float32{0.5, -0.5}, ""},
+		{"truncated data plays what is there", wavFile(wavFmt(wavFormatPCM, 1, 8000, 16), wavChunk("data", 0xffffffff, samples)),
+			[]float32{0.5, -0.5}, ""},
+		{"not a wav", []byte("RIFF\x00\x00\x00\x00AVI "), nil, "not a wav"},
+		{"empty", nil, nil, "EOF"},
+		{"no data", wavFile(wavFmt(wavFormatPCM, 1, 8000, 16)), nil, "no data chunk"},
+		{"data before fmt", wavFile(wavChunk("data", 4, samples)), nil, "before fmt"},
+		{"short fmt", wavFile(wavChunk("fmt ", 8, make([]byte, 8))), nil, "invalid fmt"},
+		{"huge fmt", wavFile(wavChunk("fmt ", 0xffffffff, make([]byte, 16))), nil, "invalid fmt"},
+		{"truncated fmt", wavFile(wavChunk("fmt ", 16, make([]byte, 10))), nil, "invalid fmt"},
+		{"huge unknown chunk", wavFile(wavChunk("junk", 0xffffffff, make([]byte, 16))), nil, "no data chunk"},
+		{"no channels", wavFile(wavFmt(wavFormatPCM, 0, 8000, 16), wavChunk("data", 4, samples)), nil, "channel"},
+		{"no sample rate", wavFile(wavFmt(wavFormatPCM, 1, 0, 16), wavChunk("data", 4, samples)), nil, "sample rate"},
+		{"no bits", wavFile(wavFmt(wavFormatPCM, 1, 8000, 0), wavChunk("data", 4, samples)), nil, "bit depth"},
+		{"12 bits", wavFile(wavFmt(wavFormatPCM, 1, 8000, 12), wavChunk("data", 4, samples)), nil, "unsupported"},
+		{"compressed", wavFile(wavFmt(2, 1, 8000, 16), wavChunk("data", 4, samples)), nil, "unsupported"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			S, err := DecodeWav(bytes.NewReader(test.file))
+			switch {
+			case test.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.wantErr != "" && err == nil:
+				t.Fatalf("expected an error containing %q", test.wantErr)
+			case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+				t.Fatalf("error %q does not contain %q", err, test.wantErr)
+			}
+			if test.wantErr != "" {
+				return
+			}
+
+			if len(S.Samples) != len(test.samples) {
+				t.Fatalf("expected %d samples, got %v", len(test.samples), S.Samples)
+			}
+			for i, s := range test.samples {
+				if S.Samples[i] != s {
+					t.Fatalf("sample %d is %v, expected %v", i, S.Samples[i], s)
+				}
+			}
+		})
+	}
+}
+
+// What the wav file sink writes can be read back
+func TestWavFileSinkRoundTrip(t *testing.T) {
+	filename := t.TempDir() + "/out.wav"
+
+	sink, err := CreateWavFileSink(filename, 8000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write([]float32{0.5, -0.5, 0.25, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	S, err := LoadSound(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if S.SampleRate != 8000 || S.Channels != 2 || S.Frames() != 2 {
+		t.Fatalf("read %d frames of %d channels at %d Hz", S.Frames(), S.Channels, S.SampleRate)
+	}
+	if S.Samples[0] < 0.49 || S.Samples[1] > -0.49 || S.Samples[2] < 0.24 {
+		t.Fatalf("unexpected samples %v", S.Samples)
+	}
+}